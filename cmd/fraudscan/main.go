@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/quickswap/quickswap/internal/fraud"
+)
+
+// fraudscan runs the shill-bidding analyzer over the bid ledger and writes
+// suspicious bidder–seller pairs to the review queue.
+func main() {
+	threshold := flag.Int("threshold", fraud.DefaultConfig.ReviewThreshold, "minimum risk score to queue for review")
+	dryRun := flag.Bool("dry-run", false, "print cases without writing to the review queue")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Note: .env file not found, using env vars")
+	}
+
+	cfg := fraud.DefaultConfig
+	cfg.ReviewThreshold = *threshold
+
+	var cases []fraud.Case
+	if *dryRun {
+		ledger, err := fraud.LoadLedger()
+		if err != nil {
+			log.Fatal(err)
+		}
+		cases = fraud.Analyze(ledger, cfg)
+	} else {
+		var err error
+		if cases, err = fraud.Scan(cfg); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(cases); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Flagged %d bidder–seller pairs", len(cases))
+}
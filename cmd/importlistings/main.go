@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/quickswap/quickswap/internal/bulkimport"
+	"github.com/quickswap/quickswap/internal/handlers"
+)
+
+// importlistings creates listings in bulk from a CSV or JSON lines file on
+// behalf of a seller and prints the per-row report.
+func main() {
+	sellerID := flag.String("seller", "", "seller user ID the listings belong to (required)")
+	formatName := flag.String("format", "", "csv or jsonl (default: from the file extension)")
+	dryRun := flag.Bool("dry-run", false, "validate every row without creating listings")
+	endsAt := flag.String("ends-at", "", "RFC3339 auction end time for rows without one")
+	stagger := flag.Duration("stagger", 0, "spacing between consecutive rows' end times, e.g. 5m")
+	flag.Parse()
+
+	if *sellerID == "" || flag.NArg() != 1 {
+		log.Fatal("usage: importlistings -seller <user-id> [-dry-run] [-ends-at T] [-stagger 5m] <file>")
+	}
+	path := flag.Arg(0)
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Note: .env file not found, using env vars")
+	}
+
+	if *formatName == "" {
+		*formatName = filepath.Ext(path)
+		if len(*formatName) > 0 {
+			*formatName = (*formatName)[1:]
+		}
+	}
+	format, err := bulkimport.ParseFormat(*formatName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := bulkimport.Options{DryRun: *dryRun, Stagger: *stagger}
+	if *endsAt != "" {
+		if opts.EndsAt, err = time.Parse(time.RFC3339, *endsAt); err != nil {
+			log.Fatalf("invalid -ends-at: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	rows, err := bulkimport.Parse(f, format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	job, built, err := bulkimport.Start(*sellerID, format, rows, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !opts.DryRun {
+		bulkimport.Publish = handlers.PublishListing
+		bulkimport.Run(context.Background(), job, built)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(job); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%d of %d rows succeeded, %d failed", job.Succeeded, job.Total, job.Failed)
+}
@@ -5,10 +5,27 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/bulkimport"
 	"github.com/quickswap/quickswap/internal/db"
+	"github.com/quickswap/quickswap/internal/disputes"
+	"github.com/quickswap/quickswap/internal/drafts"
+	"github.com/quickswap/quickswap/internal/events"
+	"github.com/quickswap/quickswap/internal/fulfilment"
+	"github.com/quickswap/quickswap/internal/fx"
 	"github.com/quickswap/quickswap/internal/handlers"
+	"github.com/quickswap/quickswap/internal/ledger"
+	"github.com/quickswap/quickswap/internal/notifications"
+	"github.com/quickswap/quickswap/internal/offers"
+	"github.com/quickswap/quickswap/internal/payments"
+	"github.com/quickswap/quickswap/internal/savedsearch"
+	"github.com/quickswap/quickswap/internal/secondchance"
+	"github.com/quickswap/quickswap/internal/settlement"
+	"github.com/quickswap/quickswap/internal/strikes"
+	"github.com/quickswap/quickswap/internal/watchlist"
 
 )
 
@@ -22,6 +39,10 @@ func main() {
 	if url == "" || key == "" {
 		log.Fatal("SUPABASE_URL and SUPABASE_ANON_KEY must be set")
 	}
+	// Bid history and questions show bidders by keyed pseudonym
+	if err := ledger.CheckHandleSecret(); err != nil {
+		log.Fatal(err)
+	}
 
 	authClient := auth.NewClient(url, key)
 
@@ -42,6 +63,59 @@ func main() {
 		defer redisClient.Close()
 	}
 
+	// Deliver notifications for domain events
+	notifier := notifications.NewService(notifications.ChannelsFromEnv()...)
+	notifier.Subscribe(events.Default)
+
+	// Remind watchers when auctions enter their final hour
+	watchlist.OnReminder = func(rem watchlist.Reminder) {
+		events.Publish(events.Event{
+			Type:      events.EndingSoon,
+			UserID:    rem.UserID,
+			ListingID: rem.ListingID,
+			Data: map[string]string{
+				"title":   rem.Title,
+				"ends_at": rem.AuctionEndTime.Format(time.RFC1123),
+			},
+		})
+	}
+	go watchlist.RunReminders(ctx, time.Minute)
+
+	// Settle auctions once they end
+	go settlement.Run(ctx, time.Minute)
+
+	// Take payment for sales (STRIPE_SECRET_KEY selects Stripe) and mark
+	// orders unpaid once their deadline passes, striking the buyer
+	provider, err := payments.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	payments.Default = provider
+	payments.OnOverdue = strikes.HandleOverdue
+	go payments.Run(ctx, time.Minute)
+
+	// Release escrow to sellers once buyers confirm delivery or time out
+	go fulfilment.Run(ctx, time.Minute)
+
+	// Send disputes the seller didn't answer in time for review
+	go disputes.Run(ctx, time.Minute)
+
+	// Scheduled drafts and bulk imports create listings like createlisting
+	drafts.Publish = handlers.PublishListing
+	bulkimport.Publish = handlers.PublishListing
+	go drafts.Run(ctx, time.Minute)
+
+	// Expire best and second-chance offers nobody answered
+	go offers.Run(ctx, time.Minute)
+	go secondchance.Run(ctx, time.Minute)
+
+	// Alert saved searches as scheduled listings go live, and send digests
+	go savedsearch.Run(ctx, time.Minute)
+
+	// Keep display exchange rates fresh (FX_RATES_FILE overrides the bundled rates)
+	fx.Default = fx.NewCache(redisClient, fx.FileProvider{Path: os.Getenv("FX_RATES_FILE")})
+	go fx.Default.Run(ctx, time.Hour)
+
 	_ = pgPool      // Keep for future use in handlers
 	_ = redisClient // Keep for future use in handlers
 
@@ -63,8 +137,9 @@ func main() {
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Idempotent-Replayed")
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -0,0 +1,93 @@
+package bulkimport
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	listing "github.com/quickswap/quickswap/internal/listings"
+)
+
+const testCSV = `title,description,category,subcategory,attributes.storage,images,starting_bid,currency,location,auction_end_time
+Phone,Works,electronics,Mobile phones,128GB,a.jpg|b.jpg,25.50,USD,"Gainesville, FL",
+Desk,Oak,home,,,c.jpg,40,USD,"Gainesville, FL",2050-01-01T12:00:00Z
+Broken,,home,,,,abc,USD,,
+`
+
+func TestParseCSV(t *testing.T) {
+	rows, err := Parse(strings.NewReader(testCSV), CSV)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(rows))
+	}
+	phone := rows[0].Input
+	if rows[0].Number != 2 || len(phone.Images) != 2 || phone.Attributes["storage"] != "128GB" || phone.StartingBid == nil {
+		t.Errorf("Unexpected first row: %+v", rows[0])
+	}
+	if rows[2].Err == nil {
+		t.Errorf("Expected an unreadable starting_bid to fail the row")
+	}
+
+	if _, err := Parse(strings.NewReader("title,price\nDesk,10\n"), CSV); err == nil {
+		t.Errorf("Expected unknown columns to be rejected")
+	}
+}
+
+func TestParseJSONL(t *testing.T) {
+	rows, err := Parse(strings.NewReader(`{"title": "Desk", "starting_bid": 40}
+
+not json
+`), JSONL)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Input.Title != "Desk" || rows[1].Err == nil || rows[1].Number != 3 {
+		t.Errorf("Unexpected rows: %+v", rows)
+	}
+}
+
+func TestValidateStaggersEndTimes(t *testing.T) {
+	rows, _ := Parse(strings.NewReader(testCSV), CSV)
+	endsAt := time.Date(2050, 1, 1, 10, 0, 0, 0, time.UTC)
+	job := &Job{SellerID: "seller1"}
+
+	built := Validate(job, rows, Options{DryRun: true, EndsAt: endsAt, Stagger: 5 * time.Minute})
+	if job.Total != 3 || job.Succeeded != 2 || job.Failed != 1 {
+		t.Fatalf("Expected 2 valid rows and 1 failure, got %+v", job)
+	}
+	if !built[0].AuctionEndTime.Equal(endsAt) {
+		t.Errorf("Expected the first row to end at ends_at, got %s", built[0].AuctionEndTime)
+	}
+	if want := time.Date(2050, 1, 1, 12, 5, 0, 0, time.UTC); !built[1].AuctionEndTime.Equal(want) {
+		t.Errorf("Expected the second row to be staggered to %s, got %s", want, built[1].AuctionEndTime)
+	}
+	if built[2] != nil || job.Rows[2].Status != RowFailed {
+		t.Errorf("Expected the broken row to fail, got %+v", job.Rows[2])
+	}
+}
+
+func TestRunPartialSuccess(t *testing.T) {
+	rows, _ := Parse(strings.NewReader(testCSV), CSV)
+	job := &Job{SellerID: "seller1"}
+	built := Validate(job, rows, Options{EndsAt: time.Date(2050, 1, 1, 10, 0, 0, 0, time.UTC)})
+
+	Publish = func(ctx context.Context, l *listing.Listing) (string, error) {
+		if l.Title == "Desk" {
+			return "", errors.New("insert failed")
+		}
+		return "list-" + l.Title, nil
+	}
+	defer func() { Publish = nil }()
+
+	Run(context.Background(), job, built)
+	if job.Status != StatusCompleted || job.Succeeded != 1 || job.Failed != 2 {
+		t.Errorf("Expected one created and two failed rows, got %+v", job)
+	}
+	if job.Rows[0].ListingID != "list-Phone" || job.Rows[1].Error != "insert failed" {
+		t.Errorf("Unexpected row results: %+v", job.Rows)
+	}
+}
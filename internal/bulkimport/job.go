@@ -0,0 +1,204 @@
+package bulkimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Jobs live in the Supabase `import_jobs` table.
+const table = "import_jobs"
+
+// progressEvery is how many rows are published between job progress saves.
+const progressEvery = 10
+
+// Job and row statuses.
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+
+	RowValid   = "valid"
+	RowCreated = "created"
+	RowFailed  = "failed"
+)
+
+// Options control how rows become listings.
+type Options struct {
+	// DryRun validates every row without creating listings.
+	DryRun bool
+	// EndsAt is the auction end time for rows that don't set one.
+	EndsAt time.Time
+	// Stagger spaces out end times: the nth valid row ends n*Stagger after
+	// its own end time, so a whole store doesn't close in the same minute.
+	Stagger time.Duration
+}
+
+// RowResult is the outcome of one row.
+type RowResult struct {
+	Row            int        `json:"row"`
+	Title          string     `json:"title,omitempty"`
+	Status         string     `json:"status"`
+	ListingID      string     `json:"listing_id,omitempty"`
+	AuctionEndTime *time.Time `json:"auction_end_time,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	Missing        []string   `json:"missing,omitempty"`
+}
+
+// Job is an import and its per-row report.
+type Job struct {
+	ID         string      `json:"id,omitempty"`
+	SellerID   string      `json:"seller_id"`
+	Format     Format      `json:"format"`
+	DryRun     bool        `json:"dry_run"`
+	Status     string      `json:"status"`
+	Total      int         `json:"total"`
+	Succeeded  int         `json:"succeeded"`
+	Failed     int         `json:"failed"`
+	Rows       []RowResult `json:"rows"`
+	CreatedAt  time.Time   `json:"created_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
+// ErrNotFound is returned for jobs that don't exist or aren't the caller's.
+var ErrNotFound = errors.New("import job not found")
+
+// Publish stores a validated listing and returns its ID. It is set by the
+// caller so imports go through the same path as single listings.
+var Publish func(ctx context.Context, l *listing.Listing) (string, error)
+
+// Validate builds a listing from every row for sellerID. The returned slice
+// is parallel to rows, with nil for rows that failed; those failures are
+// already recorded in the job.
+func Validate(job *Job, rows []Row, opts Options) []*listing.Listing {
+	built := make([]*listing.Listing, len(rows))
+	job.Total, job.Succeeded, job.Failed = len(rows), 0, 0
+	job.Rows = make([]RowResult, len(rows))
+
+	valid := 0
+	for i, row := range rows {
+		res := RowResult{Row: row.Number, Title: row.Input.Title, Status: RowValid}
+		if row.Err != nil {
+			res.Status, res.Error = RowFailed, row.Err.Error()
+			job.Rows[i] = res
+			job.Failed++
+			continue
+		}
+
+		in := row.Input
+		if in.AuctionEndTime == "" && !opts.EndsAt.IsZero() {
+			in.AuctionEndTime = opts.EndsAt.UTC().Format(time.RFC3339)
+		}
+		l, err := in.Build(job.SellerID)
+		if err != nil {
+			res.Status, res.Error, res.Missing = RowFailed, err.Error(), in.Missing()
+			job.Rows[i] = res
+			job.Failed++
+			continue
+		}
+
+		l.AuctionEndTime = l.AuctionEndTime.Add(time.Duration(valid) * opts.Stagger)
+		valid++
+		end := l.AuctionEndTime
+		res.AuctionEndTime = &end
+		built[i] = l
+		job.Rows[i] = res
+	}
+	if opts.DryRun {
+		job.Succeeded = valid
+	}
+	return built
+}
+
+// Start validates rows and, unless this is a dry run, records the job so its
+// progress can be polled. The listings still have to be created with Run.
+func Start(sellerID string, f Format, rows []Row, opts Options) (*Job, []*listing.Listing, error) {
+	job := &Job{
+		SellerID:  sellerID,
+		Format:    f,
+		DryRun:    opts.DryRun,
+		Status:    StatusRunning,
+		CreatedAt: time.Now().UTC(),
+	}
+	built := Validate(job, rows, opts)
+	if opts.DryRun {
+		now := time.Now().UTC()
+		job.Status, job.FinishedAt = StatusCompleted, &now
+		return job, built, nil
+	}
+
+	var inserted []Job
+	if err := supabase.Insert(table, []Job{*job}, &inserted); err != nil {
+		return nil, nil, fmt.Errorf("failed to record import job: %w", err)
+	}
+	if len(inserted) > 0 {
+		job.ID = inserted[0].ID
+	}
+	return job, built, nil
+}
+
+// Run creates the validated listings of a started job. Each row succeeds or
+// fails on its own; progress is saved as it goes.
+func Run(ctx context.Context, job *Job, built []*listing.Listing) {
+	for i, l := range built {
+		if l == nil {
+			continue
+		}
+		if Publish == nil {
+			job.Rows[i].Status, job.Rows[i].Error = RowFailed, "publishing is not configured"
+			job.Failed++
+			continue
+		}
+
+		id, err := Publish(ctx, l)
+		if err != nil {
+			job.Rows[i].Status, job.Rows[i].Error = RowFailed, err.Error()
+			job.Failed++
+		} else {
+			job.Rows[i].Status, job.Rows[i].ListingID = RowCreated, id
+			job.Succeeded++
+		}
+
+		if (job.Succeeded+job.Failed)%progressEvery == 0 {
+			save(job)
+		}
+	}
+
+	now := time.Now().UTC()
+	job.Status, job.FinishedAt = StatusCompleted, &now
+	save(job)
+}
+
+func save(job *Job) {
+	if job.ID == "" {
+		return
+	}
+	patch := map[string]interface{}{
+		"status":      job.Status,
+		"succeeded":   job.Succeeded,
+		"failed":      job.Failed,
+		"rows":        job.Rows,
+		"finished_at": job.FinishedAt,
+	}
+	if err := supabase.Update(table, "id=eq."+url.QueryEscape(job.ID), patch, nil); err != nil {
+		log.Printf("Warning: failed to save progress of import job %s: %v", job.ID, err)
+	}
+}
+
+// Get returns one of sellerID's import jobs.
+func Get(sellerID, id string) (*Job, error) {
+	var jobs []Job
+	query := "id=eq." + url.QueryEscape(id) + "&seller_id=eq." + url.QueryEscape(sellerID)
+	if err := supabase.Select(table, query, &jobs); err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, ErrNotFound
+	}
+	return &jobs[0], nil
+}
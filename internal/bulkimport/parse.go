@@ -0,0 +1,238 @@
+package bulkimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+// Format is the layout of an import file.
+type Format string
+
+const (
+	// CSV files have a header row naming createlisting fields. Images are
+	// separated by "|" and category attributes use "attributes.<key>"
+	// columns.
+	CSV Format = "csv"
+	// JSONL files hold one createlisting JSON object per line.
+	JSONL Format = "jsonl"
+)
+
+// MaxRows caps the number of listings in one import.
+const MaxRows = 500
+
+// ParseFormat accepts a format name or a content type.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "csv", "text/csv":
+		return CSV, nil
+	case "jsonl", "json", "ndjson", "application/x-ndjson", "application/jsonl", "application/json":
+		return JSONL, nil
+	}
+	return "", fmt.Errorf("unsupported import format %q: use csv or jsonl", s)
+}
+
+// Row is one parsed line of an import file. Err is set when the line could
+// not be read at all.
+type Row struct {
+	Number int
+	Input  listing.Input
+	Err    error
+}
+
+// Parse reads every row of an import file.
+func Parse(r io.Reader, f Format) ([]Row, error) {
+	var rows []Row
+	var err error
+	switch f {
+	case CSV:
+		rows, err = parseCSV(r)
+	case JSONL:
+		rows, err = parseJSONL(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("import file has no rows")
+	}
+	if len(rows) > MaxRows {
+		return nil, fmt.Errorf("import file has %d rows; at most %d are allowed", len(rows), MaxRows)
+	}
+	return rows, nil
+}
+
+func parseJSONL(r io.Reader) ([]Row, error) {
+	var rows []Row
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		row := Row{Number: line}
+		if err := json.Unmarshal(text, &row.Input); err != nil {
+			row.Err = fmt.Errorf("invalid JSON: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+func parseCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+		if !knownColumns[header[i]] && !strings.HasPrefix(header[i], "attributes.") {
+			return nil, fmt.Errorf("unknown CSV column %q", header[i])
+		}
+	}
+
+	var rows []Row
+	line := 1
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		row := Row{Number: line}
+		if err != nil {
+			row.Err = fmt.Errorf("invalid CSV: %v", err)
+		} else {
+			row.Err = fillInput(&row.Input, header, record)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+var knownColumns = map[string]bool{
+	"title": true, "subtitle": true, "description": true, "category": true,
+	"subcategory": true, "condition": true, "brand": true, "color": true,
+	"size": true, "images": true, "starting_bid": true, "buy_now_price": true,
+	"currency": true, "auction_start_time": true, "auction_end_time": true,
+	"location": true, "notes": true, "auction_type": true,
+	"dutch_decrement": true, "dutch_interval_minutes": true, "dutch_floor": true,
+	"quantity": true, "pricing": true, "accepts_offers": true,
+}
+
+// fillInput maps a CSV record onto a listing input by column name.
+func fillInput(in *listing.Input, header, record []string) error {
+	for i, value := range record {
+		if i >= len(header) {
+			return fmt.Errorf("row has more columns than the header")
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		column := header[i]
+		if key, ok := strings.CutPrefix(column, "attributes."); ok {
+			if in.Attributes == nil {
+				in.Attributes = map[string]string{}
+			}
+			in.Attributes[key] = value
+			continue
+		}
+
+		switch column {
+		case "title":
+			in.Title = value
+		case "subtitle":
+			in.Subtitle = value
+		case "description":
+			in.Description = value
+		case "category":
+			in.Category = value
+		case "subcategory":
+			in.Subcategory = value
+		case "condition":
+			in.Condition = value
+		case "brand":
+			in.Brand = value
+		case "color":
+			in.Color = value
+		case "size":
+			in.Size = value
+		case "images":
+			for _, img := range strings.Split(value, "|") {
+				if img = strings.TrimSpace(img); img != "" {
+					in.Images = append(in.Images, img)
+				}
+			}
+		case "starting_bid", "buy_now_price", "dutch_decrement", "dutch_floor":
+			var m money.Money
+			if err := json.Unmarshal([]byte(strconv.Quote(value)), &m); err != nil {
+				return fmt.Errorf("%s: %v", column, err)
+			}
+			switch column {
+			case "starting_bid":
+				in.StartingBid = &m
+			case "buy_now_price":
+				in.BuyNowPrice = &m
+			case "dutch_decrement":
+				in.DutchDecrement = &m
+			default:
+				in.DutchFloor = &m
+			}
+		case "dutch_interval_minutes", "quantity":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %v", column, err)
+			}
+			if column == "quantity" {
+				in.Quantity = n
+			} else {
+				in.DutchIntervalMinutes = n
+			}
+		case "pricing":
+			in.Pricing = value
+		case "accepts_offers":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s: %v", column, err)
+			}
+			in.AcceptsOffers = b
+		case "currency":
+			in.Currency = value
+		case "auction_type":
+			in.AuctionType = value
+		case "auction_start_time":
+			in.AuctionStartTime = value
+		case "auction_end_time":
+			in.AuctionEndTime = value
+		case "location":
+			in.Location = value
+		case "notes":
+			in.Notes = value
+		default:
+			return fmt.Errorf("unknown column %q", column)
+		}
+	}
+	return nil
+}
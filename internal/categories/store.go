@@ -0,0 +1,220 @@
+package categories
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Categories live in the Supabase `categories` table. Until an admin edits
+// the tree it is empty and the bundled defaults are served.
+const table = "categories"
+
+// cacheTTL is how long a loaded tree is served before it is reloaded.
+const cacheTTL = time.Minute
+
+//go:embed defaults.json
+var defaultsData []byte
+
+// Defaults returns the bundled category tree.
+func Defaults() []Category {
+	var cats []Category
+	if err := json.Unmarshal(defaultsData, &cats); err != nil {
+		panic("categories: invalid bundled defaults: " + err.Error())
+	}
+	return cats
+}
+
+var (
+	mu       sync.Mutex
+	cached   *Taxonomy
+	loadedAt time.Time
+)
+
+// Current returns the category tree, served from a short-lived cache.
+func Current() *Taxonomy {
+	mu.Lock()
+	defer mu.Unlock()
+	if cached != nil && time.Since(loadedAt) < cacheTTL {
+		return cached
+	}
+
+	cats, err := load()
+	if err != nil {
+		log.Printf("Warning: failed to load categories, using defaults: %v", err)
+	}
+	if len(cats) == 0 {
+		cats = Defaults()
+	}
+	cached, loadedAt = NewTaxonomy(cats), time.Now()
+	return cached
+}
+
+// invalidate drops the cached tree after an edit.
+func invalidate() {
+	mu.Lock()
+	defer mu.Unlock()
+	cached = nil
+}
+
+func load() ([]Category, error) {
+	var cats []Category
+	err := supabase.Select(table, "select=*&order=position.asc", &cats)
+	return cats, err
+}
+
+// ErrNotFound is returned when editing a category that doesn't exist.
+var ErrNotFound = errors.New("category not found")
+
+var idPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// seed copies the defaults into an empty table so the first edit doesn't
+// replace the whole tree.
+func seed() error {
+	cats, err := load()
+	if err != nil {
+		return err
+	}
+	if len(cats) > 0 {
+		return nil
+	}
+	return supabase.Upsert(table, Defaults(), nil)
+}
+
+// checkParent verifies that parentID exists and that making it the parent of
+// id won't create a cycle.
+func checkParent(t *Taxonomy, id string, parentID *string) error {
+	if parentID == nil {
+		return nil
+	}
+	p, ok := t.Get(*parentID)
+	if !ok {
+		return &ValidationError{Field: "parent_id", Message: fmt.Sprintf("unknown category %q", *parentID)}
+	}
+	for depth := 0; ; depth++ {
+		if p.ID == id || depth >= maxDepth {
+			return &ValidationError{Field: "parent_id", Message: "would create a cycle or a tree that is too deep"}
+		}
+		if p, ok = t.parent(p); !ok {
+			return nil
+		}
+	}
+}
+
+func checkAttributes(attrs []Attribute) error {
+	seen := map[string]bool{}
+	for _, a := range attrs {
+		if a.Key == "" || a.Label == "" {
+			return &ValidationError{Field: "attributes", Message: "every attribute needs a key and label"}
+		}
+		if seen[a.Key] {
+			return &ValidationError{Field: "attributes", Message: fmt.Sprintf("duplicate attribute %q", a.Key)}
+		}
+		seen[a.Key] = true
+	}
+	return nil
+}
+
+// Create adds a category to the tree.
+func Create(c Category) (Category, error) {
+	if !idPattern.MatchString(c.ID) {
+		return Category{}, &ValidationError{Field: "id", Message: "must be a lowercase slug such as mobile-phones"}
+	}
+	if c.Name == "" {
+		return Category{}, &ValidationError{Field: "name", Message: "is required"}
+	}
+	if c.Attributes == nil {
+		c.Attributes = []Attribute{}
+	}
+	if err := checkAttributes(c.Attributes); err != nil {
+		return Category{}, err
+	}
+	t := Current()
+	if _, exists := t.Get(c.ID); exists {
+		return Category{}, &ValidationError{Field: "id", Message: fmt.Sprintf("category %q already exists", c.ID)}
+	}
+	if err := checkParent(t, c.ID, c.ParentID); err != nil {
+		return Category{}, err
+	}
+
+	if err := seed(); err != nil {
+		return Category{}, err
+	}
+	if err := supabase.Insert(table, []Category{c}, nil); err != nil {
+		return Category{}, err
+	}
+	invalidate()
+	return c, nil
+}
+
+// Update is a partial edit of a category. Nil fields are left unchanged.
+type Update struct {
+	Name       *string      `json:"name"`
+	ParentID   *string      `json:"parent_id"`
+	Position   *int         `json:"position"`
+	Attributes *[]Attribute `json:"attributes"`
+	Retired    *bool        `json:"retired"`
+}
+
+// Edit applies u to the category with id. Moving a category to the top level
+// is done by setting parent_id to "".
+func Edit(id string, u Update) (Category, error) {
+	t := Current()
+	c, ok := t.Get(id)
+	if !ok {
+		return Category{}, ErrNotFound
+	}
+
+	if u.Name != nil {
+		if *u.Name == "" {
+			return Category{}, &ValidationError{Field: "name", Message: "is required"}
+		}
+		c.Name = *u.Name
+	}
+	if u.ParentID != nil {
+		if *u.ParentID == "" {
+			c.ParentID = nil
+		} else {
+			c.ParentID = u.ParentID
+		}
+		if err := checkParent(t, id, c.ParentID); err != nil {
+			return Category{}, err
+		}
+	}
+	if u.Position != nil {
+		c.Position = *u.Position
+	}
+	if u.Attributes != nil {
+		if err := checkAttributes(*u.Attributes); err != nil {
+			return Category{}, err
+		}
+		c.Attributes = *u.Attributes
+	}
+	if u.Retired != nil {
+		c.Retired = *u.Retired
+	}
+
+	if err := seed(); err != nil {
+		return Category{}, err
+	}
+	if err := supabase.Update(table, "id=eq."+url.QueryEscape(id), c, nil); err != nil {
+		return Category{}, err
+	}
+	invalidate()
+	return c, nil
+}
+
+// Retire hides a category from sellers. It is never deleted, since existing
+// listings refer to it.
+func Retire(id string) (Category, error) {
+	retired := true
+	return Edit(id, Update{Retired: &retired})
+}
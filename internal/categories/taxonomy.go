@@ -0,0 +1,282 @@
+package categories
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Conditions are the allowed values of Listing.Condition.
+var Conditions = []string{"new", "like_new", "used_good", "used_fair"}
+
+// Attribute is a listing field a category asks for, e.g. storage for phones.
+// An empty Values list allows free text.
+type Attribute struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label"`
+	Required bool     `json:"required,omitempty"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// Category is a node in the category tree. Attributes are inherited by
+// subcategories, which may override one by declaring the same key.
+type Category struct {
+	ID         string      `json:"id"`
+	ParentID   *string     `json:"parent_id"`
+	Name       string      `json:"name"`
+	Position   int         `json:"position"`
+	Attributes []Attribute `json:"attributes"`
+	// Retired categories are hidden and closed to new listings but kept so
+	// existing listings still resolve.
+	Retired bool `json:"retired"`
+}
+
+// Node is a category with its children, as served by GET /api/categories.
+type Node struct {
+	Category
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Taxonomy is an immutable, indexed category tree.
+type Taxonomy struct {
+	byID     map[string]Category
+	children map[string][]string
+}
+
+// NewTaxonomy indexes cats. Children are ordered by position, then name.
+func NewTaxonomy(cats []Category) *Taxonomy {
+	t := &Taxonomy{byID: make(map[string]Category), children: make(map[string][]string)}
+	for _, c := range cats {
+		t.byID[c.ID] = c
+	}
+	for _, c := range cats {
+		parent := ""
+		if c.ParentID != nil {
+			parent = *c.ParentID
+		}
+		t.children[parent] = append(t.children[parent], c.ID)
+	}
+	for _, ids := range t.children {
+		sort.Slice(ids, func(i, j int) bool {
+			a, b := t.byID[ids[i]], t.byID[ids[j]]
+			if a.Position != b.Position {
+				return a.Position < b.Position
+			}
+			return a.Name < b.Name
+		})
+	}
+	return t
+}
+
+// Get returns the category with id.
+func (t *Taxonomy) Get(id string) (Category, bool) {
+	c, ok := t.byID[id]
+	return c, ok
+}
+
+// Tree returns the category tree, leaving out retired categories unless
+// includeRetired is set.
+func (t *Taxonomy) Tree(includeRetired bool) []*Node {
+	var build func(parent string) []*Node
+	build = func(parent string) []*Node {
+		nodes := []*Node{}
+		for _, id := range t.children[parent] {
+			c := t.byID[id]
+			if c.Retired && !includeRetired {
+				continue
+			}
+			nodes = append(nodes, &Node{Category: c, Children: build(id)})
+		}
+		return nodes
+	}
+	return build("")
+}
+
+// Find looks up a child of parent ("" for top level) by ID or by name,
+// ignoring case and punctuation.
+func (t *Taxonomy) Find(parent, value string) (Category, bool) {
+	want := normalize(value)
+	for _, id := range t.children[parent] {
+		c := t.byID[id]
+		if c.ID == value || normalize(c.Name) == want || normalize(c.ID) == want {
+			return c, true
+		}
+	}
+	return Category{}, false
+}
+
+// StoredAs lists the values a listing's category column may hold for c: its
+// ID, and its name for listings created before categories had IDs.
+func (c Category) StoredAs() []string {
+	if c.Name == "" || c.Name == c.ID {
+		return []string{c.ID}
+	}
+	return []string{c.ID, c.Name}
+}
+
+// Is reports whether a listing's stored category value refers to c, by ID or
+// by the legacy name.
+func (c Category) Is(stored string) bool {
+	return stored == c.ID || (stored != "" && normalize(stored) == normalize(c.Name))
+}
+
+// maxDepth bounds walks up the tree.
+const maxDepth = 8
+
+func (t *Taxonomy) parent(c Category) (Category, bool) {
+	if c.ParentID == nil {
+		return Category{}, false
+	}
+	p, ok := t.byID[*c.ParentID]
+	return p, ok
+}
+
+// Attributes returns the attributes that apply to a category, including those
+// inherited from its ancestors.
+func (t *Taxonomy) Attributes(id string) []Attribute {
+	var chain []Category
+	for c, ok := t.byID[id]; ok && len(chain) < maxDepth; c, ok = t.parent(c) {
+		chain = append(chain, c)
+	}
+
+	var attrs []Attribute
+	index := map[string]int{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, a := range chain[i].Attributes {
+			if j, seen := index[a.Key]; seen {
+				attrs[j] = a
+				continue
+			}
+			index[a.Key] = len(attrs)
+			attrs = append(attrs, a)
+		}
+	}
+	return attrs
+}
+
+// Input is the classification a seller submits with a listing.
+type Input struct {
+	Category    string
+	Subcategory string
+	Condition   string
+	Attributes  map[string]string
+	// Size is the legacy free-text size field. It fills the size attribute
+	// when the category has one.
+	Size string
+	// Partial skips required-attribute checks, for drafts.
+	Partial bool
+}
+
+// Classified is a validated Input in canonical form.
+type Classified struct {
+	Category    string
+	Subcategory string
+	Condition   string
+	Attributes  map[string]string
+	Size        string
+}
+
+// ValidationError describes the first field that failed validation.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func invalid(field, format string, args ...interface{}) error {
+	return &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validate checks in against the tree and returns it with category IDs and
+// attribute values in canonical form.
+func (t *Taxonomy) Validate(in Input) (Classified, error) {
+	var out Classified
+
+	cat, ok := t.Find("", in.Category)
+	if !ok || cat.Retired {
+		return out, invalid("category", "unknown category %q", in.Category)
+	}
+	out.Category = cat.ID
+	leaf := cat.ID
+
+	if in.Subcategory != "" {
+		sub, ok := t.Find(cat.ID, in.Subcategory)
+		if !ok || sub.Retired {
+			return out, invalid("subcategory", "unknown subcategory %q for %s", in.Subcategory, cat.Name)
+		}
+		out.Subcategory = sub.ID
+		leaf = sub.ID
+	}
+
+	if in.Condition != "" {
+		for _, c := range Conditions {
+			if strings.EqualFold(c, in.Condition) {
+				out.Condition = c
+			}
+		}
+		if out.Condition == "" {
+			return out, invalid("condition", "must be one of %s", strings.Join(Conditions, ", "))
+		}
+	}
+
+	attrs := t.Attributes(leaf)
+	known := make(map[string]Attribute, len(attrs))
+	for _, a := range attrs {
+		known[a.Key] = a
+	}
+	values := make(map[string]string, len(in.Attributes)+1)
+	for k, v := range in.Attributes {
+		values[k] = v
+	}
+	if _, hasSize := known["size"]; hasSize && values["size"] == "" {
+		values["size"] = in.Size
+	}
+	for key := range values {
+		if _, ok := known[key]; !ok {
+			return out, invalid("attributes."+key, "not an attribute of %s", t.byID[leaf].Name)
+		}
+	}
+
+	out.Attributes = map[string]string{}
+	for _, a := range attrs {
+		v := strings.TrimSpace(values[a.Key])
+		if v == "" {
+			if a.Required && !in.Partial {
+				return out, invalid("attributes."+a.Key, "%s is required", a.Label)
+			}
+			continue
+		}
+		if len(a.Values) > 0 {
+			allowed := ""
+			for _, option := range a.Values {
+				if strings.EqualFold(option, v) {
+					allowed = option
+				}
+			}
+			if allowed == "" {
+				return out, invalid("attributes."+a.Key, "%s must be one of %s", a.Label, strings.Join(a.Values, ", "))
+			}
+			v = allowed
+		}
+		out.Attributes[a.Key] = v
+	}
+
+	out.Size = in.Size
+	if _, hasSize := known["size"]; hasSize {
+		out.Size = out.Attributes["size"]
+	}
+	return out, nil
+}
+
+// normalize lower-cases s and collapses punctuation, so "Men's T‑shirts"
+// and "mens-t-shirts" compare alike.
+func normalize(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, "")
+}
@@ -0,0 +1,100 @@
+package categories
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateDefaults(t *testing.T) {
+	tax := NewTaxonomy(Defaults())
+
+	got, err := tax.Validate(Input{
+		Category:    "electronics",
+		Subcategory: "Mobile phones",
+		Condition:   "Like_New",
+		Attributes:  map[string]string{"storage": "128gb", "brand": "Acme"},
+	})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if got.Subcategory != "mobile-phones" || got.Condition != "like_new" || got.Attributes["storage"] != "128GB" {
+		t.Errorf("Expected canonical values, got %+v", got)
+	}
+
+	// Subcategory names from the sell form, including its non-breaking hyphen
+	if _, err := tax.Validate(Input{Category: "clothing", Subcategory: "Men's T‑shirts", Attributes: map[string]string{"size": "M"}}); err != nil {
+		t.Errorf("Expected the form's subcategory name to match, got %v", err)
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	tax := NewTaxonomy(Defaults())
+
+	tests := map[string]struct {
+		in    Input
+		field string
+	}{
+		"unknown category":    {Input{Category: "cars"}, "category"},
+		"foreign subcategory": {Input{Category: "books", Subcategory: "laptops"}, "subcategory"},
+		"bad condition":       {Input{Category: "books", Condition: "broken"}, "condition"},
+		"missing required":    {Input{Category: "electronics", Subcategory: "mobile-phones"}, "attributes.storage"},
+		"disallowed value":    {Input{Category: "clothing", Attributes: map[string]string{"size": "XXXL"}}, "attributes.size"},
+		"unknown attribute":   {Input{Category: "books", Attributes: map[string]string{"storage": "1TB"}}, "attributes.storage"},
+	}
+	for name, tt := range tests {
+		_, err := tax.Validate(tt.in)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || verr.Field != tt.field {
+			t.Errorf("%s: expected error on %s, got %v", name, tt.field, err)
+		}
+	}
+}
+
+func TestAttributesOverrideParent(t *testing.T) {
+	tax := NewTaxonomy(Defaults())
+
+	for _, a := range tax.Attributes("footwear") {
+		if a.Key == "size" && a.Values[0] != "5" {
+			t.Errorf("Expected footwear to use shoe sizes, got %v", a.Values)
+		}
+	}
+	if len(tax.Attributes("mens-pants")) != 1 {
+		t.Errorf("Expected mens-pants to inherit clothing's size only, got %+v", tax.Attributes("mens-pants"))
+	}
+}
+
+func TestTreeHidesRetired(t *testing.T) {
+	cats := Defaults()
+	for i := range cats {
+		if cats[i].ID == "books" {
+			cats[i].Retired = true
+		}
+	}
+	tax := NewTaxonomy(cats)
+
+	for _, n := range tax.Tree(false) {
+		if n.ID == "books" {
+			t.Errorf("Expected retired category to be hidden")
+		}
+	}
+	if len(tax.Tree(true)) != len(tax.Tree(false))+1 {
+		t.Errorf("Expected retired category when including retired")
+	}
+	if _, err := tax.Validate(Input{Category: "books"}); err == nil {
+		t.Errorf("Expected new listings in a retired category to be rejected")
+	}
+}
+
+func TestValidateLegacySize(t *testing.T) {
+	tax := NewTaxonomy(Defaults())
+
+	got, err := tax.Validate(Input{Category: "clothing", Size: "l"})
+	if err != nil || got.Attributes["size"] != "L" || got.Size != "L" {
+		t.Errorf("Expected size to fill the size attribute, got %+v, %v", got, err)
+	}
+
+	got, err = tax.Validate(Input{Category: "electronics", Size: "Large"})
+	if err != nil || got.Size != "Large" {
+		t.Errorf("Expected free-text size where the category has none, got %+v, %v", got, err)
+	}
+}
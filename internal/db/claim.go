@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSold is returned when an auction already has a bid or buyer, so it can
+// no longer be claimed outright.
+var ErrSold = errors.New("Sold: another buyer got there first")
+
+// ClaimAuction sells an auction that has no bids yet to userID at price and
+// closes it: accepting a Dutch auction's asking price, or a seller accepting
+// a best offer. Simultaneous claims race on the highest bidder key; exactly
+// one transaction commits and the rest see ErrSold.
+func ClaimAuction(ctx context.Context, rdb *redis.Client, auctionID string, userID string, price money.Money) (BidResult, error) {
+	priceKey := fmt.Sprintf("auction:%s:price_minor", auctionID)
+	endTimeKey := fmt.Sprintf("auction:%s:end_time", auctionID)
+	highestBidderKey := fmt.Sprintf("auction:%s:highest_bidder", auctionID)
+	sellerKey := fmt.Sprintf("auction:%s:seller", auctionID)
+	seqKey := fmt.Sprintf("auction:%s:bid_seq", auctionID)
+
+	const maxRetries = 100
+
+	var result BidResult
+	txf := func(tx *redis.Tx) error {
+		winner, err := tx.Get(ctx, highestBidderKey).Result()
+		if err == nil && winner != "" {
+			return ErrSold
+		} else if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting highest bidder: %w", err)
+		}
+
+		now := time.Now()
+		endTimeUnix, err := tx.Get(ctx, endTimeKey).Int64()
+		hadEnd := err == nil
+		if err == nil {
+			if now.Unix() > endTimeUnix {
+				return fmt.Errorf("Auction Ended: current time is past auction end time")
+			}
+		} else if err != redis.Nil {
+			return fmt.Errorf("redis error getting end_time: %w", err)
+		}
+
+		if err := checkSeller(ctx, tx, sellerKey, userID); err != nil {
+			return err
+		}
+
+		previousPrice, err := tx.Get(ctx, priceKey).Int64()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting price: %w", err)
+		}
+		hadPrice := err == nil
+
+		var seq *redis.IntCmd
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, highestBidderKey, userID, 0)
+			pipe.Set(ctx, priceKey, price.Amount, 0)
+			// Close the auction to further accepts and bids
+			pipe.Set(ctx, endTimeKey, now.Unix()-1, 0)
+			seq = pipe.Incr(ctx, seqKey)
+			pipe.SAdd(ctx, fmt.Sprintf("auction:%s:participants", auctionID), userID)
+			return nil
+		})
+		if err == nil {
+			result = BidResult{Amount: price, Sequence: seq.Val(), undo: undoState{
+				price: previousPrice, hadPrice: hadPrice,
+				claimed: true, endTime: endTimeUnix, hadEnd: hadEnd,
+			}}
+		}
+		return err
+	}
+
+	for i := 0; i < maxRetries; i++ {
+		err := rdb.Watch(ctx, txf, highestBidderKey, endTimeKey)
+		if err == nil {
+			return result, nil
+		}
+		if err == redis.TxFailedErr {
+			continue // Another claim committed first; the retry sees ErrSold
+		}
+		return BidResult{}, err
+	}
+
+	return BidResult{}, fmt.Errorf("reached maximum number of retries claiming auction")
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/redis/go-redis/v9"
+)
+
+// AuctionUnits returns how many units a cached auction sells.
+func AuctionUnits(ctx context.Context, rdb *redis.Client, auctionID string) (int, error) {
+	n, err := rdb.Get(ctx, fmt.Sprintf("auction:%s:quantity", auctionID)).Int()
+	if err == redis.Nil {
+		return 1, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("redis error getting quantity: %w", err)
+	}
+	return n, nil
+}
+
+// unitBid is a standing multi-unit bid as stored in Redis.
+type unitBid struct {
+	Amount   int64 `json:"amount"`
+	Quantity int   `json:"quantity"`
+	Sequence int64 `json:"sequence"`
+}
+
+// ProcessMultiUnitBidWithTx places or raises userID's standing bid for
+// quantity units at a per-unit amount. Each bidder holds one bid, which can
+// only be raised; it must win at least one unit when ranked against the
+// others, i.e. beat the lowest winning bid once every unit is taken.
+func ProcessMultiUnitBidWithTx(ctx context.Context, rdb *redis.Client, auctionID string, userID string, amount money.Money, quantity int) (BidResult, error) {
+	priceKey := fmt.Sprintf("auction:%s:price_minor", auctionID)
+	currencyKey := fmt.Sprintf("auction:%s:currency", auctionID)
+	endTimeKey := fmt.Sprintf("auction:%s:end_time", auctionID)
+	sellerKey := fmt.Sprintf("auction:%s:seller", auctionID)
+	quantityKey := fmt.Sprintf("auction:%s:quantity", auctionID)
+	bidsKey := fmt.Sprintf("auction:%s:unit_bids", auctionID)
+	seqKey := fmt.Sprintf("auction:%s:bid_seq", auctionID)
+
+	const maxRetries = 100
+
+	var result BidResult
+	txf := func(tx *redis.Tx) error {
+		endTimeUnix, err := tx.Get(ctx, endTimeKey).Int64()
+		if err == nil {
+			if time.Now().Unix() > endTimeUnix {
+				return fmt.Errorf("Auction Ended: current time is past auction end time")
+			}
+		} else if err != redis.Nil {
+			return fmt.Errorf("redis error getting end_time: %w", err)
+		}
+
+		if err := checkSeller(ctx, tx, sellerKey, userID); err != nil {
+			return err
+		}
+
+		units, err := tx.Get(ctx, quantityKey).Int()
+		if err != nil {
+			return fmt.Errorf("redis error getting quantity: %w", err)
+		}
+		if quantity < 1 || quantity > units {
+			return fmt.Errorf("Invalid Bid: quantity must be between 1 and %d", units)
+		}
+
+		currency := money.DefaultCurrency
+		if code, err := tx.Get(ctx, currencyKey).Result(); err == nil && code != "" {
+			currency = money.Currency(code)
+		} else if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting currency: %w", err)
+		}
+		bid, err := amount.In(currency)
+		if err != nil {
+			return fmt.Errorf("Invalid Bid: %w", err)
+		}
+
+		// The price key holds the starting bid for multi-unit auctions
+		startingBid, err := tx.Get(ctx, priceKey).Int64()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting price: %w", err)
+		}
+		if !bid.IsPositive() || bid.Amount < startingBid {
+			return fmt.Errorf("Bid Too Low: amount must be at least the starting bid")
+		}
+
+		stored, err := tx.HGetAll(ctx, bidsKey).Result()
+		if err != nil {
+			return fmt.Errorf("redis error getting bids: %w", err)
+		}
+		var others []listing.UnitBid
+		var previous *unitBid
+		for id, raw := range stored {
+			var b unitBid
+			if err := json.Unmarshal([]byte(raw), &b); err != nil {
+				return fmt.Errorf("corrupt bid for %s: %w", id, err)
+			}
+			if id == userID {
+				previous = &b
+				continue
+			}
+			others = append(others, listing.UnitBid{UserID: id, Amount: money.Money{Amount: b.Amount, Currency: currency}, Quantity: b.Quantity, Sequence: b.Sequence})
+		}
+		if previous != nil && bid.Amount < previous.Amount {
+			return fmt.Errorf("Bid Too Low: amount can't be lower than your current bid")
+		}
+		if toBeat, full := listing.PriceToBeat(units, others); full && bid.Cmp(toBeat) <= 0 {
+			return fmt.Errorf("Bid Too Low: amount must be greater than the lowest winning bid of %s", toBeat)
+		}
+
+		lastSeq, err := tx.Get(ctx, seqKey).Int64()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting bid sequence: %w", err)
+		}
+		seq := lastSeq + 1
+
+		// Work out who loses units to this bid
+		before := append([]listing.UnitBid(nil), others...)
+		if previous != nil {
+			before = append(before, listing.UnitBid{UserID: userID, Amount: money.Money{Amount: previous.Amount, Currency: currency}, Quantity: previous.Quantity, Sequence: previous.Sequence})
+		}
+		after := append([]listing.UnitBid(nil), others...)
+		after = append(after, listing.UnitBid{UserID: userID, Amount: bid, Quantity: quantity, Sequence: seq})
+		won := unitsWon(listing.Allocate(units, listing.PayAsBid, after))
+		var outbid []string
+		for id, n := range unitsWon(listing.Allocate(units, listing.PayAsBid, before)) {
+			if id != userID && won[id] < n {
+				outbid = append(outbid, id)
+			}
+		}
+
+		raw, _ := json.Marshal(unitBid{Amount: bid.Amount, Quantity: quantity, Sequence: seq})
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, bidsKey, userID, raw)
+			pipe.Set(ctx, seqKey, seq, 0)
+			pipe.SAdd(ctx, fmt.Sprintf("auction:%s:participants", auctionID), userID)
+			return nil
+		})
+		if err == nil {
+			result = BidResult{Amount: bid, Sequence: seq, Revised: previous != nil, Outbid: outbid,
+				undo: undoState{hash: bidsKey, previous: stored[userID], placed: string(raw)}}
+		}
+		return err
+	}
+
+	for i := 0; i < maxRetries; i++ {
+		err := rdb.Watch(ctx, txf, bidsKey, seqKey)
+		if err == nil {
+			return result, nil
+		}
+		if err == redis.TxFailedErr {
+			continue // Retry on race condition
+		}
+		return BidResult{}, err
+	}
+
+	return BidResult{}, fmt.Errorf("reached maximum number of retries processing bid")
+}
+
+func unitsWon(awards []listing.Award) map[string]int {
+	won := make(map[string]int, len(awards))
+	for _, a := range awards {
+		won[a.UserID] = a.Quantity
+	}
+	return won
+}
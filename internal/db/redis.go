@@ -2,12 +2,16 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/quickswap/quickswap/internal/money"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -44,31 +48,85 @@ func NewRedisClient(ctx context.Context) (*redis.Client, error) {
 
 // EnsureAuctionCached fetches auction data from Postgres if missing in Redis.
 // It uses a Redis Pipeline to set both price and end_time atomically in the cache.
+// Prices are cached as integer minor units alongside the auction's currency.
 func EnsureAuctionCached(ctx context.Context, rdb *redis.Client, pg *pgxpool.Pool, auctionID string) error {
-	priceKey := fmt.Sprintf("auction:%s:price", auctionID)
+	priceKey := fmt.Sprintf("auction:%s:price_minor", auctionID)
 
-	// Step 1: Check if already cached
-	_, err := rdb.Get(ctx, priceKey).Result()
-	if err == nil {
-		return nil // Cache hit! Fast path.
-	} else if err != redis.Nil {
+	sellerKey := fmt.Sprintf("auction:%s:seller", auctionID)
+
+	// Step 1: Check if already cached, seller included
+	cached, err := rdb.Exists(ctx, priceKey, sellerKey).Result()
+	if err != nil {
 		return fmt.Errorf("redis error checking cache: %w", err)
+	} else if cached == 2 {
+		return nil // Cache hit! Fast path.
 	}
 
 	// Step 2: Cache miss, fetch starting state from Postgres 
-	var startPrice float64
+	var startPriceText string
 	var endTime time.Time
 	
-	query := "SELECT start_price, end_time FROM auctions WHERE id = $1"
-	err = pg.QueryRow(ctx, query, auctionID).Scan(&startPrice, &endTime)
+	query := "SELECT start_price::text, end_time FROM auctions WHERE id = $1"
+	err = pg.QueryRow(ctx, query, auctionID).Scan(&startPriceText, &endTime)
 	if err != nil {
 		return fmt.Errorf("failed to fetch auction from db: %w", err)
 	}
 
-	// Step 3: Save to Redis using a pipeline to guarantee both keys are written together
+	// The seller is cached alongside so the bid path can reject self-bids
+	var sellerID string
+	var currencyCode, auctionType *string
+	var quantity *int
+	query = "SELECT seller_id, currency, auction_type, quantity FROM listings WHERE id = $1"
+	if err := pg.QueryRow(ctx, query, auctionID).Scan(&sellerID, &currencyCode, &auctionType, &quantity); err != nil {
+		return fmt.Errorf("failed to fetch listing for auction %s: %w", auctionID, err)
+	}
+	if sellerID == "" {
+		return fmt.Errorf("auction %s has no seller", auctionID)
+	}
+	currency := money.DefaultCurrency
+	if currencyCode != nil && *currencyCode != "" {
+		currency = money.Currency(*currencyCode)
+	}
+
+	startPrice, err := money.Parse(startPriceText, "")
+	if err != nil {
+		return fmt.Errorf("invalid start price for auction %s: %w", auctionID, err)
+	}
+	startPrice = startPrice.Round(currency)
+
+	// Open single-unit auctions carry the high bid in the price key; sealed and
+	// multi-unit ones keep the starting bid there
+	price, leader := startPrice, ""
+	sealed := auctionType != nil && (*auctionType == "sealed_first_price" || *auctionType == "sealed_second_price")
+	if !sealed && (quantity == nil || *quantity <= 1) {
+		price, leader, err = restorePrice(ctx, rdb, pg, auctionID, startPrice)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Step 3: Save to Redis using a pipeline to guarantee both keys are written together.
+	// The price is only seeded if missing so a live auction's high bid survives a reload
 	pipe := rdb.Pipeline()
-	pipe.Set(ctx, priceKey, startPrice, 0)
+	pipe.SetNX(ctx, priceKey, price.Amount, 0)
+	if leader != "" {
+		pipe.SetNX(ctx, fmt.Sprintf("auction:%s:highest_bidder", auctionID), leader, 0)
+	}
+	pipe.Set(ctx, fmt.Sprintf("auction:%s:currency", auctionID), string(currency), 0)
 	pipe.Set(ctx, fmt.Sprintf("auction:%s:end_time", auctionID), endTime.Unix(), 0)
+	// Continue numbering after bids already in the ledger
+	var lastSeq int64
+	if err := pg.QueryRow(ctx, "SELECT COALESCE(MAX(bid_sequence), 0) FROM bids WHERE listing_id = $1", auctionID).Scan(&lastSeq); err != nil {
+		log.Printf("Warning: could not load bid sequence for auction %s: %v", auctionID, err)
+	}
+	pipe.SetNX(ctx, fmt.Sprintf("auction:%s:bid_seq", auctionID), lastSeq, 0)
+	pipe.Set(ctx, sellerKey, sellerID, 0)
+	if auctionType != nil && *auctionType != "" {
+		pipe.Set(ctx, fmt.Sprintf("auction:%s:type", auctionID), *auctionType, 0)
+	}
+	if quantity != nil && *quantity > 1 {
+		pipe.Set(ctx, fmt.Sprintf("auction:%s:quantity", auctionID), *quantity, 0)
+	}
 	
 	_, err = pipe.Exec(ctx)
 	if err != nil {
@@ -78,14 +136,93 @@ func EnsureAuctionCached(ctx context.Context, rdb *redis.Client, pg *pgxpool.Poo
 	return nil
 }
 
+// restorePrice works out the current price of an auction missing from the
+// cache: the larger of start, the price under the legacy float key written
+// before prices moved to minor units, and the best active bid in the ledger,
+// whose bidder is returned as the leader.
+func restorePrice(ctx context.Context, rdb *redis.Client, pg *pgxpool.Pool, auctionID string, start money.Money) (money.Money, string, error) {
+	price, leader := start, ""
+
+	legacy, err := rdb.Get(ctx, fmt.Sprintf("auction:%s:price", auctionID)).Result()
+	if err != nil && err != redis.Nil {
+		return price, "", fmt.Errorf("redis error getting legacy price: %w", err)
+	}
+	if f, err := strconv.ParseFloat(legacy, 64); err == nil {
+		if m := money.FromFloat(f, start.Currency); m.Cmp(price) > 0 {
+			price = m
+		}
+	}
+
+	var best, bidder string
+	query := "SELECT bid_amount::text, user_id FROM bids WHERE listing_id = $1 AND COALESCE(status, '') NOT IN ('retracted', 'revised') " +
+		"ORDER BY bid_amount DESC, bid_sequence ASC LIMIT 1"
+	err = pg.QueryRow(ctx, query, auctionID).Scan(&best, &bidder)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return price, leader, nil
+	} else if err != nil {
+		return price, "", fmt.Errorf("failed to fetch best bid for auction %s: %w", auctionID, err)
+	}
+	if f, err := strconv.ParseFloat(best, 64); err == nil {
+		if m := money.FromFloat(f, start.Currency); m.Cmp(price) >= 0 {
+			price, leader = m, bidder
+		}
+	}
+	return price, leader, nil
+}
+
+// ErrSelfBid is returned when a seller bids on their own auction.
+var ErrSelfBid = errors.New("Self Bid: sellers can't bid on their own auction")
+
+// ErrSellerUnknown is returned when an auction's seller isn't cached, so
+// self-bids can't be ruled out.
+var ErrSellerUnknown = errors.New("Auction Unavailable: auction details are still loading, try again")
+
+// checkSeller rejects bids from the auction's seller, and every bid while the
+// seller is unknown.
+func checkSeller(ctx context.Context, tx *redis.Tx, sellerKey, userID string) error {
+	sellerID, err := tx.Get(ctx, sellerKey).Result()
+	if err == redis.Nil || (err == nil && sellerID == "") {
+		return ErrSellerUnknown
+	} else if err != nil {
+		return fmt.Errorf("redis error getting seller: %w", err)
+	}
+	if sellerID == userID {
+		return ErrSelfBid
+	}
+	return nil
+}
+
+// BidResult describes an accepted bid.
+type BidResult struct {
+	// Amount is the bid in the auction's currency.
+	Amount money.Money
+	// PreviousBidder held the lead before this bid, if anyone did.
+	PreviousBidder string
+	// Sequence numbers the auction's accepted bids from 1.
+	Sequence int64
+	// Revised reports whether a sealed or multi-unit bid replaced the
+	// bidder's earlier one.
+	Revised bool
+	// Outbid lists multi-unit bidders who lost units to this bid.
+	Outbid []string
+
+	// undo is the cached state the bid replaced, for UndoBid.
+	undo undoState
+}
+
 // ProcessBidWithTx atomicly validates and processes a highest bid using Redis Optimistic Locking.
-func ProcessBidWithTx(ctx context.Context, rdb *redis.Client, auctionID string, userID string, amount float64) error {
-	priceKey := fmt.Sprintf("auction:%s:price", auctionID)
+// Amounts decoded without a currency are taken to be in the auction's currency.
+func ProcessBidWithTx(ctx context.Context, rdb *redis.Client, auctionID string, userID string, amount money.Money) (BidResult, error) {
+	priceKey := fmt.Sprintf("auction:%s:price_minor", auctionID)
+	currencyKey := fmt.Sprintf("auction:%s:currency", auctionID)
 	endTimeKey := fmt.Sprintf("auction:%s:end_time", auctionID)
 	highestBidderKey := fmt.Sprintf("auction:%s:highest_bidder", auctionID)
+	sellerKey := fmt.Sprintf("auction:%s:seller", auctionID)
+	seqKey := fmt.Sprintf("auction:%s:bid_seq", auctionID)
 
 	const maxRetries = 100
 
+	var result BidResult
 	txf := func(tx *redis.Tx) error {
 		// Read end_time
 		endTimeUnix, err := tx.Get(ctx, endTimeKey).Int64()
@@ -97,10 +234,27 @@ func ProcessBidWithTx(ctx context.Context, rdb *redis.Client, auctionID string,
 			return fmt.Errorf("redis error getting end_time: %w", err)
 		}
 
+		// Reject sellers bidding on their own auction
+		if err := checkSeller(ctx, tx, sellerKey, userID); err != nil {
+			return err
+		}
+
+		// Put the amount in the auction's currency
+		currency := money.DefaultCurrency
+		if code, err := tx.Get(ctx, currencyKey).Result(); err == nil && code != "" {
+			currency = money.Currency(code)
+		} else if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting currency: %w", err)
+		}
+		bid, err := amount.In(currency)
+		if err != nil {
+			return fmt.Errorf("Invalid Bid: %w", err)
+		}
+
 		// Read current price
-		currentPrice, err := tx.Get(ctx, priceKey).Float64()
+		currentPrice, err := tx.Get(ctx, priceKey).Int64()
 		if err == nil {
-			if amount <= currentPrice {
+			if bid.Amount <= currentPrice {
 				return fmt.Errorf("Bid Too Low: amount must be greater than current price")
 			}
 		} else if err != redis.Nil {
@@ -108,26 +262,91 @@ func ProcessBidWithTx(ctx context.Context, rdb *redis.Client, auctionID string,
 		} else if err == redis.Nil {
 			// If price doesn't exist, we could reject or just continue. 
 			// We continue assuming amount > 0.
-			if amount <= 0 {
+			if !bid.IsPositive() {
 				return fmt.Errorf("Bid Too Low: amount must be greater than current price")
 			}
 		}
 
+		hadPrice := err == nil
+
+		// Remember who is being outbid
+		previousBidder, err := tx.Get(ctx, highestBidderKey).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting highest bidder: %w", err)
+		}
+
 		// Execution: Create a pipeline
+		var seq *redis.IntCmd
 		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-			pipe.Set(ctx, priceKey, amount, 0)
+			pipe.Set(ctx, priceKey, bid.Amount, 0)
 			pipe.Set(ctx, highestBidderKey, userID, 0)
+			seq = pipe.Incr(ctx, seqKey)
 
 			participantsKey := fmt.Sprintf("auction:%s:participants", auctionID)
 			pipe.SAdd(ctx, participantsKey, userID)
 			return nil
 		})
+		if err == nil {
+			result = BidResult{Amount: bid, PreviousBidder: previousBidder, Sequence: seq.Val(),
+				undo: undoState{price: currentPrice, hadPrice: hadPrice}}
+		}
 
 		return err
 	}
 
 	for i := 0; i < maxRetries; i++ {
 		err := rdb.Watch(ctx, txf, priceKey)
+		if err == nil {
+			return result, nil
+		}
+		if err == redis.TxFailedErr {
+			continue // Retry on race condition
+		}
+		return BidResult{}, err
+	}
+
+	return BidResult{}, fmt.Errorf("reached maximum number of retries processing bid")
+}
+
+// RestoreLead rolls the cached auction state back after a bid is retracted.
+// If the retracted bid still holds the lead, price and highest bidder are set
+// to the best remaining bid (bidder is "" when none remain and price is the
+// starting bid). If a newer bid has already taken the lead nothing changes.
+func RestoreLead(ctx context.Context, rdb *redis.Client, auctionID string, retracted money.Money, retractedBy string, price money.Money, bidder string) error {
+	priceKey := fmt.Sprintf("auction:%s:price_minor", auctionID)
+	highestBidderKey := fmt.Sprintf("auction:%s:highest_bidder", auctionID)
+
+	const maxRetries = 100
+
+	txf := func(tx *redis.Tx) error {
+		currentPrice, err := tx.Get(ctx, priceKey).Int64()
+		if err == redis.Nil {
+			return nil // Not cached; the next bid reloads it
+		} else if err != nil {
+			return fmt.Errorf("redis error getting price: %w", err)
+		}
+		leader, err := tx.Get(ctx, highestBidderKey).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting highest bidder: %w", err)
+		}
+		if currentPrice != retracted.Amount || leader != retractedBy {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, priceKey, price.Amount, 0)
+			if bidder == "" {
+				pipe.Del(ctx, highestBidderKey)
+			} else {
+				pipe.Set(ctx, highestBidderKey, bidder, 0)
+			}
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxRetries; i++ {
+		err := rdb.Watch(ctx, txf, priceKey, highestBidderKey)
 		if err == nil {
 			return nil
 		}
@@ -137,5 +356,5 @@ func ProcessBidWithTx(ctx context.Context, rdb *redis.Client, auctionID string,
 		return err
 	}
 
-	return fmt.Errorf("reached maximum number of retries processing bid")
+	return fmt.Errorf("reached maximum number of retries restoring lead")
 }
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/redis/go-redis/v9"
+)
+
+// AuctionType returns the cached auction_type of an auction, or "" for
+// auctions cached without one.
+func AuctionType(ctx context.Context, rdb *redis.Client, auctionID string) (string, error) {
+	t, err := rdb.Get(ctx, fmt.Sprintf("auction:%s:type", auctionID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("redis error getting auction type: %w", err)
+	}
+	return t, nil
+}
+
+// ProcessSealedBidWithTx records a sealed bid. Each bidder holds one bid,
+// kept in a hash and never exposed as the auction price; bidding again
+// replaces it, up or down, as long as it meets the starting bid.
+func ProcessSealedBidWithTx(ctx context.Context, rdb *redis.Client, auctionID string, userID string, amount money.Money) (BidResult, error) {
+	priceKey := fmt.Sprintf("auction:%s:price_minor", auctionID)
+	currencyKey := fmt.Sprintf("auction:%s:currency", auctionID)
+	endTimeKey := fmt.Sprintf("auction:%s:end_time", auctionID)
+	sellerKey := fmt.Sprintf("auction:%s:seller", auctionID)
+	sealedKey := fmt.Sprintf("auction:%s:sealed_bids", auctionID)
+	seqKey := fmt.Sprintf("auction:%s:bid_seq", auctionID)
+
+	const maxRetries = 100
+
+	var result BidResult
+	txf := func(tx *redis.Tx) error {
+		endTimeUnix, err := tx.Get(ctx, endTimeKey).Int64()
+		if err == nil {
+			if time.Now().Unix() > endTimeUnix {
+				return fmt.Errorf("Auction Ended: current time is past auction end time")
+			}
+		} else if err != redis.Nil {
+			return fmt.Errorf("redis error getting end_time: %w", err)
+		}
+
+		if err := checkSeller(ctx, tx, sellerKey, userID); err != nil {
+			return err
+		}
+
+		currency := money.DefaultCurrency
+		if code, err := tx.Get(ctx, currencyKey).Result(); err == nil && code != "" {
+			currency = money.Currency(code)
+		} else if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting currency: %w", err)
+		}
+		bid, err := amount.In(currency)
+		if err != nil {
+			return fmt.Errorf("Invalid Bid: %w", err)
+		}
+
+		// The price key holds the starting bid for sealed auctions
+		startingBid, err := tx.Get(ctx, priceKey).Int64()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting price: %w", err)
+		}
+		if !bid.IsPositive() || bid.Amount < startingBid {
+			return fmt.Errorf("Bid Too Low: amount must be at least the starting bid")
+		}
+
+		previous, err := tx.HGet(ctx, sealedKey, userID).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting sealed bid: %w", err)
+		}
+		revised := err == nil
+
+		var seq *redis.IntCmd
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, sealedKey, userID, bid.Amount)
+			seq = pipe.Incr(ctx, seqKey)
+			pipe.SAdd(ctx, fmt.Sprintf("auction:%s:participants", auctionID), userID)
+			return nil
+		})
+		if err == nil {
+			result = BidResult{Amount: bid, Sequence: seq.Val(), Revised: revised,
+				undo: undoState{hash: sealedKey, previous: previous, placed: strconv.FormatInt(bid.Amount, 10)}}
+		}
+		return err
+	}
+
+	for i := 0; i < maxRetries; i++ {
+		err := rdb.Watch(ctx, txf, sealedKey)
+		if err == nil {
+			return result, nil
+		}
+		if err == redis.TxFailedErr {
+			continue // Retry on race condition
+		}
+		return BidResult{}, err
+	}
+
+	return BidResult{}, fmt.Errorf("reached maximum number of retries processing sealed bid")
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// undoState is the cached auction state a bid or claim replaced.
+type undoState struct {
+	// Single-unit bids and claims
+	price    int64
+	hadPrice bool
+	claimed  bool
+	endTime  int64
+	hadEnd   bool
+
+	// Sealed and multi-unit bids: the bidder's entry in hash before and
+	// after, previous being "" if they had none
+	hash     string
+	previous string
+	placed   string
+}
+
+// UndoBid rolls back the cached state of a bid or claim that couldn't be
+// recorded in the ledger, so the cache doesn't hold a bid settlement will
+// never see. If a newer bid has already moved the auction on, the newer
+// state is left alone.
+func UndoBid(ctx context.Context, rdb *redis.Client, auctionID, userID string, result BidResult) error {
+	if result.undo.hash != "" {
+		return undoEntry(ctx, rdb, result.undo, userID)
+	}
+
+	priceKey := fmt.Sprintf("auction:%s:price_minor", auctionID)
+	highestBidderKey := fmt.Sprintf("auction:%s:highest_bidder", auctionID)
+	endTimeKey := fmt.Sprintf("auction:%s:end_time", auctionID)
+	u := result.undo
+
+	const maxRetries = 100
+
+	txf := func(tx *redis.Tx) error {
+		currentPrice, err := tx.Get(ctx, priceKey).Int64()
+		if err == redis.Nil {
+			return nil // Not cached; the next bid reloads it from the ledger
+		} else if err != nil {
+			return fmt.Errorf("redis error getting price: %w", err)
+		}
+		leader, err := tx.Get(ctx, highestBidderKey).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("redis error getting highest bidder: %w", err)
+		}
+		if currentPrice != result.Amount.Amount || leader != userID {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if u.hadPrice {
+				pipe.Set(ctx, priceKey, u.price, 0)
+			} else {
+				pipe.Del(ctx, priceKey)
+			}
+			if result.PreviousBidder == "" {
+				pipe.Del(ctx, highestBidderKey)
+			} else {
+				pipe.Set(ctx, highestBidderKey, result.PreviousBidder, 0)
+			}
+			if u.claimed && u.hadEnd {
+				pipe.Set(ctx, endTimeKey, u.endTime, 0)
+			} else if u.claimed {
+				pipe.Del(ctx, endTimeKey)
+			}
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxRetries; i++ {
+		err := rdb.Watch(ctx, txf, priceKey, highestBidderKey, endTimeKey)
+		if err == nil {
+			return nil
+		}
+		if err == redis.TxFailedErr {
+			continue // Retry on race condition
+		}
+		return err
+	}
+
+	return fmt.Errorf("reached maximum number of retries undoing bid")
+}
+
+// undoEntry puts userID's sealed or multi-unit bid back to what it was,
+// unless they have bid again since.
+func undoEntry(ctx context.Context, rdb *redis.Client, u undoState, userID string) error {
+	const maxRetries = 100
+
+	txf := func(tx *redis.Tx) error {
+		current, err := tx.HGet(ctx, u.hash, userID).Result()
+		if err == redis.Nil || (err == nil && current != u.placed) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("redis error getting bid: %w", err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if u.previous == "" {
+				pipe.HDel(ctx, u.hash, userID)
+			} else {
+				pipe.HSet(ctx, u.hash, userID, u.previous)
+			}
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxRetries; i++ {
+		err := rdb.Watch(ctx, txf, u.hash)
+		if err == nil {
+			return nil
+		}
+		if err == redis.TxFailedErr {
+			continue // Retry on race condition
+		}
+		return err
+	}
+
+	return fmt.Errorf("reached maximum number of retries undoing bid")
+}
@@ -0,0 +1,448 @@
+// Package disputes handles buyer complaints about paid orders. A buyer opens
+// a case while the money is still in escrow, both sides add evidence, the
+// seller responds and an admin resolves it with a refund, a partial refund or
+// a rejection that releases the money to the seller.
+package disputes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/events"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/payments"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+const (
+	table         = "disputes"
+	evidenceTable = "dispute_evidence"
+)
+
+// Limits on disputes.
+const (
+	// OpenWindow is how long after paying a buyer can open a dispute.
+	OpenWindow = 30 * 24 * time.Hour
+	// ResponseWindow is how long the seller has to respond before the case
+	// goes to an admin without their side.
+	ResponseWindow = 3 * 24 * time.Hour
+	// MaxEvidence caps the evidence each party can attach.
+	MaxEvidence = 10
+)
+
+// Reasons a buyer can give.
+const (
+	NotAsDescribed = "not_as_described"
+	NotReceived    = "not_received"
+	Damaged        = "damaged"
+	Other          = "other"
+)
+
+var reasons = map[string]bool{NotAsDescribed: true, NotReceived: true, Damaged: true, Other: true}
+
+// Status of a dispute.
+type Status string
+
+const (
+	// AwaitingSeller cases are waiting for the seller's response.
+	AwaitingSeller Status = "awaiting_seller"
+	// UnderReview cases are waiting for an admin decision.
+	UnderReview Status = "under_review"
+	// Resolved cases have an admin or seller-accepted resolution.
+	Resolved Status = "resolved"
+	// Withdrawn cases were dropped by the buyer.
+	Withdrawn Status = "withdrawn"
+)
+
+// Open reports whether the case is still in progress.
+func (s Status) Open() bool { return s == AwaitingSeller || s == UnderReview }
+
+// Resolution is the outcome of a resolved dispute.
+type Resolution string
+
+const (
+	Refund        Resolution = "refund"
+	PartialRefund Resolution = "partial_refund"
+	Rejected      Resolution = "rejected"
+)
+
+var (
+	ErrNotFound  = errors.New("dispute not found")
+	ErrForbidden = errors.New("dispute belongs to someone else")
+	ErrInvalid   = errors.New("invalid dispute request")
+	ErrClosed    = errors.New("dispute can't do that in its current state")
+)
+
+// Dispute is a case opened against an order.
+type Dispute struct {
+	ID             string         `json:"id,omitempty"`
+	OrderID        string         `json:"order_id"`
+	ListingID      string         `json:"listing_id"`
+	Title          string         `json:"title"`
+	BuyerID        string         `json:"buyer_id"`
+	SellerID       string         `json:"seller_id"`
+	Reason         string         `json:"reason"`
+	Description    string         `json:"description"`
+	Status         Status         `json:"status"`
+	SellerResponse string         `json:"seller_response,omitempty"`
+	RespondedAt    *time.Time     `json:"responded_at,omitempty"`
+	ResponseDueAt  time.Time      `json:"response_due_at"`
+	Resolution     Resolution     `json:"resolution,omitempty"`
+	RefundAmount   *money.Money   `json:"refund_amount,omitempty"`
+	Currency       money.Currency `json:"currency"`
+	ResolvedBy     string         `json:"resolved_by,omitempty"`
+	ResolutionNote string         `json:"resolution_note,omitempty"`
+	ResolvedAt     *time.Time     `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// Evidence is a photo, document or statement attached to a dispute.
+type Evidence struct {
+	ID          string    `json:"id,omitempty"`
+	DisputeID   string    `json:"dispute_id"`
+	UserID      string    `json:"user_id"`
+	URL         string    `json:"url,omitempty"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Party reports whether userID is the buyer or seller in d.
+func (d *Dispute) Party(userID string) bool {
+	return userID == d.BuyerID || userID == d.SellerID
+}
+
+// Open starts a dispute on a paid order. The money stays in escrow until the
+// case is closed.
+func Open(o *payments.Order, buyerID, reason, description string, now time.Time) (*Dispute, error) {
+	switch {
+	case o.BuyerID != buyerID:
+		return nil, ErrForbidden
+	case o.Status != payments.Paid:
+		return nil, fmt.Errorf("%w: only orders held in escrow can be disputed", ErrClosed)
+	case o.Disputed:
+		return nil, fmt.Errorf("%w: the order already has an open dispute", ErrClosed)
+	case o.PaidAt != nil && now.Sub(*o.PaidAt) > OpenWindow:
+		return nil, fmt.Errorf("%w: disputes must be opened within %d days of paying", ErrClosed, int(OpenWindow.Hours()/24))
+	case !reasons[reason]:
+		return nil, fmt.Errorf("%w: unknown reason %q", ErrInvalid, reason)
+	case description == "":
+		return nil, fmt.Errorf("%w: describe the problem", ErrInvalid)
+	}
+
+	// Only one open can take the hold, even if two race past the check above
+	if err := payments.PatchWhere(o, "&disputed=is.false", map[string]interface{}{"disputed": true}); errors.Is(err, payments.ErrState) {
+		return nil, fmt.Errorf("%w: the order already has an open dispute", ErrClosed)
+	} else if err != nil {
+		return nil, err
+	}
+	d := Dispute{
+		OrderID:       o.ID,
+		ListingID:     o.ListingID,
+		Title:         o.Title,
+		BuyerID:       o.BuyerID,
+		SellerID:      o.SellerID,
+		Reason:        reason,
+		Description:   description,
+		Status:        AwaitingSeller,
+		ResponseDueAt: now.Add(ResponseWindow),
+		Currency:      o.Currency,
+		CreatedAt:     now,
+	}
+	var created []Dispute
+	if err := supabase.Insert(table, []Dispute{d}, &created); err != nil {
+		if err := payments.Patch(o, map[string]interface{}{"disputed": false}); err != nil {
+			log.Printf("Warning: failed to clear dispute hold on order %s: %v", o.ID, err)
+		}
+		return nil, err
+	}
+	if len(created) > 0 {
+		d = created[0]
+		d.round()
+	}
+	publish(events.DisputeOpened, d.SellerID, &d)
+	return &d, nil
+}
+
+// AddEvidence attaches evidence from either party to an open dispute.
+func AddEvidence(d *Dispute, userID, evidenceURL, description string, now time.Time) (*Evidence, error) {
+	switch {
+	case !d.Party(userID):
+		return nil, ErrForbidden
+	case !d.Status.Open():
+		return nil, ErrClosed
+	case description == "" && evidenceURL == "":
+		return nil, fmt.Errorf("%w: evidence needs a url or a description", ErrInvalid)
+	}
+	if evidenceURL != "" {
+		u, err := url.Parse(evidenceURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("%w: evidence url must be http(s)", ErrInvalid)
+		}
+	}
+
+	existing, err := EvidenceFor(d.ID)
+	if err != nil {
+		return nil, err
+	}
+	mine := 0
+	for _, e := range existing {
+		if e.UserID == userID {
+			mine++
+		}
+	}
+	if mine >= MaxEvidence {
+		return nil, fmt.Errorf("%w: at most %d pieces of evidence each", ErrInvalid, MaxEvidence)
+	}
+
+	e := Evidence{DisputeID: d.ID, UserID: userID, URL: evidenceURL, Description: description, CreatedAt: now}
+	var created []Evidence
+	if err := supabase.Insert(evidenceTable, []Evidence{e}, &created); err != nil {
+		return nil, err
+	}
+	if len(created) > 0 {
+		e = created[0]
+	}
+	return &e, nil
+}
+
+// Respond records the seller's side and sends the case for review. A seller
+// who accepts the buyer's claim refunds them in full straight away.
+func Respond(ctx context.Context, p payments.Provider, d *Dispute, sellerID, response string, acceptRefund bool, now time.Time) error {
+	switch {
+	case d.SellerID != sellerID:
+		return ErrForbidden
+	case d.Status != AwaitingSeller:
+		return ErrClosed
+	case response == "" && !acceptRefund:
+		return fmt.Errorf("%w: a response is required", ErrInvalid)
+	}
+
+	if err := update(d, AwaitingSeller, map[string]interface{}{
+		"status":          UnderReview,
+		"seller_response": response,
+		"responded_at":    now,
+	}); err != nil {
+		return err
+	}
+	if acceptRefund {
+		return Resolve(ctx, p, d, sellerID, Refund, nil, "Seller accepted a refund", now)
+	}
+	publish(events.DisputeResponded, d.BuyerID, d)
+	return nil
+}
+
+// Withdraw closes the case at the buyer's request. The order's hold is lifted
+// and escrow releases to the seller on its usual schedule.
+func Withdraw(d *Dispute, buyerID string, now time.Time) error {
+	switch {
+	case d.BuyerID != buyerID:
+		return ErrForbidden
+	case !d.Status.Open():
+		return ErrClosed
+	}
+	if err := update(d, d.Status, map[string]interface{}{"status": Withdrawn, "resolved_at": now}); err != nil {
+		return err
+	}
+	o, err := payments.Get(d.OrderID)
+	if err != nil {
+		return err
+	}
+	return payments.Patch(o, map[string]interface{}{"disputed": false})
+}
+
+// Resolve closes the case and moves the escrowed money accordingly: a refund
+// returns it all to the buyer, a partial refund returns refund and releases
+// the rest, and a rejection releases it all to the seller.
+func Resolve(ctx context.Context, p payments.Provider, d *Dispute, resolverID string, r Resolution, refund *money.Money, note string, now time.Time) error {
+	if !d.Status.Open() {
+		return ErrClosed
+	}
+	o, err := payments.Get(d.OrderID)
+	if err != nil {
+		return err
+	}
+	var amount money.Money
+	switch r {
+	case Refund:
+		amount = o.Amount
+	case PartialRefund:
+		if refund == nil {
+			return fmt.Errorf("%w: a partial refund needs refund_amount", ErrInvalid)
+		}
+		if amount, err = refund.In(o.Currency); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		if !amount.IsPositive() || amount.Cmp(o.Amount) >= 0 {
+			return fmt.Errorf("%w: refund_amount must be between zero and %s", ErrInvalid, o.Amount)
+		}
+	case Rejected:
+	default:
+		return fmt.Errorf("%w: resolution must be %s, %s or %s", ErrInvalid, Refund, PartialRefund, Rejected)
+	}
+
+	// Claim the case before moving money so two resolutions can't both pay out
+	patch := map[string]interface{}{
+		"status":          Resolved,
+		"resolution":      r,
+		"resolved_by":     resolverID,
+		"resolution_note": note,
+		"resolved_at":     now,
+	}
+	if r != Rejected {
+		patch["refund_amount"] = amount
+	}
+	if err := update(d, d.Status, patch); err != nil {
+		return err
+	}
+
+	// The order stays held until the money has moved
+	if err := payments.SettleDispute(ctx, p, o, amount, now); err != nil {
+		return fmt.Errorf("dispute resolved but escrow failed to settle: %w", err)
+	}
+
+	for _, id := range []string{d.BuyerID, d.SellerID} {
+		if err := updateReputation(id); err != nil {
+			log.Printf("Warning: failed to update dispute record for %s: %v", id, err)
+		}
+		publish(events.DisputeResolved, id, d)
+	}
+	return nil
+}
+
+// update patches d if it is still in status from.
+func update(d *Dispute, from Status, patch map[string]interface{}) error {
+	var updated []Dispute
+	query := "id=eq." + url.QueryEscape(d.ID) + "&status=eq." + string(from)
+	if err := supabase.Update(table, query, patch, &updated); err != nil {
+		return err
+	}
+	if len(updated) == 0 {
+		return ErrClosed
+	}
+	*d = updated[0]
+	d.round()
+	return nil
+}
+
+// Get returns the dispute with the given ID.
+func Get(id string) (*Dispute, error) {
+	rows, err := list("id=eq." + url.QueryEscape(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return &rows[0], nil
+}
+
+// ForUser returns the disputes userID is the buyer or seller in.
+func ForUser(userID string) ([]Dispute, error) {
+	id := url.QueryEscape(userID)
+	return list("or=(buyer_id.eq." + id + ",seller_id.eq." + id + ")")
+}
+
+// WithStatus returns every dispute in status, for the admin queue.
+func WithStatus(s Status) ([]Dispute, error) {
+	return list("status=eq." + url.QueryEscape(string(s)))
+}
+
+// EvidenceFor returns the evidence attached to a dispute, oldest first.
+func EvidenceFor(disputeID string) ([]Evidence, error) {
+	var rows []Evidence
+	query := "dispute_id=eq." + url.QueryEscape(disputeID) + "&order=created_at.asc"
+	if err := supabase.Select(evidenceTable, query, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func list(filter string) ([]Dispute, error) {
+	var rows []Dispute
+	if err := supabase.Select(table, filter+"&order=created_at.desc", &rows); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].round()
+	}
+	return rows, nil
+}
+
+// round puts amounts read back from the table in the dispute's currency.
+func (d *Dispute) round() {
+	if d.Currency == "" {
+		d.Currency = money.DefaultCurrency
+	}
+	if d.RefundAmount != nil {
+		*d.RefundAmount = d.RefundAmount.Round(d.Currency)
+	}
+}
+
+// Run calls EscalateOverdue every interval until ctx is cancelled.
+func Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := EscalateOverdue(time.Now().UTC()); err != nil {
+				log.Printf("Warning: dispute escalation failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Escalated %d disputes without a seller response", n)
+			}
+		}
+	}
+}
+
+// EscalateOverdue sends cases the seller didn't respond to in time for
+// review.
+func EscalateOverdue(now time.Time) (int, error) {
+	var escalated []Dispute
+	query := "status=eq." + string(AwaitingSeller) + "&response_due_at=lte." + url.QueryEscape(now.Format(time.RFC3339))
+	if err := supabase.Update(table, query, map[string]interface{}{"status": UnderReview}, &escalated); err != nil {
+		return 0, err
+	}
+	return len(escalated), nil
+}
+
+// updateReputation stores a user's dispute record on their profile, where
+// trust scoring reads it: cases lost as a seller, and cases rejected as a
+// buyer.
+func updateReputation(userID string) error {
+	var rows []Dispute
+	id := url.QueryEscape(userID)
+	query := "select=buyer_id,seller_id,resolution&status=eq." + string(Resolved) +
+		"&or=(buyer_id.eq." + id + ",seller_id.eq." + id + ")"
+	if err := supabase.Select(table, query, &rows); err != nil {
+		return err
+	}
+	lost, rejected := 0, 0
+	for _, d := range rows {
+		switch {
+		case d.SellerID == userID && d.Resolution != Rejected:
+			lost++
+		case d.BuyerID == userID && d.Resolution == Rejected:
+			rejected++
+		}
+	}
+	patch := map[string]int{"disputes_lost_count": lost, "disputes_rejected_count": rejected}
+	return supabase.Update("profiles", "id=eq."+id, patch, nil)
+}
+
+func publish(t events.Type, userID string, d *Dispute) {
+	data := map[string]string{"title": d.Title, "dispute_id": d.ID, "reason": d.Reason}
+	if d.Resolution != "" {
+		data["resolution"] = string(d.Resolution)
+	}
+	if d.RefundAmount != nil {
+		data["amount"] = d.RefundAmount.String()
+	}
+	events.Publish(events.Event{Type: t, UserID: userID, ListingID: d.ListingID, Data: data})
+}
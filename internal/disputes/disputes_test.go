@@ -0,0 +1,86 @@
+package disputes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/payments"
+)
+
+func TestOpenChecks(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	paidAt := now.Add(-24 * time.Hour)
+	longAgo := now.Add(-OpenWindow - time.Hour)
+	o := payments.Order{ID: "o1", BuyerID: "buyer", SellerID: "seller", Status: payments.Paid, PaidAt: &paidAt, Amount: money.New(4000, "USD")}
+	pending := o
+	pending.Status = payments.Pending
+	disputed := o
+	disputed.Disputed = true
+	late := o
+	late.PaidAt = &longAgo
+
+	for _, tc := range []struct {
+		name        string
+		o           payments.Order
+		user        string
+		reason      string
+		description string
+		err         error
+	}{
+		{"seller can't open", o, "seller", Damaged, "Cracked", ErrForbidden},
+		{"not paid", pending, "buyer", Damaged, "Cracked", ErrClosed},
+		{"already disputed", disputed, "buyer", Damaged, "Cracked", ErrClosed},
+		{"window passed", late, "buyer", Damaged, "Cracked", ErrClosed},
+		{"unknown reason", o, "buyer", "changed_mind", "Cracked", ErrInvalid},
+		{"no description", o, "buyer", NotReceived, "", ErrInvalid},
+	} {
+		o := tc.o
+		if _, err := Open(&o, tc.user, tc.reason, tc.description, now); !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestCaseChecks(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	p := &payments.FakeProvider{}
+	d := Dispute{ID: "d1", OrderID: "o1", BuyerID: "buyer", SellerID: "seller", Status: AwaitingSeller}
+	resolved := d
+	resolved.Status = Resolved
+
+	if _, err := AddEvidence(&d, "stranger", "https://img.example/1.jpg", "", now); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for evidence from a stranger, got %v", err)
+	}
+	if _, err := AddEvidence(&d, "buyer", "javascript:alert(1)", "", now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected ErrInvalid for a non-http url, got %v", err)
+	}
+	if _, err := AddEvidence(&resolved, "buyer", "", "Photo of the box", now); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed for evidence on a resolved case, got %v", err)
+	}
+
+	if err := Respond(ctx, p, &d, "buyer", "No", false, now); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for the buyer responding, got %v", err)
+	}
+	if err := Respond(ctx, p, &d, "seller", "", false, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected ErrInvalid for an empty response, got %v", err)
+	}
+	review := d
+	review.Status = UnderReview
+	if err := Respond(ctx, p, &review, "seller", "It was fine", false, now); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed responding twice, got %v", err)
+	}
+
+	if err := Withdraw(&d, "seller", now); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for the seller withdrawing, got %v", err)
+	}
+	if err := Withdraw(&resolved, "buyer", now); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed withdrawing a resolved case, got %v", err)
+	}
+	if err := Resolve(ctx, p, &resolved, "admin", Refund, nil, "", now); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed resolving twice, got %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+// Package drafts keeps listings that sellers are still writing: autosaved
+// data, optionally scheduled to publish at a set time.
+package drafts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Drafts live in the Supabase `listing_drafts` table.
+const table = "listing_drafts"
+
+// Status is where a draft is in its lifecycle.
+type Status string
+
+const (
+	// Editing drafts are being filled in and autosaved.
+	Editing Status = "draft"
+	// Scheduled drafts passed validation and publish at PublishAt.
+	Scheduled Status = "scheduled"
+	// Publishing is held briefly while a scheduled draft is being published.
+	Publishing Status = "publishing"
+	// Published drafts have become a listing.
+	Published Status = "published"
+	// Failed scheduled drafts no longer passed validation at publish time.
+	Failed Status = "failed"
+)
+
+// Draft is a listing still being written. Data may be incomplete.
+type Draft struct {
+	ID        string        `json:"id,omitempty"`
+	SellerID  string        `json:"seller_id"`
+	Data      listing.Input `json:"data"`
+	Status    Status        `json:"status"`
+	PublishAt *time.Time    `json:"publish_at"`
+	ListingID *string       `json:"listing_id"`
+	Error     string        `json:"error"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+var (
+	// ErrNotFound is returned for drafts that don't exist or aren't the
+	// caller's.
+	ErrNotFound = errors.New("draft not found")
+	// ErrPublished is returned when changing a draft that is already a
+	// listing.
+	ErrPublished = errors.New("draft has already been published")
+	// ErrInvalid wraps validation failures of the fields a draft has.
+	ErrInvalid = errors.New("invalid draft")
+)
+
+// Create stores a new draft after checking the fields it has.
+func Create(sellerID string, in listing.Input) (*Draft, error) {
+	if err := in.Check(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	now := time.Now().UTC()
+	d := Draft{SellerID: sellerID, Data: in, Status: Editing, CreatedAt: now, UpdatedAt: now}
+	var inserted []Draft
+	if err := supabase.Insert(table, []Draft{d}, &inserted); err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+	if len(inserted) == 0 {
+		return nil, fmt.Errorf("failed to save draft: no row returned")
+	}
+	return &inserted[0], nil
+}
+
+// Get returns one of sellerID's drafts.
+func Get(sellerID, id string) (*Draft, error) {
+	var rows []Draft
+	query := "id=eq." + url.QueryEscape(id) + "&seller_id=eq." + url.QueryEscape(sellerID)
+	if err := supabase.Select(table, query, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return &rows[0], nil
+}
+
+// ForSeller returns sellerID's unpublished drafts, most recently edited
+// first.
+func ForSeller(sellerID string) ([]Draft, error) {
+	var rows []Draft
+	query := "seller_id=eq." + url.QueryEscape(sellerID) + "&status=neq." + string(Published) + "&order=updated_at.desc"
+	if err := supabase.Select(table, query, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Save replaces a draft's data, as sent by autosave. Saving a scheduled
+// draft cancels the schedule.
+func Save(sellerID, id string, in listing.Input) (*Draft, error) {
+	d, err := Get(sellerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status == Published || d.Status == Publishing {
+		return nil, ErrPublished
+	}
+	if err := in.Check(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	// Only overwrite the status we read, so a save racing the scheduler
+	// can't pull a draft back out of publishing.
+	query := "id=eq." + url.QueryEscape(id) + "&status=eq." + url.QueryEscape(string(d.Status))
+	d.Data, d.Status, d.PublishAt, d.Error = in, Editing, nil, ""
+	d.UpdatedAt = time.Now().UTC()
+	patch := map[string]interface{}{
+		"data":       d.Data,
+		"status":     d.Status,
+		"publish_at": nil,
+		"error":      "",
+		"updated_at": d.UpdatedAt,
+	}
+	var rows []Draft
+	if err := supabase.Update(table, query, patch, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrPublished
+	}
+	return d, nil
+}
+
+// Delete discards one of sellerID's drafts.
+func Delete(sellerID, id string) error {
+	if _, err := Get(sellerID, id); err != nil {
+		return err
+	}
+	return supabase.Delete(table, "id=eq."+url.QueryEscape(id))
+}
+
+// Schedule marks a validated draft to be published at at.
+func Schedule(d *Draft, at time.Time) error {
+	d.Status, d.PublishAt, d.Error = Scheduled, &at, ""
+	d.UpdatedAt = time.Now().UTC()
+	patch := map[string]interface{}{
+		"status":     d.Status,
+		"publish_at": at,
+		"error":      "",
+		"updated_at": d.UpdatedAt,
+	}
+	return supabase.Update(table, "id=eq."+url.QueryEscape(d.ID), patch, nil)
+}
+
+// MarkPublished records the listing a draft became.
+func MarkPublished(d *Draft, listingID string) error {
+	d.Status, d.ListingID, d.Error = Published, &listingID, ""
+	d.UpdatedAt = time.Now().UTC()
+	patch := map[string]interface{}{
+		"status":     d.Status,
+		"listing_id": listingID,
+		"error":      "",
+		"updated_at": d.UpdatedAt,
+	}
+	return supabase.Update(table, "id=eq."+url.QueryEscape(d.ID), patch, nil)
+}
+
+func markFailed(d *Draft, reason error) error {
+	patch := map[string]interface{}{
+		"status":     Failed,
+		"error":      reason.Error(),
+		"updated_at": time.Now().UTC(),
+	}
+	return supabase.Update(table, "id=eq."+url.QueryEscape(d.ID), patch, nil)
+}
+
+// Publish stores a validated listing and returns its ID. It is set by the
+// server so scheduled drafts go through the same path as new listings.
+var Publish func(ctx context.Context, l *listing.Listing) (string, error)
+
+// Run publishes scheduled drafts that are due every interval until ctx is
+// cancelled.
+func Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := PublishDue(ctx, time.Now().UTC()); err != nil {
+				log.Printf("Warning: scheduled publishing failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Published %d scheduled drafts", n)
+			}
+		}
+	}
+}
+
+// PublishDue publishes every scheduled draft whose publish time has passed.
+// Drafts that no longer validate are marked failed with the reason.
+func PublishDue(ctx context.Context, now time.Time) (int, error) {
+	if Publish == nil {
+		return 0, fmt.Errorf("drafts.Publish is not set")
+	}
+
+	var due []Draft
+	query := "status=eq." + string(Scheduled) + "&publish_at=lte." + url.QueryEscape(now.Format(time.RFC3339))
+	if err := supabase.Select(table, query, &due); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for i := range due {
+		d := &due[i]
+
+		// Claim the draft so an overlapping run can't publish it twice
+		var claimed []Draft
+		claim := "id=eq." + url.QueryEscape(d.ID) + "&status=eq." + string(Scheduled)
+		if err := supabase.Update(table, claim, map[string]interface{}{"status": Publishing}, &claimed); err != nil || len(claimed) == 0 {
+			continue
+		}
+
+		l, err := d.Data.Build(d.SellerID)
+		if err != nil {
+			if err := markFailed(d, err); err != nil {
+				log.Printf("Warning: failed to mark draft %s as failed: %v", d.ID, err)
+			}
+			continue
+		}
+		id, err := Publish(ctx, l)
+		if err != nil {
+			log.Printf("Warning: failed to publish draft %s: %v", d.ID, err)
+			if err := Schedule(d, *d.PublishAt); err != nil {
+				log.Printf("Warning: failed to reschedule draft %s: %v", d.ID, err)
+			}
+			continue
+		}
+		if err := MarkPublished(d, id); err != nil {
+			log.Printf("Warning: draft %s published as %s but not marked: %v", d.ID, id, err)
+		}
+		published++
+	}
+	return published, nil
+}
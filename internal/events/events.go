@@ -0,0 +1,142 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Type identifies what happened.
+type Type string
+
+const (
+	// Outbid is sent to the previous highest bidder when someone bids higher.
+	Outbid Type = "outbid"
+	// AuctionWon is sent to the winning bidder when an auction settles.
+	AuctionWon Type = "auction_won"
+	// ItemSold is sent to the seller when an auction settles with a winner.
+	ItemSold Type = "item_sold"
+	// EndingSoon is sent to watchers when an auction enters its final hour.
+	EndingSoon Type = "ending_soon"
+	// NewMessage is sent to the other party of a post-auction conversation.
+	NewMessage Type = "new_message"
+	// QuestionAnswered is sent to the asker and bidders when a seller answers.
+	QuestionAnswered Type = "question_answered"
+	// SavedSearchMatch is sent when a new listing matches an instant saved search.
+	SavedSearchMatch Type = "saved_search_match"
+	// SavedSearchDigest is the daily summary of matches for a saved search.
+	SavedSearchDigest Type = "saved_search_digest"
+	// BidRetracted is sent to the seller when a bidder retracts a bid.
+	BidRetracted Type = "bid_retracted"
+	// LeadRestored is sent to the bidder who is back in the lead after the
+	// bid above theirs was retracted.
+	LeadRestored Type = "lead_restored"
+	// OfferReceived is sent to the seller when a buyer makes an offer.
+	OfferReceived Type = "offer_received"
+	// OfferCountered is sent to the buyer when the seller counters.
+	OfferCountered Type = "offer_countered"
+	// OfferAccepted is sent to the other party when an offer is accepted.
+	OfferAccepted Type = "offer_accepted"
+	// OfferDeclined is sent to the other party when an offer is declined.
+	OfferDeclined Type = "offer_declined"
+	// SecondChanceOffer is sent to a runner-up offered an item after close.
+	SecondChanceOffer Type = "second_chance_offer"
+	// SecondChanceAccepted and SecondChanceDeclined tell the seller how the
+	// runner-up answered.
+	SecondChanceAccepted Type = "second_chance_accepted"
+	SecondChanceDeclined Type = "second_chance_declined"
+	// PaymentReceived is sent to the seller when the buyer pays into escrow.
+	PaymentReceived Type = "payment_received"
+	// PaymentOverdue is sent to the buyer when they miss the payment deadline.
+	PaymentOverdue Type = "payment_overdue"
+	// UnpaidItem is sent to the seller when the buyer misses the payment
+	// deadline, with the options to relist or make a second-chance offer.
+	UnpaidItem Type = "unpaid_item"
+	// BiddingRestricted is sent to a buyer whose unpaid-item strikes reach
+	// the limit.
+	BiddingRestricted Type = "bidding_restricted"
+	// PaymentReleased is sent to the seller when escrow pays them out.
+	PaymentReleased Type = "payment_released"
+	// PaymentRefunded is sent to the buyer when escrow refunds them.
+	PaymentRefunded Type = "payment_refunded"
+	// OrderShipped is sent to the buyer when the seller adds tracking.
+	OrderShipped Type = "order_shipped"
+	// DisputeOpened is sent to the seller when a buyer disputes an order.
+	DisputeOpened Type = "dispute_opened"
+	// DisputeResponded is sent to the buyer when the seller responds to
+	// their dispute.
+	DisputeResponded Type = "dispute_responded"
+	// DisputeResolved is sent to both parties when a dispute is resolved.
+	DisputeResolved Type = "dispute_resolved"
+)
+
+// Event is a single domain event addressed to one user.
+type Event struct {
+	Type      Type              `json:"type"`
+	UserID    string            `json:"user_id"`
+	ListingID string            `json:"listing_id"`
+	Data      map[string]string `json:"data,omitempty"`
+	At        time.Time         `json:"at"`
+}
+
+// Handler consumes events published on a Bus.
+type Handler func(Event)
+
+// Bus fans events out to subscribers. Handlers run on their own goroutine so
+// publishing never blocks the request that produced the event.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+	all      []Handler
+}
+
+// NewBus creates an empty event bus.
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[Type][]Handler)}
+}
+
+// Subscribe registers h for events of type t.
+func (b *Bus) Subscribe(t Type, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[t] = append(b.handlers[t], h)
+}
+
+// SubscribeAll registers h for every event type.
+func (b *Bus) SubscribeAll(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.all = append(b.all, h)
+}
+
+// Publish delivers e to every matching subscriber.
+func (b *Bus) Publish(e Event) {
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	targets := make([]Handler, 0, len(b.handlers[e.Type])+len(b.all))
+	targets = append(targets, b.handlers[e.Type]...)
+	targets = append(targets, b.all...)
+	b.mu.RUnlock()
+
+	for _, h := range targets {
+		go func(h Handler) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Warning: event handler for %s panicked: %v", e.Type, r)
+				}
+			}()
+			h(e)
+		}(h)
+	}
+}
+
+// Default is the process-wide bus used by handlers and background jobs.
+var Default = NewBus()
+
+// Publish publishes e on the Default bus.
+func Publish(e Event) {
+	Default.Publish(e)
+}
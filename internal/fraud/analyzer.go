@@ -0,0 +1,312 @@
+package fraud
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Rule names used in flags.
+const (
+	RuleSingleSeller      = "single_seller"
+	RuleBidRetract        = "bid_retract"
+	RuleSharedFingerprint = "shared_fingerprint"
+	RulePricePumping      = "price_pumping"
+)
+
+// BidRecord is one entry from the bid ledger.
+type BidRecord struct {
+	ID        string    `json:"id"`
+	ListingID string    `json:"listing_id"`
+	UserID    string    `json:"user_id"`
+	BidAmount float64   `json:"bid_amount"`
+	Timestamp time.Time `json:"timestamp"`
+	Status    string    `json:"status"`
+}
+
+// ListingInfo is the part of a listing the analyzer needs.
+type ListingInfo struct {
+	ID          string  `json:"id"`
+	SellerID    string  `json:"seller_id"`
+	WinnerID    string  `json:"winner_id"`
+	StartingBid float64 `json:"starting_bid"`
+}
+
+// Ledger is everything the analyzer looks at.
+type Ledger struct {
+	Bids         []BidRecord
+	Listings     map[string]ListingInfo
+	Fingerprints []Fingerprint
+}
+
+// Config tunes the analyzer's thresholds.
+type Config struct {
+	// MinBidsSingleSeller is how many bids an account needs, all on one
+	// seller's listings, before it is flagged.
+	MinBidsSingleSeller int
+	// MinRetractions flags accounts retracting at least this many bids.
+	MinRetractions int
+	// PumpIncrement is the largest raise, as a fraction of the previous
+	// price, that counts as a minimal increment.
+	PumpIncrement float64
+	// MinPumpBids is how many minimal raises on one listing count as pumping.
+	MinPumpBids int
+	// ReviewThreshold is the risk score at which a case is queued for review.
+	ReviewThreshold int
+}
+
+// DefaultConfig is used by the scanner.
+var DefaultConfig = Config{
+	MinBidsSingleSeller: 3,
+	MinRetractions:      2,
+	PumpIncrement:       0.05,
+	MinPumpBids:         3,
+	ReviewThreshold:     40,
+}
+
+// Flag is a single suspicious pattern between a bidder and a seller.
+type Flag struct {
+	Rule       string   `json:"rule"`
+	Score      int      `json:"score"`
+	Detail     string   `json:"detail"`
+	ListingIDs []string `json:"listing_ids,omitempty"`
+}
+
+// Case groups the flags raised for one bidder–seller pair.
+type Case struct {
+	UserID    string `json:"user_id"`
+	SellerID  string `json:"seller_id"`
+	RiskScore int    `json:"risk_score"`
+	Flags     []Flag `json:"flags"`
+}
+
+// Analyze runs every rule over the ledger and returns the cases at or above
+// cfg.ReviewThreshold, riskiest first.
+func Analyze(l Ledger, cfg Config) []Case {
+	cases := make(map[[2]string]*Case)
+	flag := func(userID, sellerID string, f Flag) {
+		key := [2]string{userID, sellerID}
+		c, ok := cases[key]
+		if !ok {
+			c = &Case{UserID: userID, SellerID: sellerID}
+			cases[key] = c
+		}
+		c.Flags = append(c.Flags, f)
+		c.RiskScore += f.Score
+		if c.RiskScore > 100 {
+			c.RiskScore = 100
+		}
+	}
+
+	byUser := make(map[string][]BidRecord)
+	for _, b := range l.Bids {
+		if _, ok := l.Listings[b.ListingID]; !ok {
+			continue
+		}
+		byUser[b.UserID] = append(byUser[b.UserID], b)
+	}
+
+	for userID, bids := range byUser {
+		checkSingleSeller(l, cfg, userID, bids, flag)
+		checkRetractions(l, cfg, userID, bids, flag)
+	}
+	checkPricePumping(l, cfg, flag)
+	checkSharedFingerprints(l, byUser, flag)
+
+	var out []Case
+	for _, c := range cases {
+		if c.RiskScore >= cfg.ReviewThreshold {
+			out = append(out, *c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].RiskScore != out[j].RiskScore {
+			return out[i].RiskScore > out[j].RiskScore
+		}
+		if out[i].UserID != out[j].UserID {
+			return out[i].UserID < out[j].UserID
+		}
+		return out[i].SellerID < out[j].SellerID
+	})
+	return out
+}
+
+type flagFunc func(userID, sellerID string, f Flag)
+
+// checkSingleSeller flags accounts whose bids all go to one seller.
+func checkSingleSeller(l Ledger, cfg Config, userID string, bids []BidRecord, flag flagFunc) {
+	if len(bids) < cfg.MinBidsSingleSeller {
+		return
+	}
+	sellerID := ""
+	listingSet := make(map[string]bool)
+	won := false
+	for _, b := range bids {
+		info := l.Listings[b.ListingID]
+		if sellerID == "" {
+			sellerID = info.SellerID
+		} else if info.SellerID != sellerID {
+			return
+		}
+		listingSet[b.ListingID] = true
+		if info.WinnerID == userID {
+			won = true
+		}
+	}
+	if len(listingSet) < 2 {
+		return
+	}
+
+	score := 30
+	if !won {
+		score += 10
+	}
+	flag(userID, sellerID, Flag{
+		Rule:       RuleSingleSeller,
+		Score:      score,
+		Detail:     fmt.Sprintf("all %d bids across %d listings went to one seller (won any: %v)", len(bids), len(listingSet), won),
+		ListingIDs: sortedKeys(listingSet),
+	})
+}
+
+// checkRetractions flags accounts that repeatedly bid then retract.
+func checkRetractions(l Ledger, cfg Config, userID string, bids []BidRecord, flag flagFunc) {
+	bySeller := make(map[string]map[string]bool)
+	for _, b := range bids {
+		if b.Status != "retracted" {
+			continue
+		}
+		sellerID := l.Listings[b.ListingID].SellerID
+		if bySeller[sellerID] == nil {
+			bySeller[sellerID] = make(map[string]bool)
+		}
+		bySeller[sellerID][b.ListingID] = true
+	}
+
+	total := 0
+	for _, listings := range bySeller {
+		total += len(listings)
+	}
+	if total < cfg.MinRetractions {
+		return
+	}
+	for sellerID, listings := range bySeller {
+		flag(userID, sellerID, Flag{
+			Rule:       RuleBidRetract,
+			Score:      15 + 10*len(listings),
+			Detail:     fmt.Sprintf("retracted bids on %d of this seller's listings (%d overall)", len(listings), total),
+			ListingIDs: sortedKeys(listings),
+		})
+	}
+}
+
+// checkPricePumping flags bidders that repeatedly nudge the price up by the
+// smallest amount on an auction they don't go on to win.
+func checkPricePumping(l Ledger, cfg Config, flag flagFunc) {
+	byListing := make(map[string][]BidRecord)
+	for _, b := range l.Bids {
+		if _, ok := l.Listings[b.ListingID]; ok && b.Status != "retracted" {
+			byListing[b.ListingID] = append(byListing[b.ListingID], b)
+		}
+	}
+
+	for listingID, bids := range byListing {
+		info := l.Listings[listingID]
+		sort.Slice(bids, func(i, j int) bool { return bids[i].Timestamp.Before(bids[j].Timestamp) })
+
+		minimal := make(map[string]int)
+		price := info.StartingBid
+		for _, b := range bids {
+			if price > 0 && b.BidAmount > price && (b.BidAmount-price)/price <= cfg.PumpIncrement {
+				minimal[b.UserID]++
+			}
+			if b.BidAmount > price {
+				price = b.BidAmount
+			}
+		}
+
+		for userID, n := range minimal {
+			if n < cfg.MinPumpBids || userID == info.WinnerID {
+				continue
+			}
+			flag(userID, info.SellerID, Flag{
+				Rule:       RulePricePumping,
+				Score:      20 + 5*(n-cfg.MinPumpBids),
+				Detail:     fmt.Sprintf("%d minimal raises without winning", n),
+				ListingIDs: []string{listingID},
+			})
+		}
+	}
+}
+
+// checkSharedFingerprints flags bidders seen on the same device or network
+// as the seller whose listings they bid on.
+func checkSharedFingerprints(l Ledger, byUser map[string][]BidRecord, flag flagFunc) {
+	devices := make(map[string]map[string]bool)
+	ips := make(map[string]map[string]bool)
+	for _, fp := range l.Fingerprints {
+		if fp.DeviceID != "" {
+			if devices[fp.UserID] == nil {
+				devices[fp.UserID] = make(map[string]bool)
+			}
+			devices[fp.UserID][fp.DeviceID] = true
+		}
+		if fp.IP != "" {
+			if ips[fp.UserID] == nil {
+				ips[fp.UserID] = make(map[string]bool)
+			}
+			ips[fp.UserID][fp.IP] = true
+		}
+	}
+
+	for userID, bids := range byUser {
+		sellers := make(map[string]map[string]bool)
+		for _, b := range bids {
+			sellerID := l.Listings[b.ListingID].SellerID
+			if sellerID == "" || sellerID == userID {
+				continue
+			}
+			if sellers[sellerID] == nil {
+				sellers[sellerID] = make(map[string]bool)
+			}
+			sellers[sellerID][b.ListingID] = true
+		}
+
+		for sellerID, listings := range sellers {
+			switch {
+			case overlaps(devices[userID], devices[sellerID]):
+				flag(userID, sellerID, Flag{
+					Rule:       RuleSharedFingerprint,
+					Score:      50,
+					Detail:     "bidder and seller used the same device",
+					ListingIDs: sortedKeys(listings),
+				})
+			case overlaps(ips[userID], ips[sellerID]):
+				flag(userID, sellerID, Flag{
+					Rule:       RuleSharedFingerprint,
+					Score:      35,
+					Detail:     "bidder and seller used the same IP address",
+					ListingIDs: sortedKeys(listings),
+				})
+			}
+		}
+	}
+}
+
+func overlaps(a, b map[string]bool) bool {
+	for k := range a {
+		if b[k] {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,82 @@
+package fraud
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalyze(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return start.Add(time.Duration(min) * time.Minute) }
+
+	ledger := Ledger{
+		Listings: map[string]ListingInfo{
+			"l1": {ID: "l1", SellerID: "seller", WinnerID: "honest", StartingBid: 100},
+			"l2": {ID: "l2", SellerID: "seller", WinnerID: "honest", StartingBid: 100},
+			"l3": {ID: "l3", SellerID: "other", WinnerID: "honest", StartingBid: 100},
+		},
+		Bids: []BidRecord{
+			// shill only bids on seller's listings, in minimal raises
+			{ListingID: "l1", UserID: "shill", BidAmount: 101, Timestamp: at(1)},
+			{ListingID: "l1", UserID: "honest", BidAmount: 120, Timestamp: at(2)},
+			{ListingID: "l1", UserID: "shill", BidAmount: 122, Timestamp: at(3)},
+			{ListingID: "l1", UserID: "shill", BidAmount: 124, Timestamp: at(4)},
+			{ListingID: "l1", UserID: "honest", BidAmount: 150, Timestamp: at(5)},
+			{ListingID: "l2", UserID: "shill", BidAmount: 110, Timestamp: at(6)},
+			{ListingID: "l2", UserID: "honest", BidAmount: 130, Timestamp: at(7)},
+			// honest spreads bids over sellers
+			{ListingID: "l3", UserID: "honest", BidAmount: 200, Timestamp: at(8)},
+		},
+		Fingerprints: []Fingerprint{
+			{UserID: "shill", DeviceID: "dev-1", IP: "10.0.0.1"},
+			{UserID: "seller", DeviceID: "dev-1", IP: "10.0.0.2"},
+			{UserID: "honest", DeviceID: "dev-2", IP: "10.0.0.1"},
+		},
+	}
+
+	cases := Analyze(ledger, DefaultConfig)
+	if len(cases) != 1 {
+		t.Fatalf("Expected 1 case, got %d: %+v", len(cases), cases)
+	}
+
+	c := cases[0]
+	if c.UserID != "shill" || c.SellerID != "seller" {
+		t.Errorf("Expected shill/seller case, got %s/%s", c.UserID, c.SellerID)
+	}
+	if c.RiskScore != 100 {
+		t.Errorf("Expected capped risk score of 100, got %d", c.RiskScore)
+	}
+
+	rules := map[string]bool{}
+	for _, f := range c.Flags {
+		rules[f.Rule] = true
+	}
+	for _, rule := range []string{RuleSingleSeller, RuleSharedFingerprint, RulePricePumping} {
+		if !rules[rule] {
+			t.Errorf("Expected %s flag, got %+v", rule, c.Flags)
+		}
+	}
+}
+
+func TestAnalyzeRetractions(t *testing.T) {
+	ledger := Ledger{
+		Listings: map[string]ListingInfo{
+			"l1": {ID: "l1", SellerID: "seller", StartingBid: 10},
+			"l2": {ID: "l2", SellerID: "seller", StartingBid: 10},
+		},
+		Bids: []BidRecord{
+			{ListingID: "l1", UserID: "u1", BidAmount: 50, Status: "retracted"},
+			{ListingID: "l2", UserID: "u1", BidAmount: 60, Status: "retracted"},
+		},
+	}
+
+	cfg := DefaultConfig
+	cfg.ReviewThreshold = 1
+	cases := Analyze(ledger, cfg)
+	if len(cases) != 1 || cases[0].Flags[0].Rule != RuleBidRetract {
+		t.Fatalf("Expected a single retraction case, got %+v", cases)
+	}
+	if cases[0].RiskScore != 35 {
+		t.Errorf("Expected risk score 35, got %d", cases[0].RiskScore)
+	}
+}
@@ -0,0 +1,49 @@
+package fraud
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/ratelimit"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Fingerprint records the network and device a user acted from.
+type Fingerprint struct {
+	UserID   string    `json:"user_id"`
+	IP       string    `json:"ip"`
+	DeviceID string    `json:"device_id"`
+	SeenAt   time.Time `json:"seen_at"`
+}
+
+// FingerprintFromRequest builds a fingerprint for userID from r. The IP is
+// resolved as the rate limiter does, so only trusted proxies can set it. The
+// device ID is taken from the X-Device-ID header set by the clients, falling
+// back to a hash of the User-Agent.
+func FingerprintFromRequest(userID string, r *http.Request) Fingerprint {
+	ip := ratelimit.ClientIP(r)
+
+	device := r.Header.Get("X-Device-ID")
+	if device == "" {
+		sum := sha256.Sum256([]byte(r.UserAgent()))
+		device = "ua:" + hex.EncodeToString(sum[:8])
+	}
+
+	return Fingerprint{UserID: userID, IP: ip, DeviceID: device, SeenAt: time.Now().UTC()}
+}
+
+// RecordFingerprint stores fp for later analysis. It is best-effort and
+// never blocks the caller.
+func RecordFingerprint(fp Fingerprint) {
+	if fp.UserID == "" {
+		return
+	}
+	go func() {
+		if err := supabase.Insert("user_fingerprints", []Fingerprint{fp}, nil); err != nil {
+			log.Printf("Warning: failed to record fingerprint for %s: %v", fp.UserID, err)
+		}
+	}()
+}
@@ -0,0 +1,85 @@
+package fraud
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// ReviewItem is a row in the fraud_review_queue table.
+type ReviewItem struct {
+	UserID    string    `json:"user_id"`
+	SellerID  string    `json:"seller_id"`
+	RiskScore int       `json:"risk_score"`
+	Flags     []Flag    `json:"flags"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LoadLedger reads the bid ledger, listings and fingerprints from Supabase.
+func LoadLedger() (Ledger, error) {
+	var l Ledger
+	if err := supabase.Select("bids", "select=id,listing_id,user_id,bid_amount,timestamp,status", &l.Bids); err != nil {
+		return l, fmt.Errorf("load bids: %w", err)
+	}
+
+	var listings []struct {
+		ID          string  `json:"id"`
+		SellerID    string  `json:"seller_id"`
+		WinnerID    *string `json:"winner_id"`
+		StartingBid float64 `json:"starting_bid"`
+	}
+	if err := supabase.Select("listings", "select=id,seller_id,winner_id,starting_bid", &listings); err != nil {
+		return l, fmt.Errorf("load listings: %w", err)
+	}
+	l.Listings = make(map[string]ListingInfo, len(listings))
+	for _, row := range listings {
+		info := ListingInfo{ID: row.ID, SellerID: row.SellerID, StartingBid: row.StartingBid}
+		if row.WinnerID != nil {
+			info.WinnerID = *row.WinnerID
+		}
+		l.Listings[row.ID] = info
+	}
+
+	if err := supabase.Select("user_fingerprints", "select=user_id,ip,device_id", &l.Fingerprints); err != nil {
+		return l, fmt.Errorf("load fingerprints: %w", err)
+	}
+	return l, nil
+}
+
+// Enqueue writes cases into the review queue, replacing the previous score
+// for the same bidder–seller pair.
+func Enqueue(cases []Case, now time.Time) error {
+	if len(cases) == 0 {
+		return nil
+	}
+	items := make([]ReviewItem, 0, len(cases))
+	for _, c := range cases {
+		items = append(items, ReviewItem{
+			UserID:    c.UserID,
+			SellerID:  c.SellerID,
+			RiskScore: c.RiskScore,
+			Flags:     c.Flags,
+			Status:    "open",
+			UpdatedAt: now,
+		})
+	}
+	if err := supabase.Upsert("fraud_review_queue", items, nil); err != nil {
+		return fmt.Errorf("enqueue review items: %w", err)
+	}
+	return nil
+}
+
+// Scan analyzes the whole ledger and queues suspicious pairs for review.
+func Scan(cfg Config) ([]Case, error) {
+	ledger, err := LoadLedger()
+	if err != nil {
+		return nil, err
+	}
+	cases := Analyze(ledger, cfg)
+	if err := Enqueue(cases, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
@@ -0,0 +1,231 @@
+// Package fulfilment coordinates the handoff of settled orders: the buyer
+// picks a pickup slot or shipping at checkout, the seller adds tracking, and
+// escrow is released when the buyer confirms delivery or after a timeout.
+package fulfilment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/events"
+	"github.com/quickswap/quickswap/internal/geo"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/payments"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Fulfilment methods a buyer can choose.
+const (
+	Pickup   = "pickup"
+	Shipping = "shipping"
+)
+
+// listingCompleted marks a listing whose orders have all been paid out.
+const listingCompleted = "completed"
+
+// Escrow is released automatically this long after the handoff if the buyer
+// hasn't confirmed delivery or raised a problem.
+const (
+	// ShippingTimeout runs from when the seller adds tracking.
+	ShippingTimeout = 14 * 24 * time.Hour
+	// PickupTimeout runs from the end of the chosen pickup slot.
+	PickupTimeout = 3 * 24 * time.Hour
+)
+
+var (
+	ErrInvalid    = errors.New("invalid fulfilment choice")
+	ErrNotOffered = errors.New("the seller doesn't offer that fulfilment")
+)
+
+// Choice is the buyer's fulfilment selection at checkout.
+type Choice struct {
+	Method string `json:"method"`
+	// Slot indexes the listing's pickup slots
+	Slot int `json:"slot"`
+	// Address is where shipped items go
+	Address string `json:"address,omitempty"`
+}
+
+// Choose records the buyer's choice on a pending order, adding any shipping
+// cost to the amount due. It can't change once checkout has started a
+// payment for the amount.
+func Choose(ctx context.Context, o *payments.Order, l *listing.Listing, userID string, c Choice, g geo.Geocoder, now time.Time) error {
+	switch {
+	case o.BuyerID != userID:
+		return payments.ErrForbidden
+	case o.Status != payments.Pending:
+		return payments.ErrState
+	case o.PaymentID != "":
+		return fmt.Errorf("%w: payment has already started", payments.ErrState)
+	case l.Fulfilment == nil:
+		return fmt.Errorf("%w: arrange the handoff with the seller in messages", ErrNotOffered)
+	}
+
+	itemPrice := o.Amount
+	if o.ShippingCost != nil {
+		itemPrice = itemPrice.Sub(*o.ShippingCost)
+	}
+	patch := map[string]interface{}{"fulfilment_method": c.Method}
+
+	switch c.Method {
+	case Pickup:
+		p := l.Fulfilment.Pickup
+		if p == nil {
+			return fmt.Errorf("%w: no local pickup", ErrNotOffered)
+		}
+		if c.Slot < 0 || c.Slot >= len(p.Slots) {
+			return fmt.Errorf("%w: slot must be between 0 and %d", ErrInvalid, len(p.Slots)-1)
+		}
+		slot := p.Slots[c.Slot]
+		if !slot.End.After(now) {
+			return fmt.Errorf("%w: that pickup slot has passed", ErrInvalid)
+		}
+		patch["pickup_slot"] = slot
+		patch["shipping_address"] = nil
+		patch["shipping_cost"] = nil
+		patch["amount"] = itemPrice
+		patch["release_due_at"] = slot.End.Add(PickupTimeout)
+	case Shipping:
+		s := l.Fulfilment.Shipping
+		if s == nil {
+			return fmt.Errorf("%w: no shipping", ErrNotOffered)
+		}
+		if c.Address == "" {
+			return fmt.Errorf("%w: a shipping address is required", ErrInvalid)
+		}
+		cost := s.Cost
+		if s.Rate == listing.CalculatedRate {
+			from, ok := l.Point()
+			if !ok {
+				return fmt.Errorf("%w: the listing's location is unknown", ErrNotOffered)
+			}
+			place, err := g.Geocode(ctx, c.Address)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalid, err)
+			}
+			cost = s.Quote(from, place.Point)
+		}
+		patch["pickup_slot"] = nil
+		patch["shipping_address"] = c.Address
+		patch["shipping_cost"] = cost
+		patch["amount"] = itemPrice.Add(cost)
+		patch["release_due_at"] = nil
+	default:
+		return fmt.Errorf("%w: method must be %q or %q", ErrInvalid, Pickup, Shipping)
+	}
+	return payments.PatchUnstarted(o, patch)
+}
+
+// Ship records the seller's tracking details on a paid, shipped order and
+// starts the release timeout.
+func Ship(o *payments.Order, userID, carrier, trackingNumber string, now time.Time) error {
+	switch {
+	case o.SellerID != userID:
+		return payments.ErrForbidden
+	case o.Status != payments.Paid || o.Method != Shipping:
+		return payments.ErrState
+	case trackingNumber == "":
+		return fmt.Errorf("%w: a tracking number is required", ErrInvalid)
+	}
+	err := payments.Patch(o, map[string]interface{}{
+		"carrier":         carrier,
+		"tracking_number": trackingNumber,
+		"shipped_at":      now,
+		"release_due_at":  now.Add(ShippingTimeout),
+	})
+	if err != nil {
+		return err
+	}
+	events.Publish(events.Event{
+		Type:      events.OrderShipped,
+		UserID:    o.BuyerID,
+		ListingID: o.ListingID,
+		Data: map[string]string{
+			"title":           o.Title,
+			"carrier":         carrier,
+			"tracking_number": trackingNumber,
+		},
+	})
+	return nil
+}
+
+// ConfirmDelivery records that the buyer has the item and releases escrow to
+// the seller.
+func ConfirmDelivery(ctx context.Context, p payments.Provider, o *payments.Order, userID string, now time.Time) error {
+	switch {
+	case o.BuyerID != userID:
+		return payments.ErrForbidden
+	case o.Status != payments.Paid:
+		return payments.ErrState
+	case o.Disputed:
+		return payments.ErrDisputed
+	}
+	if err := payments.Patch(o, map[string]interface{}{"delivered_at": now}); err != nil {
+		return err
+	}
+	return release(ctx, p, o, now)
+}
+
+// release pays o out and completes its listing once every order for it has
+// been paid out.
+func release(ctx context.Context, p payments.Provider, o *payments.Order, now time.Time) error {
+	if err := payments.Release(ctx, p, o, now); err != nil {
+		return err
+	}
+	orders, err := payments.ForListing(o.ListingID)
+	if err != nil {
+		log.Printf("Warning: failed to check orders for %s: %v", o.ListingID, err)
+		return nil
+	}
+	for _, other := range orders {
+		if other.Status != payments.Released {
+			return nil
+		}
+	}
+	query := "id=eq." + url.QueryEscape(o.ListingID) + "&status=eq.sold"
+	if err := supabase.Update("listings", query, map[string]string{"status": listingCompleted}, nil); err != nil {
+		log.Printf("Warning: failed to complete listing %s: %v", o.ListingID, err)
+	}
+	return nil
+}
+
+// Run calls ReleaseDue every interval until ctx is cancelled.
+func Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := ReleaseDue(ctx, payments.Default, time.Now().UTC()); err != nil {
+				log.Printf("Warning: automatic escrow release failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Released escrow for %d orders", n)
+			}
+		}
+	}
+}
+
+// ReleaseDue pays out paid orders whose release timeout has passed without
+// the buyer confirming delivery.
+func ReleaseDue(ctx context.Context, p payments.Provider, now time.Time) (int, error) {
+	due, err := payments.DueForRelease(now)
+	if err != nil {
+		return 0, err
+	}
+	released := 0
+	for i := range due {
+		if err := release(ctx, p, &due[i], now); err != nil {
+			log.Printf("Warning: failed to release order %s: %v", due[i].ID, err)
+			continue
+		}
+		released++
+	}
+	return released, nil
+}
@@ -0,0 +1,75 @@
+package fulfilment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/geo"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/payments"
+)
+
+func TestChooseChecks(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	slot := listing.PickupSlot{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}
+	pickupOnly := &listing.Listing{Fulfilment: &listing.Fulfilment{Pickup: &listing.Pickup{Slots: []listing.PickupSlot{slot}}}}
+	shipping := &listing.Listing{Fulfilment: &listing.Fulfilment{Shipping: &listing.Shipping{Rate: listing.CalculatedRate, Cost: money.New(200, "USD"), PerKm: money.New(10, "USD")}}}
+	o := payments.Order{ID: "o1", BuyerID: "buyer", SellerID: "seller", Status: payments.Pending, Amount: money.New(4000, "USD")}
+	paid := o
+	paid.Status = payments.Paid
+	checkingOut := o
+	checkingOut.PaymentID = "pi_1"
+
+	for _, tc := range []struct {
+		name string
+		o    payments.Order
+		l    *listing.Listing
+		user string
+		c    Choice
+		err  error
+	}{
+		{"seller can't choose", o, pickupOnly, "seller", Choice{Method: Pickup}, payments.ErrForbidden},
+		{"already paid", paid, pickupOnly, "buyer", Choice{Method: Pickup}, payments.ErrState},
+		{"checkout started", checkingOut, pickupOnly, "buyer", Choice{Method: Pickup}, payments.ErrState},
+		{"no options", o, &listing.Listing{}, "buyer", Choice{Method: Pickup}, ErrNotOffered},
+		{"shipping not offered", o, pickupOnly, "buyer", Choice{Method: Shipping, Address: "Ocala"}, ErrNotOffered},
+		{"slot out of range", o, pickupOnly, "buyer", Choice{Method: Pickup, Slot: 3}, ErrInvalid},
+		{"slot passed", o, pickupOnly, "buyer", Choice{Method: Pickup}, ErrInvalid},
+		{"no address", o, shipping, "buyer", Choice{Method: Shipping}, ErrInvalid},
+		{"listing not geocoded", o, shipping, "buyer", Choice{Method: Shipping, Address: "Ocala"}, ErrNotOffered},
+		{"unknown method", o, pickupOnly, "buyer", Choice{Method: "drone"}, ErrInvalid},
+	} {
+		err := Choose(context.Background(), &tc.o, tc.l, tc.user, tc.c, geo.Default, now)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestHandoffChecks(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	o := payments.Order{ID: "o1", BuyerID: "buyer", SellerID: "seller", Status: payments.Paid, Method: Pickup}
+
+	if err := Ship(&o, "buyer", "UPS", "1Z", now); !errors.Is(err, payments.ErrForbidden) {
+		t.Errorf("Expected only the seller to ship, got %v", err)
+	}
+	if err := Ship(&o, "seller", "UPS", "1Z", now); !errors.Is(err, payments.ErrState) {
+		t.Errorf("Expected pickup orders not to ship, got %v", err)
+	}
+	o.Method = Shipping
+	if err := Ship(&o, "seller", "UPS", "", now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected a tracking number to be required, got %v", err)
+	}
+
+	p := &payments.FakeProvider{}
+	if err := ConfirmDelivery(context.Background(), p, &o, "seller", now); !errors.Is(err, payments.ErrForbidden) {
+		t.Errorf("Expected only the buyer to confirm, got %v", err)
+	}
+	o.Status = payments.Pending
+	if err := ConfirmDelivery(context.Background(), p, &o, "buyer", now); !errors.Is(err, payments.ErrState) {
+		t.Errorf("Expected unpaid orders not to confirm, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKey holds the latest rates shared by every server instance.
+const redisKey = "fx:rates"
+
+// maxAge is how long rates held in memory are trusted before Redis is
+// consulted again.
+const maxAge = 5 * time.Minute
+
+// Cache keeps the latest rates in Redis and in memory.
+type Cache struct {
+	rdb      *redis.Client
+	provider Provider
+
+	mu       sync.RWMutex
+	current  Rates
+	loadedAt time.Time
+}
+
+// NewCache creates a cache refreshed from provider. rdb may be nil, in which
+// case rates are only kept in memory.
+func NewCache(rdb *redis.Client, provider Provider) *Cache {
+	return &Cache{rdb: rdb, provider: provider}
+}
+
+// Refresh fetches rates from the provider and stores them.
+func (c *Cache) Refresh(ctx context.Context) error {
+	r, err := c.provider.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if c.rdb != nil {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if err := c.rdb.Set(ctx, redisKey, b, 0).Err(); err != nil {
+			log.Printf("Warning: failed to store exchange rates in redis: %v", err)
+		}
+	}
+	c.set(r)
+	return nil
+}
+
+func (c *Cache) set(r Rates) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current = r
+	c.loadedAt = time.Now()
+}
+
+// Current returns the latest known rates, loading them from Redis or the
+// provider if the in-memory copy is stale or missing.
+func (c *Cache) Current(ctx context.Context) Rates {
+	c.mu.RLock()
+	r, loadedAt := c.current, c.loadedAt
+	c.mu.RUnlock()
+	if len(r.Rates) > 0 && time.Since(loadedAt) < maxAge {
+		return r
+	}
+
+	if c.rdb != nil {
+		if b, err := c.rdb.Get(ctx, redisKey).Bytes(); err == nil {
+			var fromRedis Rates
+			if err := json.Unmarshal(b, &fromRedis); err == nil && len(fromRedis.Rates) > 0 {
+				c.set(fromRedis)
+				return fromRedis
+			}
+		}
+	}
+
+	if err := c.Refresh(ctx); err != nil {
+		log.Printf("Warning: failed to load exchange rates: %v", err)
+		return r
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current
+}
+
+// Run refreshes the rates every interval until ctx is cancelled.
+func (c *Cache) Run(ctx context.Context, interval time.Duration) {
+	if err := c.Refresh(ctx); err != nil {
+		log.Printf("Warning: failed to refresh exchange rates: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil {
+				log.Printf("Warning: failed to refresh exchange rates: %v", err)
+			}
+		}
+	}
+}
+
+// Default is the process-wide cache used by handlers. It serves the bundled
+// rates until main replaces it.
+var Default = NewCache(nil, FileProvider{})
+
+// Convert converts m to currency to using the Default cache.
+func Convert(ctx context.Context, m money.Money, to money.Currency) (money.Money, bool) {
+	return Default.Current(ctx).Convert(m, to)
+}
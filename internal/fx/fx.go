@@ -0,0 +1,78 @@
+package fx
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+// Rates are exchange rates expressed as units of each currency per one unit
+// of Base.
+type Rates struct {
+	Base  money.Currency             `json:"base"`
+	AsOf  time.Time                  `json:"as_of"`
+	Rates map[money.Currency]float64 `json:"rates"`
+}
+
+// Convert returns m in currency to, rounded to to's minor units. The result
+// is approximate and only meant for display. ok is false if either currency
+// has no rate.
+func (r Rates) Convert(m money.Money, to money.Currency) (money.Money, bool) {
+	if m.Currency == to {
+		return m, true
+	}
+	from, ok := r.Rates[m.Currency]
+	if !ok || from <= 0 {
+		return money.Money{}, false
+	}
+	target, ok := r.Rates[to]
+	if !ok || target <= 0 {
+		return money.Money{}, false
+	}
+	major := m.Float() / from * target
+	scale := math.Pow10(to.Exponent())
+	return money.New(int64(math.Round(major*scale)), to), true
+}
+
+// Provider fetches the latest exchange rates.
+type Provider interface {
+	Fetch(ctx context.Context) (Rates, error)
+}
+
+//go:embed rates.json
+var fixture []byte
+
+// FileProvider reads rates from a JSON file, for offline use. With an empty
+// Path it serves the rates bundled with the server.
+type FileProvider struct {
+	Path string
+}
+
+func (p FileProvider) Fetch(ctx context.Context) (Rates, error) {
+	data := fixture
+	if p.Path != "" {
+		b, err := os.ReadFile(p.Path)
+		if err != nil {
+			return Rates{}, fmt.Errorf("read rates file: %w", err)
+		}
+		data = b
+	}
+
+	var r Rates
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Rates{}, fmt.Errorf("parse rates: %w", err)
+	}
+	if r.Base == "" || len(r.Rates) == 0 {
+		return Rates{}, fmt.Errorf("parse rates: missing base or rates")
+	}
+	if _, ok := r.Rates[r.Base]; !ok {
+		r.Rates[r.Base] = 1
+	}
+	return r, nil
+}
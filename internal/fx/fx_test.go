@@ -0,0 +1,44 @@
+package fx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+func TestConvert(t *testing.T) {
+	r := Rates{Base: "USD", Rates: map[money.Currency]float64{"USD": 1, "EUR": 0.9, "JPY": 150}}
+
+	tests := []struct {
+		in   money.Money
+		to   money.Currency
+		want money.Money
+	}{
+		{money.New(1000, "USD"), "EUR", money.New(900, "EUR")},
+		{money.New(900, "EUR"), "USD", money.New(1000, "USD")},
+		{money.New(1000, "USD"), "JPY", money.New(1500, "JPY")},
+		{money.New(1500, "JPY"), "EUR", money.New(900, "EUR")},
+		{money.New(1234, "USD"), "USD", money.New(1234, "USD")},
+	}
+	for _, tt := range tests {
+		got, ok := r.Convert(tt.in, tt.to)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("Convert(%s, %s) = %s, %v; want %s", tt.in, tt.to, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := r.Convert(money.New(100, "USD"), "GBP"); ok {
+		t.Errorf("Expected conversion to a currency without a rate to fail")
+	}
+}
+
+func TestFileProviderFixture(t *testing.T) {
+	r, err := FileProvider{}.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if r.Base != "USD" || r.Rates["USD"] != 1 {
+		t.Errorf("Unexpected fixture rates: %+v", r)
+	}
+}
@@ -0,0 +1,68 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// earthRadiusKm is the mean radius of the Earth.
+const earthRadiusKm = 6371.0
+
+// cellDegrees is the size of the grid listing coordinates are snapped to,
+// roughly 2km, so a seller's exact address can't be recovered.
+const cellDegrees = 0.02
+
+// Point is a WGS84 coordinate.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Valid reports whether p is a real coordinate.
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// ParsePoint reads a "lat,lng" pair such as the near= query parameter.
+func ParsePoint(s string) (Point, error) {
+	latStr, lngStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return Point{}, fmt.Errorf("invalid point %q: want lat,lng", s)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid latitude %q", latStr)
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid longitude %q", lngStr)
+	}
+	p := Point{Lat: lat, Lng: lng}
+	if !p.Valid() {
+		return Point{}, fmt.Errorf("point %q is out of range", s)
+	}
+	return p, nil
+}
+
+// DistanceKm returns the great-circle distance between a and b.
+func DistanceKm(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Fuzz snaps p to the centre of its grid cell. Every address in a cell maps
+// to the same point, so fuzzed coordinates are safe to store and return.
+func Fuzz(p Point) Point {
+	snap := func(v float64) float64 {
+		c := (math.Floor(v/cellDegrees) + 0.5) * cellDegrees
+		return math.Round(c*1e4) / 1e4
+	}
+	return Point{Lat: snap(p.Lat), Lng: snap(p.Lng)}
+}
@@ -0,0 +1,67 @@
+package geo
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	gainesville := Point{Lat: 29.6516, Lng: -82.3248}
+	jacksonville := Point{Lat: 30.3322, Lng: -81.6557}
+
+	d := DistanceKm(gainesville, jacksonville)
+	if math.Abs(d-99) > 3 {
+		t.Errorf("Expected about 99km between Gainesville and Jacksonville, got %.1f", d)
+	}
+	if DistanceKm(gainesville, gainesville) != 0 {
+		t.Errorf("Expected zero distance to self")
+	}
+}
+
+func TestParsePoint(t *testing.T) {
+	p, err := ParsePoint("29.65, -82.32")
+	if err != nil || p.Lat != 29.65 || p.Lng != -82.32 {
+		t.Errorf("ParsePoint = %+v, %v", p, err)
+	}
+	for _, bad := range []string{"", "29.65", "abc,1", "91,0", "0,181"} {
+		if _, err := ParsePoint(bad); err == nil {
+			t.Errorf("Expected ParsePoint(%q) to fail", bad)
+		}
+	}
+}
+
+func TestFuzz(t *testing.T) {
+	a := Fuzz(Point{Lat: 29.6516, Lng: -82.3248})
+	b := Fuzz(Point{Lat: 29.6599, Lng: -82.3201})
+	if a != b {
+		t.Errorf("Expected nearby points in one cell to fuzz alike, got %+v and %+v", a, b)
+	}
+	if d := DistanceKm(a, Point{Lat: 29.6516, Lng: -82.3248}); d > 2 {
+		t.Errorf("Fuzzed point moved too far: %.2fkm", d)
+	}
+}
+
+func TestGazetteerGeocode(t *testing.T) {
+	g := NewGazetteer()
+	ctx := context.Background()
+
+	tests := map[string]string{
+		"Gainesville":                    "Gainesville, FL",
+		"gainesville, fl":                "Gainesville, FL",
+		"1600 Main St, Austin, TX 78701": "Austin, TX",
+		"Portland, ME":                   "Portland, ME",
+		"Portland":                       "Portland, OR",
+		"St. Louis, MO":                  "St. Louis, MO",
+	}
+	for query, want := range tests {
+		p, err := g.Geocode(ctx, query)
+		if err != nil || p.Name != want {
+			t.Errorf("Geocode(%q) = %q, %v; want %q", query, p.Name, err, want)
+		}
+	}
+
+	if _, err := g.Geocode(ctx, "Nowhere Special"); err != ErrNoMatch {
+		t.Errorf("Expected ErrNoMatch, got %v", err)
+	}
+}
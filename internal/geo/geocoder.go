@@ -0,0 +1,110 @@
+package geo
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrNoMatch is returned when a location can't be geocoded.
+var ErrNoMatch = errors.New("location not recognised")
+
+// Place is a geocoded location. Name is the public, coarse label for it,
+// e.g. "Gainesville, FL".
+type Place struct {
+	Name  string `json:"name"`
+	Point Point  `json:"point"`
+}
+
+// Geocoder resolves free-text locations to coordinates.
+type Geocoder interface {
+	Geocode(ctx context.Context, query string) (Place, error)
+}
+
+//go:embed gazetteer.json
+var gazetteerData []byte
+
+// Gazetteer is an offline Geocoder backed by a list of known towns. It
+// resolves city names, optionally followed by a region or postal code, and
+// addresses ending in one.
+type Gazetteer struct {
+	places map[string]Place
+}
+
+type gazetteerEntry struct {
+	City    string  `json:"city"`
+	Region  string  `json:"region"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+}
+
+// NewGazetteer loads the gazetteer bundled with the server.
+func NewGazetteer() *Gazetteer {
+	var entries []gazetteerEntry
+	if err := json.Unmarshal(gazetteerData, &entries); err != nil {
+		panic("geo: invalid bundled gazetteer: " + err.Error())
+	}
+
+	g := &Gazetteer{places: make(map[string]Place)}
+	for _, e := range entries {
+		place := Place{Name: e.City + ", " + e.Region, Point: Point{Lat: e.Lat, Lng: e.Lng}}
+		for _, key := range []string{
+			e.City + " " + e.Region,
+			e.City + " " + e.Region + " " + e.Country,
+			e.City + " " + e.Country,
+		} {
+			g.places[normalize(key)] = place
+		}
+		// A bare city name goes to the first entry listed
+		if _, taken := g.places[normalize(e.City)]; !taken {
+			g.places[normalize(e.City)] = place
+		}
+	}
+	return g
+}
+
+// Geocode matches query against the gazetteer, trying the whole string and
+// then each comma-separated tail, so "12 Elm St, Austin, TX 78701" resolves
+// to Austin.
+func (g *Gazetteer) Geocode(ctx context.Context, query string) (Place, error) {
+	parts := strings.Split(query, ",")
+	for i := range parts {
+		tail := normalize(strings.Join(parts[i:], " "))
+		if p, ok := g.places[tail]; ok {
+			return p, nil
+		}
+		if p, ok := g.places[stripPostcode(tail)]; ok {
+			return p, nil
+		}
+		if p, ok := g.places[normalize(parts[i])]; ok {
+			return p, nil
+		}
+	}
+	return Place{}, ErrNoMatch
+}
+
+// normalize lower-cases s and collapses punctuation and spacing.
+func normalize(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// stripPostcode drops a trailing numeric postal code.
+func stripPostcode(s string) string {
+	if i := strings.LastIndexByte(s, ' '); i > 0 {
+		last := s[i+1:]
+		if strings.IndexFunc(last, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// Default is the geocoder used for new listings.
+var Default Geocoder = NewGazetteer()
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// isAdmin reports whether userID is listed in ADMIN_USER_IDS, a
+// comma-separated list of Supabase user IDs.
+func isAdmin(userID string) bool {
+	for _, id := range strings.Split(os.Getenv("ADMIN_USER_IDS"), ",") {
+		if id = strings.TrimSpace(id); id != "" && id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAdmin is requireUser for admin-only endpoints.
+func requireAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return "", false
+	}
+	if !isAdmin(userID) {
+		respondError(w, "Admin access required", http.StatusForbidden)
+		return "", false
+	}
+	return userID, true
+}
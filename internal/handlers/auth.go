@@ -10,6 +10,7 @@ import (
 	"os"
 
 	auth "github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/fraud"
 )
 
 func loginHandler(c *auth.Client) http.HandlerFunc {
@@ -39,6 +40,7 @@ func loginHandler(c *auth.Client) http.HandlerFunc {
 			respondError(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
+		fraud.RecordFingerprint(fraud.FingerprintFromRequest(session.User.ID, r))
 
 		respondJSON(w, map[string]interface{}{
 			"session": map[string]interface{}{
@@ -157,3 +157,43 @@ func TestProfileHandler(t *testing.T) {
 		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 }
+
+func TestUpdateProfileHandlerPreferredCurrency(t *testing.T) {
+	var patched map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "user123", "email": "test@example.com"}`))
+		case "/rest/v1/profiles":
+			json.NewDecoder(r.Body).Decode(&patched)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[{"id": "user123", "preferred_currency": "EUR"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	handler := updateProfileHandler(auth.NewClient(ts.URL, "anon"))
+
+	req := httptest.NewRequest("PATCH", "/api/profile", bytes.NewBufferString(`{"preferred_currency": "XYZ"}`))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unsupported currency, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest("PATCH", "/api/profile", bytes.NewBufferString(`{"preferred_currency": "eur"}`))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if patched["preferred_currency"] != "EUR" {
+		t.Errorf("Expected preferred_currency normalized to EUR, got %v", patched["preferred_currency"])
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+)
+
+// bidHistoryHandler returns a listing's bid history oldest first, paginated
+// by bid sequence. Bidders are shown as per-auction pseudonymous handles;
+// the caller's own bids are marked with is_you. Sealed auctions only show the
+// caller's own bids until they close.
+func bidHistoryHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listingID := r.PathValue("id")
+		if listingID == "" {
+			respondError(w, "Listing ID is required", http.StatusBadRequest)
+			return
+		}
+
+		var after int64
+		if v := r.URL.Query().Get("after"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				respondError(w, "Invalid after parameter", http.StatusBadRequest)
+				return
+			}
+			after = n
+		}
+		limit := 50
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				respondError(w, "Invalid limit parameter", http.StatusBadRequest)
+				return
+			}
+			limit = min(n, ledger.MaxPageSize)
+		}
+
+		l, err := listing.Get(listingID)
+		if errors.Is(err, listing.ErrNotFound) {
+			respondError(w, "Listing not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch listing", http.StatusInternalServerError)
+			return
+		}
+
+		history, err := ledger.History(listingID, l.Currency, after, limit)
+		if err != nil {
+			respondError(w, "Failed to fetch bids", http.StatusInternalServerError)
+			return
+		}
+		bidders, err := ledger.Bidders(listingID)
+		if err != nil {
+			respondError(w, "Failed to fetch bids", http.StatusInternalServerError)
+			return
+		}
+		handles, err := ledger.Handles(listingID, bidders)
+		if err != nil {
+			log.Printf("Error masking bidders on %s: %v", listingID, err)
+			respondError(w, "Bid history is unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		callerID := optionalUser(r)
+
+		// While a sealed auction is open callers only see their own bids
+		hidden := l.BidsHidden(time.Now())
+
+		out := make([]map[string]interface{}, 0, len(history))
+		for _, b := range history {
+			if hidden && (callerID == "" || b.UserID != callerID) {
+				continue
+			}
+			out = append(out, map[string]interface{}{
+				"sequence":    b.BidSequence,
+				"amount":      b.BidAmount,
+				"currency":    b.BidAmount.Currency,
+				"placed_at":   b.Timestamp,
+				"is_auto_bid": b.IsAutoBid,
+				"status":      b.Status,
+				"bidder":      handles[b.UserID],
+				"is_you":      callerID != "" && b.UserID == callerID,
+			})
+		}
+
+		resp := map[string]interface{}{"bids": out, "bids_hidden": hidden}
+		if len(history) == limit {
+			resp["next_after"] = history[len(history)-1].BidSequence
+		}
+		respondJSON(w, resp)
+	}
+}
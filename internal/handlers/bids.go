@@ -3,28 +3,45 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/categories"
+	"github.com/quickswap/quickswap/internal/fx"
+	"github.com/quickswap/quickswap/internal/geo"
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/watchlist"
 )
 
 type Bid struct {
-	ID          string  `json:"id"`
-	ListingID   string  `json:"listing_id"`
-	UserID      string  `json:"user_id"`
-	BidAmount   float64 `json:"bid_amount"`
-	Timestamp   string  `json:"timestamp"`
-	Status      string  `json:"status"`
-	IsAutoBid   bool    `json:"is_auto_bid"`
-	BidSequence int     `json:"bid_sequence"`
-	Title       string  `json:"title"`
-	Image       string  `json:"image"`
-	CurrentBid  float64 `json:"current_bid"`
-	AuctionEnd  string  `json:"auction_end_time"`
-	TimeLeft    string  `json:"time_left"`
-	Label       string  `json:"label"`
+	ID          string         `json:"id"`
+	ListingID   string         `json:"listing_id"`
+	UserID      string         `json:"user_id"`
+	BidAmount   money.Money    `json:"bid_amount"`
+	Timestamp   string         `json:"timestamp"`
+	Status      string         `json:"status"`
+	IsAutoBid   bool           `json:"is_auto_bid"`
+	BidSequence int            `json:"bid_sequence"`
+	Title       string         `json:"title"`
+	Image       string         `json:"image"`
+	CurrentBid  money.Money    `json:"current_bid"`
+	Currency    money.Currency `json:"currency"`
+	AuctionEnd  string         `json:"auction_end_time"`
+	TimeLeft    string         `json:"time_left"`
+	Label       string         `json:"label"`
+	// Multi-quantity listings: units bid for and currently winning
+	Quantity     int `json:"quantity,omitempty"`
+	UnitsWinning int `json:"units_winning,omitempty"`
 }
 
 func myBidsHandler(authClient *auth.Client) http.HandlerFunc {
@@ -100,6 +117,8 @@ func myBidsHandler(authClient *auth.Client) http.HandlerFunc {
 		}
 
 		// For each bid, fetch listing details
+		allocations := map[string][]listing.Award{}
+		leaders := map[string]*ledger.Bid{}
 		for i := range bids {
 			listingURL := supaURL + "/rest/v1/listings?id=eq." + bids[i].ListingID
 			reqListing, _ := http.NewRequest("GET", listingURL, nil)
@@ -112,17 +131,27 @@ func myBidsHandler(authClient *auth.Client) http.HandlerFunc {
 				continue // skip if listing not found
 			}
 			var listings []struct {
-				Title      string   `json:"title"`
-				Images     []string `json:"images"`
-				CurrentBid float64  `json:"current_bid"`
-				AuctionEnd string   `json:"auction_end_time"`
+				Title       string              `json:"title"`
+				Images      []string            `json:"images"`
+				CurrentBid  money.Money         `json:"current_bid"`
+				Currency    money.Currency      `json:"currency"`
+				AuctionEnd  string              `json:"auction_end_time"`
+				Quantity    int                 `json:"quantity"`
+				Pricing     listing.Pricing     `json:"pricing"`
+				AuctionType listing.AuctionType `json:"auction_type"`
 			}
 			if err := json.NewDecoder(respListing.Body).Decode(&listings); err == nil && len(listings) > 0 {
+				currency := listings[0].Currency
+				if currency == "" {
+					currency = money.DefaultCurrency
+				}
 				bids[i].Title = listings[0].Title
 				if len(listings[0].Images) > 0 {
 					bids[i].Image = listings[0].Images[0]
 				}
-				bids[i].CurrentBid = listings[0].CurrentBid
+				bids[i].Currency = currency
+				bids[i].BidAmount = bids[i].BidAmount.Round(currency)
+				bids[i].CurrentBid = listings[0].CurrentBid.Round(currency)
 				bids[i].AuctionEnd = listings[0].AuctionEnd
 				// Calculate time left
 				auctionEnd, err := time.Parse(time.RFC3339, listings[0].AuctionEnd)
@@ -135,18 +164,32 @@ func myBidsHandler(authClient *auth.Client) http.HandlerFunc {
 					}
 				}
 				// Determine label
-				if bids[i].TimeLeft == "Ended" {
-					if bids[i].BidAmount == bids[i].CurrentBid {
-						bids[i].Label = "Winning"
-					} else {
-						bids[i].Label = "Lost"
+				if units := listings[0].Quantity; units > 1 {
+					awards, ok := allocations[bids[i].ListingID]
+					if !ok {
+						awards, err = multiUnitAwards(bids[i].ListingID, units, listings[0].Pricing, currency)
+						if err != nil {
+							log.Printf("Warning: failed to rank bids on %s: %v", bids[i].ListingID, err)
+						}
+						allocations[bids[i].ListingID] = awards
 					}
+					multiUnitLabel(&bids[i], awards)
+				} else if listings[0].AuctionType.Sealed() && bids[i].TimeLeft != "Ended" {
+					// Standing stays hidden until a sealed auction ends
+					bids[i].Label = "Sealed"
 				} else {
-					if bids[i].BidAmount == bids[i].CurrentBid {
-						bids[i].Label = "Winning"
-					} else {
-						bids[i].Label = "Outbid"
+					leader, ok := leaders[bids[i].ListingID]
+					if !ok {
+						leader, err = singleUnitLeader(bids[i].ListingID, currency)
+						if err != nil {
+							log.Printf("Warning: failed to rank bids on %s: %v", bids[i].ListingID, err)
+						}
+						leaders[bids[i].ListingID] = leader
+					}
+					if leader != nil {
+						bids[i].CurrentBid = leader.BidAmount
 					}
+					singleUnitLabel(&bids[i], leader)
 				}
 			}
 			respListing.Body.Close()
@@ -158,6 +201,84 @@ func myBidsHandler(authClient *auth.Client) http.HandlerFunc {
 	}
 }
 
+// multiUnitAwards ranks the standing bids on a multi-quantity listing.
+func multiUnitAwards(listingID string, units int, pricing listing.Pricing, currency money.Currency) ([]listing.Award, error) {
+	active, err := ledger.Active(listingID, currency)
+	if err != nil {
+		return nil, err
+	}
+	bids := make([]listing.UnitBid, len(active))
+	for i, b := range active {
+		bids[i] = listing.UnitBid{UserID: b.UserID, Amount: b.BidAmount, Quantity: b.Quantity, Sequence: b.BidSequence}
+	}
+	return listing.Allocate(units, pricing, bids), nil
+}
+
+// singleUnitLeader returns the bid leading a single-unit listing, or nil if
+// there are no standing bids.
+func singleUnitLeader(listingID string, currency money.Currency) (*ledger.Bid, error) {
+	active, err := ledger.Active(listingID, currency)
+	if err != nil || len(active) == 0 {
+		return nil, err
+	}
+	return &active[0], nil
+}
+
+// singleUnitLabel labels a bid on a single-unit listing by whether it is the
+// standing high bid.
+func singleUnitLabel(b *Bid, leader *ledger.Bid) {
+	switch {
+	case b.Status == ledger.StatusRevised || b.Status == ledger.StatusRetracted:
+		b.Label = strings.ToUpper(b.Status[:1]) + b.Status[1:]
+	case leader != nil && leader.ID == b.ID:
+		b.Label = "Winning"
+	case b.TimeLeft == "Ended":
+		b.Label = "Lost"
+	default:
+		b.Label = "Outbid"
+	}
+}
+
+// multiUnitLabel labels a bid on a multi-quantity listing by how many of the
+// units it asked for it is winning.
+func multiUnitLabel(b *Bid, awards []listing.Award) {
+	if b.Status == ledger.StatusRevised || b.Status == ledger.StatusRetracted {
+		b.Label = strings.ToUpper(b.Status[:1]) + b.Status[1:]
+		return
+	}
+	requested := max(b.Quantity, 1)
+	for _, a := range awards {
+		if a.UserID == b.UserID {
+			b.UnitsWinning = a.Quantity
+		}
+	}
+	switch {
+	case b.UnitsWinning == 0 && b.TimeLeft == "Ended":
+		b.Label = "Lost"
+	case b.UnitsWinning == 0:
+		b.Label = "Outbid"
+	case b.UnitsWinning < requested:
+		b.Label = fmt.Sprintf("Winning %d of %d", b.UnitsWinning, requested)
+	default:
+		b.Label = "Winning"
+	}
+}
+
+// inList formats values as a quoted PostgREST in.() list for a query string.
+func inList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = url.QueryEscape(`"` + strings.ReplaceAll(v, `"`, `\"`) + `"`)
+	}
+	return "(" + strings.Join(quoted, ",") + ")"
+}
+
+// Radius search bounds for topListingsHandler, in kilometres.
+const (
+	defaultRadiusKm = 25
+	maxRadiusKm     = 500
+)
+
 // TopListingsHandler fetches listings for trending now, ending soon, and starting soon
 func topListingsHandler(authClient *auth.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -166,6 +287,26 @@ func topListingsHandler(authClient *auth.Client) http.HandlerFunc {
 			return
 		}
 
+		// Optional radius search: near=lat,lng&radius_km=
+		var near *geo.Point
+		radiusKm := float64(defaultRadiusKm)
+		if v := r.URL.Query().Get("near"); v != "" {
+			p, err := geo.ParsePoint(v)
+			if err != nil {
+				respondError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			near = &p
+		}
+		if v := r.URL.Query().Get("radius_km"); v != "" {
+			km, err := strconv.ParseFloat(v, 64)
+			if err != nil || km <= 0 || km > maxRadiusKm {
+				respondError(w, fmt.Sprintf("radius_km must be between 0 and %d", maxRadiusKm), http.StatusBadRequest)
+				return
+			}
+			radiusKm = km
+		}
+
 		supaURL := os.Getenv("SUPABASE_URL")
 		apiKey := os.Getenv("SUPABASE_SERVICE_KEY")
 		if apiKey == "" {
@@ -173,6 +314,26 @@ func topListingsHandler(authClient *auth.Client) http.HandlerFunc {
 		}
 
 		url := supaURL + "/rest/v1/listings?select=*&order=auction_end_time.asc"
+
+		// Optional category filter, by ID or name
+		if v := r.URL.Query().Get("category"); v != "" {
+			tax := categories.Current()
+			cat, ok := tax.Find("", v)
+			if !ok {
+				respondError(w, "Unknown category", http.StatusBadRequest)
+				return
+			}
+			// Older listings store the category name rather than its ID
+			url += "&category=in." + inList(cat.StoredAs())
+			if v := r.URL.Query().Get("subcategory"); v != "" {
+				sub, ok := tax.Find(cat.ID, v)
+				if !ok {
+					respondError(w, "Unknown subcategory", http.StatusBadRequest)
+					return
+				}
+				url += "&subcategory=in." + inList(sub.StoredAs())
+			}
+		}
 		reqListings, _ := http.NewRequest("GET", url, nil)
 		reqListings.Header.Set("apikey", apiKey)
 		reqListings.Header.Set("Authorization", "Bearer "+apiKey)
@@ -186,25 +347,63 @@ func topListingsHandler(authClient *auth.Client) http.HandlerFunc {
 		defer respListings.Body.Close()
 
 		var listings []struct {
-			ID           string   `json:"id"`
-			Title        string   `json:"title"`
-			Subtitle     string   `json:"subtitle"`
-			Images       []string `json:"images"`
-			StartingBid  float64  `json:"starting_bid"`
+			ID          string              `json:"id"`
+			Title       string              `json:"title"`
+			Subtitle    string              `json:"subtitle"`
+			Images      []string            `json:"images"`
+			StartingBid money.Money         `json:"starting_bid"`
+			Currency    money.Currency      `json:"currency"`
+			AuctionType listing.AuctionType `json:"auction_type"`
+			*listing.DutchSchedule
 			AuctionStart string   `json:"auction_start_time"`
 			AuctionEnd   string   `json:"auction_end_time"`
+			Latitude     *float64 `json:"latitude"`
+			Longitude    *float64 `json:"longitude"`
+			LocationArea string   `json:"location_area"`
 		}
 		if err := json.NewDecoder(respListings.Body).Decode(&listings); err != nil {
 			respondError(w, "Invalid listings response", http.StatusInternalServerError)
 			return
 		}
 
+		// Drop listings outside the search radius, remembering distances
+		distances := map[string]float64{}
+		if near != nil {
+			inRange := listings[:0]
+			for _, l := range listings {
+				if l.Latitude == nil || l.Longitude == nil {
+					continue
+				}
+				d := geo.DistanceKm(*near, geo.Point{Lat: *l.Latitude, Lng: *l.Longitude})
+				if d <= radiusKm {
+					distances[l.ID] = math.Round(d*10) / 10
+					inRange = append(inRange, l)
+				}
+			}
+			listings = inRange
+		}
+
+		// Watcher counts feed the trending ranking; a failed lookup just
+		// falls back to ranking by bid.
+		ids := make([]string, 0, len(listings))
+		for _, l := range listings {
+			ids = append(ids, l.ID)
+		}
+		watchers, err := watchlist.Counts(ids)
+		if err != nil {
+			log.Printf("Warning: failed to fetch watcher counts: %v", err)
+			watchers = map[string]int{}
+		}
+
+		// Approximate prices in the caller's currency, if they have one
+		displayIn := displayCurrency(r, optionalUser(r))
+
 		now := time.Now().UTC()
 		var trending, endingSoon, startingSoon []map[string]interface{}
 
 		for _, l := range listings {
 			// Fetch top bid for this listing
-			bidsURL := supaURL + "/rest/v1/bids?listing_id=eq." + l.ID + "&order=bid_amount.desc&limit=1"
+			bidsURL := supaURL + "/rest/v1/bids?listing_id=eq." + l.ID + "&" + ledger.Standing + "&order=bid_amount.desc&limit=1"
 			reqBids, _ := http.NewRequest("GET", bidsURL, nil)
 			reqBids.Header.Set("apikey", apiKey)
 			reqBids.Header.Set("Authorization", "Bearer "+apiKey)
@@ -215,7 +414,7 @@ func topListingsHandler(authClient *auth.Client) http.HandlerFunc {
 				continue
 			}
 			var bids []struct {
-				BidAmount float64 `json:"bid_amount"`
+				BidAmount money.Money `json:"bid_amount"`
 			}
 			if err := json.NewDecoder(respBids.Body).Decode(&bids); err != nil {
 				respBids.Body.Close()
@@ -223,13 +422,33 @@ func topListingsHandler(authClient *auth.Client) http.HandlerFunc {
 			}
 			respBids.Body.Close()
 
-			currentBid := l.StartingBid
+			currency := l.Currency
+			if currency == "" {
+				currency = money.DefaultCurrency
+			}
+			currentBid := l.StartingBid.Round(currency)
 			if len(bids) > 0 {
-				currentBid = bids[0].BidAmount
+				currentBid = bids[0].BidAmount.Round(currency)
 			}
 
 			auctionEnd, _ := time.Parse(time.RFC3339, l.AuctionEnd)
 			auctionStart, _ := time.Parse(time.RFC3339, l.AuctionStart)
+			if l.AuctionType == "" {
+				l.AuctionType = listing.English
+			}
+			if l.AuctionType.Sealed() && now.Before(auctionEnd) {
+				// Sealed bids stay hidden until close
+				currentBid = l.StartingBid.Round(currency)
+			}
+			if l.AuctionType == listing.Dutch && l.DutchSchedule != nil && len(bids) == 0 {
+				// Unsold Dutch auctions show the scheduled asking price
+				schedule := listing.DutchSchedule{
+					Decrement:       l.Decrement.Round(currency),
+					IntervalMinutes: l.IntervalMinutes,
+					Floor:           l.Floor.Round(currency),
+				}
+				currentBid = schedule.Price(l.StartingBid.Round(currency), auctionStart, now)
+			}
 
 			card := map[string]interface{}{
 				"id":                 l.ID,
@@ -237,12 +456,24 @@ func topListingsHandler(authClient *auth.Client) http.HandlerFunc {
 				"subtitle":           l.Subtitle,
 				"image":              "",
 				"current_bid":        currentBid,
+				"currency":           currency,
+				"auction_type":       l.AuctionType,
 				"auction_end_time":   l.AuctionEnd,
 				"auction_start_time": l.AuctionStart,
+				"watchers":           watchers[l.ID],
 			}
 			if len(l.Images) > 0 {
 				card["image"] = l.Images[0]
 			}
+			if l.LocationArea != "" {
+				card["location"] = l.LocationArea
+			}
+			if d, ok := distances[l.ID]; ok {
+				card["distance_km"] = d
+			}
+			if converted := convertedAmounts(r, displayIn, map[string]money.Money{"current_bid": currentBid}); converted != nil {
+				card["converted"] = converted
+			}
 
 			// Trending: highest current bid (top N)
 			// Ending soon: auction_end_time within next 1 hour
@@ -256,15 +487,23 @@ func topListingsHandler(authClient *auth.Client) http.HandlerFunc {
 			}
 		}
 
-		// Sort trending by current_bid descending
-		// (Simple bubble sort for brevity, use sort.Slice in production)
-		for i := 0; i < len(trending); i++ {
-			for j := i + 1; j < len(trending); j++ {
-				if trending[j]["current_bid"].(float64) > trending[i]["current_bid"].(float64) {
-					trending[i], trending[j] = trending[j], trending[i]
-				}
+		// Sort trending by watchers, then current_bid, descending. Bids are
+		// compared in the rates' base currency; ones that can't be converted
+		// sort last
+		rates := fx.Default.Current(r.Context())
+		bidValue := func(card map[string]interface{}) float64 {
+			if m, ok := rates.Convert(card["current_bid"].(money.Money), rates.Base); ok {
+				return m.Float()
 			}
+			return -1
 		}
+		sort.SliceStable(trending, func(i, j int) bool {
+			wi, wj := trending[i]["watchers"].(int), trending[j]["watchers"].(int)
+			if wi != wj {
+				return wi > wj
+			}
+			return bidValue(trending[i]) > bidValue(trending[j])
+		})
 		if len(trending) > 5 {
 			trending = trending[:5]
 		}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 	"github.com/quickswap/quickswap/internal/auth"
 )
 
@@ -68,3 +71,434 @@ func TestTopListingsHandler(t *testing.T) {
 		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 }
+
+func TestMyBidsHandlerWinningLabel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "user123", "email": "test@example.com"}`))
+		case "/rest/v1/bids":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[{"id": "bid1", "listing_id": "list1", "bid_amount": 30.3}]`))
+		case "/rest/v1/listings":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[{"id": "list1", "title": "Test Listing", "current_bid": "30.30", "currency": "USD", "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("GET", "/api/mybids", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	myBidsHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+
+	var body struct {
+		Bids []struct {
+			BidAmount json.Number `json:"bid_amount"`
+			Label     string      `json:"label"`
+		} `json:"bids"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if len(body.Bids) != 1 || body.Bids[0].Label != "Winning" {
+		t.Fatalf("Expected a single Winning bid, got %+v", body.Bids)
+	}
+	if body.Bids[0].BidAmount.String() != "30.30" {
+		t.Errorf("Expected bid_amount formatted as 30.30, got %s", body.Bids[0].BidAmount)
+	}
+}
+
+func TestMyBidsHandlerLabelsFromLedger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "user123"}`))
+		case "/rest/v1/bids":
+			if r.URL.Query().Get("user_id") != "" {
+				w.Write([]byte(`[{"id": "bid1", "listing_id": "list1", "user_id": "user123", "bid_amount": 20, "status": "active"}, {"id": "bid0", "listing_id": "list1", "user_id": "user123", "bid_amount": 25, "status": "retracted"}]`))
+				return
+			}
+			// Standing bids, highest first
+			w.Write([]byte(`[{"id": "bid9", "listing_id": "list1", "user_id": "other", "bid_amount": 22, "status": "active"}, {"id": "bid1", "listing_id": "list1", "user_id": "user123", "bid_amount": 20, "status": "active"}]`))
+		case "/rest/v1/listings":
+			// current_bid is stale; the ledger decides who leads
+			w.Write([]byte(`[{"id": "list1", "title": "Test Listing", "current_bid": "20.00", "currency": "USD", "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("GET", "/api/mybids", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	myBidsHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+
+	var body struct {
+		Bids []struct {
+			ID         string      `json:"id"`
+			CurrentBid json.Number `json:"current_bid"`
+			Label      string      `json:"label"`
+		} `json:"bids"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	labels := map[string]string{}
+	for _, b := range body.Bids {
+		labels[b.ID] = b.Label
+		if b.CurrentBid.String() != "22.00" {
+			t.Errorf("Expected current_bid 22.00 from the ledger, got %s", b.CurrentBid)
+		}
+	}
+	if labels["bid1"] != "Outbid" || labels["bid0"] != "Retracted" {
+		t.Errorf("Expected bid1 Outbid and bid0 Retracted, got %v", labels)
+	}
+}
+
+func TestMyBidsHandlerPartialWin(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "user123"}`))
+		case "/rest/v1/bids":
+			if r.URL.Query().Get("user_id") != "" {
+				w.Write([]byte(`[{"id": "bid2", "listing_id": "list1", "user_id": "user123", "bid_amount": 12, "quantity": 3, "status": "active"}]`))
+				return
+			}
+			w.Write([]byte(`[
+				{"id": "bid1", "listing_id": "list1", "user_id": "user456", "bid_amount": 15, "quantity": 3, "bid_sequence": 1, "status": "active"},
+				{"id": "bid2", "listing_id": "list1", "user_id": "user123", "bid_amount": 12, "quantity": 3, "bid_sequence": 2, "status": "active"}
+			]`))
+		case "/rest/v1/listings":
+			w.Write([]byte(`[{"id": "list1", "title": "Phone case", "currency": "USD", "quantity": 5, "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("GET", "/api/mybids", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	myBidsHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+
+	var body struct {
+		Bids []struct {
+			Label        string `json:"label"`
+			UnitsWinning int    `json:"units_winning"`
+		} `json:"bids"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if len(body.Bids) != 1 || body.Bids[0].Label != "Winning 2 of 3" || body.Bids[0].UnitsWinning != 2 {
+		t.Fatalf("Expected a partial win of 2 of 3 units, got %+v", body.Bids)
+	}
+}
+
+func TestTopListingsHandlerConvertedPrices(t *testing.T) {
+	ts := setupBidsMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("GET", "/api/toplistings?currency=EUR", nil)
+	rr := httptest.NewRecorder()
+	topListingsHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+
+	var body struct {
+		Trending []struct {
+			CurrentBid json.Number `json:"current_bid"`
+			Currency   string      `json:"currency"`
+			Converted  struct {
+				Currency   string      `json:"currency"`
+				CurrentBid json.Number `json:"current_bid"`
+			} `json:"converted"`
+		} `json:"trending_now"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if len(body.Trending) != 1 {
+		t.Fatalf("Expected one trending listing, got %+v", body.Trending)
+	}
+	card := body.Trending[0]
+	if card.Currency != "USD" || card.CurrentBid.String() != "50.00" {
+		t.Errorf("Expected native price 50.00 USD, got %s %s", card.CurrentBid, card.Currency)
+	}
+	if card.Converted.Currency != "EUR" || card.Converted.CurrentBid.String() != "46.00" {
+		t.Errorf("Expected converted price 46.00 EUR, got %s %s", card.Converted.CurrentBid, card.Converted.Currency)
+	}
+}
+
+func TestTopListingsHandlerRadiusSearch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/v1/bids":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[]`))
+		case "/rest/v1/listings":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[
+				{"id": "near", "title": "Desk", "starting_bid": 10, "auction_end_time": "2050-01-01T00:00:00Z", "latitude": 29.65, "longitude": -82.33, "location_area": "Gainesville, FL"},
+				{"id": "far", "title": "Chair", "starting_bid": 10, "auction_end_time": "2050-01-01T00:00:00Z", "latitude": 25.77, "longitude": -80.19},
+				{"id": "unknown", "title": "Lamp", "starting_bid": 10, "auction_end_time": "2050-01-01T00:00:00Z"}
+			]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	handler := topListingsHandler(auth.NewClient(ts.URL, "anon"))
+
+	req := httptest.NewRequest("GET", "/api/toplistings?near=29.6516,-82.3248&radius_km=50", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var body struct {
+		Trending []map[string]interface{} `json:"trending_now"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if len(body.Trending) != 1 || body.Trending[0]["id"] != "near" {
+		t.Fatalf("Expected only the nearby listing, got %v", body.Trending)
+	}
+	if d, ok := body.Trending[0]["distance_km"].(float64); !ok || d > 2 {
+		t.Errorf("Expected a distance under 2km, got %v", body.Trending[0]["distance_km"])
+	}
+	if body.Trending[0]["location"] != "Gainesville, FL" {
+		t.Errorf("Expected the coarse location on the card, got %v", body.Trending[0]["location"])
+	}
+
+	req = httptest.NewRequest("GET", "/api/toplistings?near=abc", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid near point, got %d", rr.Code)
+	}
+}
+
+func TestBidHistoryHandler(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "user123"}`))
+		case "/rest/v1/listings":
+			w.Write([]byte(`[{"id": "list1", "title": "Test Listing", "currency": "USD", "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		case "/rest/v1/bids":
+			if r.URL.Query().Get("select") == "user_id" {
+				w.Write([]byte(`[{"user_id": "user456"}, {"user_id": "user123"}, {"user_id": "user456"}]`))
+				return
+			}
+			w.Write([]byte(`[
+				{"listing_id": "list1", "user_id": "user456", "bid_amount": 10, "bid_sequence": 1, "status": "active", "timestamp": "2049-12-31T10:00:00Z"},
+				{"listing_id": "list1", "user_id": "user123", "bid_amount": 12, "bid_sequence": 2, "status": "active", "timestamp": "2049-12-31T11:00:00Z"}
+			]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	os.Setenv("BIDDER_HANDLE_SECRET", "test-secret")
+
+	handler := bidHistoryHandler(auth.NewClient(ts.URL, "anon"))
+
+	req := httptest.NewRequest("GET", "/api/listings/list1/bids?limit=2", nil)
+	req.SetPathValue("id", "list1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp struct {
+		Bids []struct {
+			Sequence int64  `json:"sequence"`
+			Bidder   string `json:"bidder"`
+			IsYou    bool   `json:"is_you"`
+		} `json:"bids"`
+		NextAfter *int64 `json:"next_after"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Bids) != 2 {
+		t.Fatalf("Expected 2 bids, got %d", len(resp.Bids))
+	}
+	if resp.Bids[0].IsYou || !resp.Bids[1].IsYou {
+		t.Errorf("Expected only the caller's bid to be marked is_you: %+v", resp.Bids)
+	}
+	if resp.Bids[0].Bidder == "" || resp.Bids[0].Bidder == "user456" {
+		t.Errorf("Expected a pseudonymous handle, got %q", resp.Bids[0].Bidder)
+	}
+	if resp.NextAfter == nil || *resp.NextAfter != 2 {
+		t.Errorf("Expected next_after 2, got %v", resp.NextAfter)
+	}
+
+	bad := httptest.NewRequest("GET", "/api/listings/list1/bids?after=x", nil)
+	bad.SetPathValue("id", "list1")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, bad)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid after, got %d", rr.Code)
+	}
+}
+
+func TestRetractBidHandler(t *testing.T) {
+	placed := time.Now().UTC().Add(-5 * time.Minute).Format(time.RFC3339)
+	var audited bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "user123"}`))
+		case "/rest/v1/listings":
+			w.Write([]byte(`[{"id": "list1", "title": "Test Listing", "seller_id": "seller1", "starting_bid": 10, "currency": "USD", "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		case "/rest/v1/bids":
+			switch {
+			case r.Method == "PATCH":
+				w.Write([]byte(`[{"id": "b2", "status": "retracted"}]`))
+			case r.URL.Query().Get("id") == "eq.b2":
+				w.Write([]byte(`[{"id": "b2", "listing_id": "list1", "user_id": "user123", "bid_amount": 1000, "bid_sequence": 2, "status": "active", "timestamp": "` + placed + `"}]`))
+			case r.URL.Query().Get("id") != "":
+				w.Write([]byte(`[]`))
+			default:
+				w.Write([]byte(`[
+					{"id": "b2", "listing_id": "list1", "user_id": "user123", "bid_amount": 1000, "bid_sequence": 2, "status": "active"},
+					{"id": "b1", "listing_id": "list1", "user_id": "user456", "bid_amount": 100, "bid_sequence": 1, "status": "active"}
+				]`))
+			}
+		case "/rest/v1/bid_retractions":
+			if r.Method == "POST" {
+				audited = true
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`[{"id": "r1"}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		case "/rest/v1/profiles":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	handler := retractBidHandler(auth.NewClient(ts.URL, "anon"), nil)
+	retract := func(bidID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/listings/list1/bids/"+bidID+"/retract", bytes.NewBufferString(body))
+		req.SetPathValue("id", "list1")
+		req.SetPathValue("bid_id", bidID)
+		req.Header.Set("Authorization", "Bearer validtoken")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := retract("b2", `{"reason": "typo"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		CurrentPrice float64 `json:"current_price"`
+		Retraction   struct {
+			NewLeaderID string `json:"new_leader_id"`
+		} `json:"retraction"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.CurrentPrice != 100 || resp.Retraction.NewLeaderID != "user456" {
+		t.Errorf("Expected the lead to fall back to user456 at 100, got %+v", resp)
+	}
+	if !audited {
+		t.Error("Expected the retraction to be audited")
+	}
+
+	if rr := retract("b2", `{"reason": "changed my mind"}`); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for an unknown reason, got %d", rr.Code)
+	}
+	if rr := retract("b9", `{"reason": "typo"}`); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown bid, got %d", rr.Code)
+	}
+}
+
+func TestTopListingsHandlerRanksAcrossCurrencies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/v1/listings":
+			w.Write([]byte(`[{"id": "yen", "title": "Yen Listing", "starting_bid": 100, "currency": "JPY", "auction_end_time": "2050-01-01T00:00:00Z"},
+				{"id": "usd", "title": "Dollar Listing", "starting_bid": 50, "currency": "USD", "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		case "/rest/v1/bids":
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("GET", "/api/toplistings", nil)
+	rr := httptest.NewRecorder()
+	topListingsHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+
+	var body struct {
+		Trending []struct {
+			ID string `json:"id"`
+		} `json:"trending_now"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if len(body.Trending) != 2 || body.Trending[0].ID != "usd" {
+		t.Errorf("Expected 50 USD to rank above 100 JPY, got %+v", body.Trending)
+	}
+}
+
+func TestTopListingsHandlerMatchesLegacyCategoryNames(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/v1/listings":
+			query = r.URL.Query().Get("category")
+			w.Write([]byte(`[{"id": "old", "title": "Old Listing", "category": "Electronics", "starting_bid": 50, "currency": "USD", "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		case "/rest/v1/bids":
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("GET", "/api/toplistings?category=electronics", nil)
+	rr := httptest.NewRecorder()
+	topListingsHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rr.Code)
+	}
+	if query != `in.("electronics","Electronics")` {
+		t.Errorf("Expected the filter to match the category ID or its legacy name, got %q", query)
+	}
+}
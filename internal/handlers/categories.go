@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/categories"
+)
+
+// categoriesHandler returns the category tree, each category's attribute
+// schema and the allowed listing conditions. Admins may pass
+// ?include_retired=true to see retired categories.
+func categoriesHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		includeRetired := r.URL.Query().Get("include_retired") == "true" && isAdmin(optionalUser(r))
+
+		respondJSON(w, map[string]interface{}{
+			"categories": categories.Current().Tree(includeRetired),
+			"conditions": categories.Conditions,
+		})
+	}
+}
+
+// respondCategoryError maps category store errors to responses.
+func respondCategoryError(w http.ResponseWriter, err error) {
+	var verr *categories.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		respondError(w, verr.Error(), http.StatusBadRequest)
+	case errors.Is(err, categories.ErrNotFound):
+		respondError(w, "Category not found", http.StatusNotFound)
+	default:
+		respondError(w, "Failed to save category", http.StatusInternalServerError)
+	}
+}
+
+// createCategoryHandler adds a category. Admin only.
+func createCategoryHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := requireAdmin(w, r); !ok {
+			return
+		}
+
+		var req categories.Category
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		c, err := categories.Create(req)
+		if err != nil {
+			respondCategoryError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(c)
+	}
+}
+
+// updateCategoryHandler edits a category's name, parent, position,
+// attributes or retired flag. Admin only.
+func updateCategoryHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := requireAdmin(w, r); !ok {
+			return
+		}
+
+		var req categories.Update
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		c, err := categories.Edit(r.PathValue("id"), req)
+		if err != nil {
+			respondCategoryError(w, err)
+			return
+		}
+		respondJSON(w, c)
+	}
+}
+
+// retireCategoryHandler retires a category so no new listings can use it.
+// Admin only.
+func retireCategoryHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := requireAdmin(w, r); !ok {
+			return
+		}
+
+		c, err := categories.Retire(r.PathValue("id"))
+		if err != nil {
+			respondCategoryError(w, err)
+			return
+		}
+		respondJSON(w, c)
+	}
+}
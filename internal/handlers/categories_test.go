@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+func setupCategoriesMockServer(inserted *[]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "admin1", "email": "admin@example.com"}`))
+		case "/rest/v1/categories":
+			switch r.Method {
+			case "GET":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[]`))
+			case "POST":
+				var rows []map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&rows)
+				*inserted = append(*inserted, rows...)
+				w.WriteHeader(http.StatusCreated)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestCategoriesHandler(t *testing.T) {
+	var inserted []map[string]interface{}
+	ts := setupCategoriesMockServer(&inserted)
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("GET", "/api/categories", nil)
+	rr := httptest.NewRecorder()
+	categoriesHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+
+	var body struct {
+		Categories []struct {
+			ID       string `json:"id"`
+			Children []struct {
+				ID string `json:"id"`
+			} `json:"children"`
+		} `json:"categories"`
+		Conditions []string `json:"conditions"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if len(body.Categories) == 0 || body.Categories[0].ID != "electronics" || len(body.Categories[0].Children) == 0 {
+		t.Errorf("Expected the default tree, got %+v", body.Categories)
+	}
+	if len(body.Conditions) == 0 {
+		t.Errorf("Expected allowed conditions")
+	}
+}
+
+func TestCreateCategoryHandlerAdminOnly(t *testing.T) {
+	var inserted []map[string]interface{}
+	ts := setupCategoriesMockServer(&inserted)
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	handler := createCategoryHandler(auth.NewClient(ts.URL, "anon"))
+	body := `{"id": "tablets", "parent_id": "electronics", "name": "Tablets",
+		"attributes": [{"key": "storage", "label": "Storage", "required": true, "values": ["64GB", "128GB"]}]}`
+
+	os.Setenv("ADMIN_USER_IDS", "")
+	req := httptest.NewRequest("POST", "/api/admin/categories", bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for non-admins, got %d", rr.Code)
+	}
+
+	os.Setenv("ADMIN_USER_IDS", "someone, admin1")
+	defer os.Setenv("ADMIN_USER_IDS", "")
+	req = httptest.NewRequest("POST", "/api/admin/categories", bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(inserted) == 0 || inserted[len(inserted)-1]["id"] != "tablets" {
+		t.Errorf("Expected the new category to be stored, got %v", inserted)
+	}
+
+	req = httptest.NewRequest("POST", "/api/admin/categories", bytes.NewBufferString(`{"id": "Bad Id", "name": "Bad"}`))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid id, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/fx"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// displayCurrency picks the currency approximate prices are shown in: the
+// ?currency= query parameter if valid, else the caller's preferred currency.
+// It returns "" when there is nothing to convert to.
+func displayCurrency(r *http.Request, userID string) money.Currency {
+	if code := r.URL.Query().Get("currency"); code != "" {
+		if c, err := money.ParseCurrency(code); err == nil {
+			return c
+		}
+	}
+	if userID == "" {
+		return ""
+	}
+
+	var profiles []struct {
+		PreferredCurrency string `json:"preferred_currency"`
+	}
+	if err := supabase.Select("profiles", "id=eq."+url.QueryEscape(userID)+"&select=preferred_currency", &profiles); err != nil || len(profiles) == 0 {
+		return ""
+	}
+	c, err := money.ParseCurrency(profiles[0].PreferredCurrency)
+	if err != nil {
+		return ""
+	}
+	return c
+}
+
+// convertedAmounts converts each amount to currency to for display. It
+// returns nil when no conversion is needed or rates are unavailable. Bids are
+// always placed in the listing's own currency.
+func convertedAmounts(r *http.Request, to money.Currency, amounts map[string]money.Money) map[string]interface{} {
+	if to == "" {
+		return nil
+	}
+	out := map[string]interface{}{"currency": to, "approximate": true}
+	for name, m := range amounts {
+		if m.Currency == to {
+			return nil
+		}
+		c, ok := fx.Convert(r.Context(), m, to)
+		if !ok {
+			return nil
+		}
+		out[name] = c
+	}
+	return out
+}
+
+// updateProfileHandler changes the caller's profile settings. Only
+// preferred_currency can be changed for now.
+func updateProfileHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			PreferredCurrency *string `json:"preferred_currency"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.PreferredCurrency == nil {
+			respondError(w, "No profile fields to update", http.StatusBadRequest)
+			return
+		}
+
+		// An empty value clears the preference
+		patch := map[string]interface{}{"preferred_currency": nil}
+		if *req.PreferredCurrency != "" {
+			c, err := money.ParseCurrency(*req.PreferredCurrency)
+			if err != nil {
+				respondError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			patch["preferred_currency"] = c
+		}
+
+		var updated []map[string]interface{}
+		if err := supabase.Update("profiles", "id=eq."+url.QueryEscape(userID), patch, &updated); err != nil {
+			respondError(w, "Failed to update profile", http.StatusInternalServerError)
+			return
+		}
+		if len(updated) == 0 {
+			respondError(w, "Profile not found", http.StatusNotFound)
+			return
+		}
+
+		respondJSON(w, updated[0])
+	}
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/disputes"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/payments"
+)
+
+// openDisputeHandler lets a buyer open a dispute on a paid order, which holds
+// its escrow until the case is closed.
+func openDisputeHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			OrderID     string `json:"order_id"`
+			Reason      string `json:"reason"`
+			Description string `json:"description"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		o, ok := partyOrder(w, req.OrderID, userID)
+		if !ok {
+			return
+		}
+		d, err := disputes.Open(o, userID, req.Reason, req.Description, time.Now().UTC())
+		if err != nil {
+			respondDisputeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		respondJSON(w, map[string]interface{}{"dispute": d})
+	}
+}
+
+// disputesHandler lists the caller's disputes as buyer or seller. Admins can
+// pass ?status= to see every dispute in that status, such as the
+// under_review queue.
+func disputesHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var list []disputes.Dispute
+		var err error
+		if status := r.URL.Query().Get("status"); status != "" {
+			if !isAdmin(userID) {
+				respondError(w, "Admin access required", http.StatusForbidden)
+				return
+			}
+			list, err = disputes.WithStatus(disputes.Status(status))
+		} else {
+			list, err = disputes.ForUser(userID)
+		}
+		if err != nil {
+			respondError(w, "Failed to fetch disputes", http.StatusInternalServerError)
+			return
+		}
+		if list == nil {
+			list = []disputes.Dispute{}
+		}
+		respondJSON(w, map[string]interface{}{"disputes": list})
+	}
+}
+
+// disputeHandler returns a dispute and its evidence to either party or an
+// admin.
+func disputeHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		d, ok := visibleDispute(w, r.PathValue("id"), userID)
+		if !ok {
+			return
+		}
+		evidence, err := disputes.EvidenceFor(d.ID)
+		if err != nil {
+			respondError(w, "Failed to fetch evidence", http.StatusInternalServerError)
+			return
+		}
+		if evidence == nil {
+			evidence = []disputes.Evidence{}
+		}
+		respondJSON(w, map[string]interface{}{"dispute": d, "evidence": evidence})
+	}
+}
+
+// addEvidenceHandler attaches a link to an uploaded photo or document, or a
+// written statement, to an open dispute.
+func addEvidenceHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			URL         string `json:"url"`
+			Description string `json:"description"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		d, ok := visibleDispute(w, r.PathValue("id"), userID)
+		if !ok {
+			return
+		}
+		e, err := disputes.AddEvidence(d, userID, req.URL, req.Description, time.Now().UTC())
+		if err != nil {
+			respondDisputeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		respondJSON(w, map[string]interface{}{"evidence": e})
+	}
+}
+
+// respondDisputeHandler records the seller's response. Sellers can accept a
+// full refund instead of contesting the claim.
+func respondDisputeHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Response     string `json:"response"`
+			AcceptRefund bool   `json:"accept_refund"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		d, ok := visibleDispute(w, r.PathValue("id"), userID)
+		if !ok {
+			return
+		}
+		if err := disputes.Respond(r.Context(), payments.Default, d, userID, req.Response, req.AcceptRefund, time.Now().UTC()); err != nil {
+			respondDisputeError(w, err)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"dispute": d})
+	}
+}
+
+// withdrawDisputeHandler lets the buyer drop their dispute, releasing escrow
+// to the seller.
+func withdrawDisputeHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		d, ok := visibleDispute(w, r.PathValue("id"), userID)
+		if !ok {
+			return
+		}
+		if err := disputes.Withdraw(d, userID, time.Now().UTC()); err != nil {
+			respondDisputeError(w, err)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"dispute": d})
+	}
+}
+
+// resolveDisputeHandler lets an admin close a dispute with a refund, a
+// partial refund of refund_amount, or a rejection that releases escrow to the
+// seller.
+func resolveDisputeHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := requireAdmin(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Resolution   disputes.Resolution `json:"resolution"`
+			RefundAmount *money.Money        `json:"refund_amount"`
+			Note         string              `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Note == "" {
+			respondError(w, "A note explaining the resolution is required", http.StatusBadRequest)
+			return
+		}
+
+		d, ok := visibleDispute(w, r.PathValue("id"), adminID)
+		if !ok {
+			return
+		}
+		if err := disputes.Resolve(r.Context(), payments.Default, d, adminID, req.Resolution, req.RefundAmount, req.Note, time.Now().UTC()); err != nil {
+			respondDisputeError(w, err)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"dispute": d})
+	}
+}
+
+// visibleDispute fetches a dispute the caller is a party to or can review as
+// an admin, writing the error response if there isn't one.
+func visibleDispute(w http.ResponseWriter, id, userID string) (*disputes.Dispute, bool) {
+	d, err := disputes.Get(id)
+	if errors.Is(err, disputes.ErrNotFound) || (err == nil && !d.Party(userID) && !isAdmin(userID)) {
+		respondError(w, "Dispute not found", http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		respondError(w, "Failed to fetch dispute", http.StatusInternalServerError)
+		return nil, false
+	}
+	return d, true
+}
+
+// respondDisputeError maps dispute errors to responses, falling back to the
+// order errors for escrow failures.
+func respondDisputeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, disputes.ErrForbidden):
+		respondError(w, "You can't do that on this dispute", http.StatusForbidden)
+	case errors.Is(err, disputes.ErrInvalid):
+		respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, disputes.ErrClosed):
+		respondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, disputes.ErrNotFound):
+		respondError(w, "Dispute not found", http.StatusNotFound)
+	default:
+		log.Printf("Dispute request failed: %v", err)
+		respondOrderError(w, err)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/payments"
+)
+
+func TestOpenDisputeHandler(t *testing.T) {
+	var held, opened bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "buyer1"}`))
+		case "/rest/v1/orders":
+			if r.Method == "PATCH" {
+				if r.URL.Query().Get("disputed") != "is.false" {
+					t.Errorf("Expected the hold conditional on no open dispute, got %q", r.URL.RawQuery)
+				}
+				held = true
+				w.Write([]byte(`[{"id": "o1", "listing_id": "list1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 40, "status": "paid", "disputed": true}]`))
+				return
+			}
+			w.Write([]byte(`[{"id": "o1", "listing_id": "list1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 40, "status": "paid", "paid_at": "2049-01-01T00:00:00Z"}]`))
+		case "/rest/v1/disputes":
+			opened = r.Method == "POST"
+			w.Write([]byte(`[{"id": "d1", "order_id": "o1", "buyer_id": "buyer1", "seller_id": "seller1", "reason": "damaged", "status": "awaiting_seller"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	open := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/disputes", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer validtoken")
+		rr := httptest.NewRecorder()
+		openDisputeHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := open(`{"order_id": "o1", "reason": "changed_mind", "description": "Meh"}`); rr.Code != http.StatusBadRequest || held {
+		t.Errorf("Expected 400 and no hold for an unknown reason, got %d (held=%v)", rr.Code, held)
+	}
+	rr := open(`{"order_id": "o1", "reason": "damaged", "description": "Arrived cracked"}`)
+	if rr.Code != http.StatusCreated || !held || !opened {
+		t.Fatalf("Expected 201 with escrow held, got %d (held=%v opened=%v): %s", rr.Code, held, opened, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"status":"awaiting_seller"`) {
+		t.Errorf("Expected the dispute awaiting the seller, got %s", rr.Body.String())
+	}
+}
+
+func TestResolveDisputeHandlerHoldsUntilSettled(t *testing.T) {
+	var patches []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "admin1"}`))
+		case "/rest/v1/disputes":
+			w.Write([]byte(`[{"id": "d1", "order_id": "o1", "buyer_id": "buyer1", "seller_id": "seller1", "reason": "damaged", "status": "under_review"}]`))
+		case "/rest/v1/orders":
+			if r.Method == "PATCH" {
+				var patch map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&patch)
+				patches = append(patches, patch)
+				w.Write([]byte(`[{"id": "o1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 40, "status": "refunded", "payment_id": "pi_1"}]`))
+				return
+			}
+			w.Write([]byte(`[{"id": "o1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 40, "status": "paid", "payment_id": "pi_1", "disputed": true}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	os.Setenv("ADMIN_USER_IDS", "admin1")
+	defer os.Setenv("ADMIN_USER_IDS", "")
+	fake := &payments.FakeProvider{}
+	payments.Default = fake
+
+	req := httptest.NewRequest("POST", "/api/disputes/d1/resolve", bytes.NewBufferString(`{"resolution": "refund", "note": "Arrived broken"}`))
+	req.SetPathValue("id", "d1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	resolveDisputeHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, ok := fake.Refunded("pi_1"); !ok {
+		t.Error("Expected the buyer refunded")
+	}
+	if len(patches) != 1 || patches[0]["status"] != "refunded" || patches[0]["disputed"] != false {
+		t.Errorf("Expected the hold lifted only with the refund, got %v", patches)
+	}
+}
+
+func TestResolveDisputeHandlerRequiresAdmin(t *testing.T) {
+	ts := setupOrdersMockServer(new(bool))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	os.Setenv("ADMIN_USER_IDS", "")
+
+	req := httptest.NewRequest("POST", "/api/disputes/d1/resolve", bytes.NewBufferString(`{"resolution": "refund", "note": "Refund it"}`))
+	req.SetPathValue("id", "d1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	resolveDisputeHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-admin resolving, got %d", rr.Code)
+	}
+}
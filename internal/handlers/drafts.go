@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/drafts"
+	listing "github.com/quickswap/quickswap/internal/listings"
+)
+
+// PublishListing stores a validated listing the way createListingHandler
+// does. It is exported for scheduled draft publishing.
+func PublishListing(ctx context.Context, l *listing.Listing) (string, error) {
+	return publishListing(ctx, l)
+}
+
+// draftResponse adds the fields still needed before a draft can publish.
+func draftResponse(d *drafts.Draft) map[string]interface{} {
+	missing := d.Data.Missing()
+	if missing == nil {
+		missing = []string{}
+	}
+	return map[string]interface{}{
+		"draft":   d,
+		"missing": missing,
+	}
+}
+
+// respondDraftError maps drafts errors to responses.
+func respondDraftError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, drafts.ErrNotFound):
+		respondError(w, "Draft not found", http.StatusNotFound)
+	case errors.Is(err, drafts.ErrPublished):
+		respondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, drafts.ErrInvalid):
+		respondError(w, err.Error(), http.StatusBadRequest)
+	default:
+		respondError(w, "Failed to save draft", http.StatusInternalServerError)
+	}
+}
+
+// draftsHandler lists the caller's drafts (GET) or starts a new one (POST).
+// Drafts accept any subset of the createlisting fields.
+func draftsHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			list, err := drafts.ForSeller(userID)
+			if err != nil {
+				respondError(w, "Failed to fetch drafts", http.StatusInternalServerError)
+				return
+			}
+			if list == nil {
+				list = []drafts.Draft{}
+			}
+			respondJSON(w, map[string]interface{}{"drafts": list})
+			return
+		}
+
+		var in listing.Input
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil && err != io.EOF {
+			respondError(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		d, err := drafts.Create(userID, in)
+		if err != nil {
+			respondDraftError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(draftResponse(d))
+	}
+}
+
+// draftHandler reads (GET), autosaves (PUT) or discards (DELETE) one of the
+// caller's drafts.
+func draftHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		id := r.PathValue("id")
+
+		switch r.Method {
+		case http.MethodGet:
+			d, err := drafts.Get(userID, id)
+			if err != nil {
+				respondDraftError(w, err)
+				return
+			}
+			respondJSON(w, draftResponse(d))
+
+		case http.MethodPut:
+			var in listing.Input
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				respondError(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+			d, err := drafts.Save(userID, id, in)
+			if err != nil {
+				respondDraftError(w, err)
+				return
+			}
+			respondJSON(w, draftResponse(d))
+
+		case http.MethodDelete:
+			if err := drafts.Delete(userID, id); err != nil {
+				respondDraftError(w, err)
+				return
+			}
+			respondJSON(w, map[string]interface{}{"id": id, "deleted": true})
+
+		default:
+			respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// publishDraftHandler runs full listing validation on a draft and publishes
+// it now, or at publish_at if one is given.
+func publishDraftHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			PublishAt *time.Time `json:"publish_at"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			respondError(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		d, err := drafts.Get(userID, r.PathValue("id"))
+		if err != nil {
+			respondDraftError(w, err)
+			return
+		}
+		if d.Status == drafts.Published || d.Status == drafts.Publishing {
+			respondDraftError(w, drafts.ErrPublished)
+			return
+		}
+
+		l, err := d.Data.Build(userID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   err.Error(),
+				"missing": d.Data.Missing(),
+			})
+			return
+		}
+
+		if req.PublishAt != nil && req.PublishAt.After(time.Now()) {
+			at := req.PublishAt.UTC()
+			if !at.Before(l.AuctionEndTime) {
+				respondError(w, "publish_at must be before auction_end_time", http.StatusBadRequest)
+				return
+			}
+			if !l.AuctionStartTime.IsZero() && at.After(l.AuctionStartTime) {
+				respondError(w, "publish_at must not be after auction_start_time", http.StatusBadRequest)
+				return
+			}
+			if err := drafts.Schedule(d, at); err != nil {
+				respondDraftError(w, err)
+				return
+			}
+			respondJSON(w, draftResponse(d))
+			return
+		}
+
+		id, err := publishListing(r.Context(), l)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := drafts.MarkPublished(d, id); err != nil {
+			respondError(w, "Listing created but draft not updated", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, map[string]interface{}{
+			"listing_id": id,
+			"status":     "success",
+			"message":    "Listing created successfully.",
+		})
+	}
+}
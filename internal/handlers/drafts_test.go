@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+const completeDraft = `{"title": "Desk", "description": "Oak desk", "category": "home", "images": ["a.jpg"],
+	"starting_bid": 40, "auction_end_time": "2050-01-02T00:00:00Z", "location": "Gainesville, FL"}`
+
+func setupDraftsMockServer(draftData string, patches *[]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "user123", "email": "test@example.com"}`))
+		case "/rest/v1/listing_drafts":
+			switch r.Method {
+			case "GET":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[{"id": "d1", "seller_id": "user123", "status": "draft", "data": ` + draftData + `}]`))
+			case "POST":
+				var rows []map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&rows)
+				rows[0]["id"] = "d2"
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(rows)
+			case "PATCH":
+				var patch map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&patch)
+				*patches = append(*patches, patch)
+				if r.Header.Get("Prefer") == "return=representation" {
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode([]map[string]interface{}{patch})
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}
+		case "/rest/v1/listings":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`[{"id": "list9", "title": "Desk", "starting_bid": 40}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestDraftsHandlerAutosavesPartialDraft(t *testing.T) {
+	var patches []map[string]interface{}
+	ts := setupDraftsMockServer(`{"title": "Desk"}`, &patches)
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("POST", "/api/drafts", bytes.NewBufferString(`{"title": "Desk", "category": "home"}`))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	draftsHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Draft struct {
+			ID string `json:"id"`
+		} `json:"draft"`
+		Missing []string `json:"missing"`
+	}
+	json.NewDecoder(rr.Body).Decode(&body)
+	if body.Draft.ID != "d2" || len(body.Missing) == 0 {
+		t.Errorf("Expected the new draft with its missing fields, got %+v", body)
+	}
+
+	req = httptest.NewRequest("POST", "/api/drafts", bytes.NewBufferString(`{"category": "cars"}`))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr = httptest.NewRecorder()
+	draftsHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid field, got %d", rr.Code)
+	}
+}
+
+func TestPublishDraftHandler(t *testing.T) {
+	var patches []map[string]interface{}
+	ts := setupDraftsMockServer(completeDraft, &patches)
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/drafts/{id}/publish", publishDraftHandler(auth.NewClient(ts.URL, "anon")))
+
+	// Scheduled for later: nothing is listed yet
+	req := httptest.NewRequest("POST", "/api/drafts/d1/publish", bytes.NewBufferString(`{"publish_at": "2049-06-01T00:00:00Z"}`))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(patches) != 1 || patches[0]["status"] != "scheduled" {
+		t.Errorf("Expected the draft to be scheduled, got %v", patches)
+	}
+
+	// Published now
+	req = httptest.NewRequest("POST", "/api/drafts/d1/publish", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	var body map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&body)
+	if rr.Code != http.StatusOK || body["listing_id"] != "list9" {
+		t.Fatalf("Expected the draft to publish as list9, got %d %v", rr.Code, body)
+	}
+	if last := patches[len(patches)-1]; last["status"] != "published" || last["listing_id"] != "list9" {
+		t.Errorf("Expected the draft to be marked published, got %v", last)
+	}
+}
+
+func TestPublishDraftHandlerRequiresCompleteDraft(t *testing.T) {
+	var patches []map[string]interface{}
+	ts := setupDraftsMockServer(`{"title": "Desk"}`, &patches)
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/drafts/{id}/publish", publishDraftHandler(auth.NewClient(ts.URL, "anon")))
+
+	req := httptest.NewRequest("POST", "/api/drafts/d1/publish", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an incomplete draft, got %d", rr.Code)
+	}
+	var body struct {
+		Missing []string `json:"missing"`
+	}
+	json.NewDecoder(rr.Body).Decode(&body)
+	if len(body.Missing) == 0 {
+		t.Errorf("Expected the missing fields to be listed")
+	}
+}
+
+func TestDraftHandlerSaveLosesRaceWithPublish(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "user123"}`))
+		case "/rest/v1/listing_drafts":
+			if r.Method == "PATCH" {
+				// The scheduler moved the draft on after it was read
+				if r.URL.Query().Get("status") != "eq.scheduled" {
+					t.Errorf("Expected the save conditional on the status read, got %q", r.URL.RawQuery)
+				}
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"id": "d1", "seller_id": "user123", "status": "scheduled", "data": {"title": "Desk"}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("PUT", "/api/drafts/d1", bytes.NewBufferString(`{"title": "Oak desk"}`))
+	req.SetPathValue("id", "d1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	draftHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 when the draft was published mid-save, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/db"
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/supabase"
+	"github.com/redis/go-redis/v9"
+)
+
+// acceptPriceHandler buys a Dutch auction at its current asking price. The
+// optional max_price guards against paying more than the price the caller
+// was shown.
+func acceptPriceHandler(c *auth.Client, pg *pgxpool.Pool, rdb *redis.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auctionID := r.PathValue("id")
+		if auctionID == "" {
+			respondError(w, "Auction ID is required", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok || !checkBidding(w, userID) {
+			return
+		}
+
+		var req struct {
+			MaxPrice *money.Money `json:"max_price"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		l, err := listing.Get(auctionID)
+		if errors.Is(err, listing.ErrNotFound) {
+			respondError(w, "Auction not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch auction", http.StatusInternalServerError)
+			return
+		}
+		if l.AuctionType != listing.Dutch {
+			respondError(w, "Only Dutch auctions have a price to accept", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now().UTC()
+		if now.Before(l.AuctionStartTime) {
+			respondError(w, "Auction has not started", http.StatusBadRequest)
+			return
+		}
+		price := l.AskingPrice(now)
+		if req.MaxPrice != nil {
+			maxPrice, err := req.MaxPrice.In(l.Currency)
+			if err != nil {
+				respondError(w, "Invalid max_price", http.StatusBadRequest)
+				return
+			}
+			if price.Cmp(maxPrice) > 0 {
+				respondError(w, "Current price is above max_price", http.StatusConflict)
+				return
+			}
+		}
+
+		ctx := r.Context()
+		if err := db.EnsureAuctionCached(ctx, rdb, pg, auctionID); err != nil {
+			log.Printf("Error caching auction %s: %v", auctionID, err)
+			respondError(w, "Auction not found or error loading auction", http.StatusNotFound)
+			return
+		}
+		result, err := db.ClaimAuction(ctx, rdb, auctionID, userID, price)
+		switch {
+		case errors.Is(err, db.ErrSold):
+			respondError(w, err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, db.ErrSelfBid):
+			respondError(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, db.ErrSellerUnknown):
+			respondError(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		case err != nil:
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := finishClaim(ctx, rdb, auctionID, userID, result, now); err != nil {
+			log.Printf("Error finishing claim on %s: %v", auctionID, err)
+			respondError(w, "Failed to record purchase, please try again", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, map[string]interface{}{
+			"message":  "Price accepted",
+			"amount":   result.Amount,
+			"currency": result.Amount.Currency,
+		})
+	}
+}
+
+// finishClaim records an auction claimed outright in the bid ledger and ends
+// the listing now, so settlement picks the buyer up on its next pass. If the
+// ledger write fails the claim is undone in the cache and an error returned.
+func finishClaim(ctx context.Context, rdb *redis.Client, auctionID, userID string, result db.BidResult, now time.Time) error {
+	if err := ledger.Record(ledger.Bid{
+		ListingID:   auctionID,
+		UserID:      userID,
+		BidAmount:   result.Amount,
+		BidSequence: result.Sequence,
+	}); err != nil {
+		if uerr := db.UndoBid(context.WithoutCancel(ctx), rdb, auctionID, userID, result); uerr != nil {
+			log.Printf("Warning: unrecorded claim %d on %s not rolled back: %v", result.Sequence, auctionID, uerr)
+		}
+		return fmt.Errorf("record claim: %w", err)
+	}
+
+	patch := map[string]interface{}{"auction_end_time": now}
+	if err := supabase.Update("listings", "id=eq."+url.QueryEscape(auctionID)+"&settled_at=is.null", patch, nil); err != nil {
+		log.Printf("Warning: failed to end auction %s: %v", auctionID, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+func TestAcceptPriceHandler(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "user123"}`))
+		case "/rest/v1/listings":
+			if r.URL.Query().Get("id") == "eq.english" {
+				w.Write([]byte(`[{"id": "english", "starting_bid": 10, "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+				return
+			}
+			w.Write([]byte(`[{"id": "dutch", "seller_id": "seller1", "starting_bid": 100, "auction_type": "dutch",
+				"dutch_decrement": 5, "dutch_interval_minutes": 60, "dutch_floor": 40,
+				"auction_start_time": "2020-01-01T00:00:00Z", "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		case "/rest/v1/bidder_strikes":
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	handler := acceptPriceHandler(auth.NewClient(ts.URL, "anon"), nil, nil)
+	accept := func(id, body string) int {
+		req := httptest.NewRequest("POST", "/api/auctions/"+id+"/accept", bytes.NewBufferString(body))
+		req.SetPathValue("id", id)
+		req.Header.Set("Authorization", "Bearer validtoken")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := accept("english", ``); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a non-Dutch auction, got %d", code)
+	}
+	// The schedule has long since reached its floor of 40
+	if code := accept("dutch", `{"max_price": 30}`); code != http.StatusConflict {
+		t.Errorf("Expected 409 when the price is above max_price, got %d", code)
+	}
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -9,29 +11,178 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/quickswap/quickswap/internal/auth"
 	"github.com/quickswap/quickswap/internal/db"
+	"github.com/quickswap/quickswap/internal/events"
+	"github.com/quickswap/quickswap/internal/fraud"
+	"github.com/quickswap/quickswap/internal/idempotency"
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/ratelimit"
 	"github.com/redis/go-redis/v9"
 )
 
 // NewRouter returns an http.Handler with auth routes registered.
 func NewRouter(c *auth.Client, pg *pgxpool.Pool, rdb *redis.Client) http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/auth/login", loginHandler(c))
-	mux.HandleFunc("/api/auth/signup", signupHandler(c))
+	limiter := ratelimit.New(rdb)
+	idem := idempotency.New(rdb)
+
+	mux.Handle("/api/auth/login", ratelimit.Middleware(limiter, ratelimit.Auth, loginHandler(c)))
+	mux.Handle("/api/auth/signup", ratelimit.Middleware(limiter, ratelimit.Auth, signupHandler(c)))
 	mux.HandleFunc("/api/auth/logout", logoutHandler(c))
 	mux.HandleFunc("/api/auth/me", meHandler(c))
 	mux.HandleFunc("/api/profile", profileHandler(c))
+	mux.HandleFunc("PATCH /api/profile", updateProfileHandler(c))
 
 	// Register listing route
-	mux.HandleFunc("/api/createlisting", createListingHandler(c))
+	mux.Handle("/api/createlisting", idempotency.Middleware(idem, "createlisting", createListingHandler(c)))
 	mux.HandleFunc("/api/mylistings", myListingHandler(c))
+	mux.HandleFunc("POST /api/imports", importListingsHandler(c))
+	mux.HandleFunc("GET /api/imports/{id}", importJobHandler(c))
+	mux.HandleFunc("/api/drafts", draftsHandler(c))
+	mux.HandleFunc("/api/drafts/{id}", draftHandler(c))
+	mux.Handle("POST /api/drafts/{id}/publish", idempotency.Middleware(idem, "publishdraft", publishDraftHandler(c)))
 	mux.HandleFunc("/api/listing", singleListingHandler(c))
 
+	// Register category taxonomy Api
+	mux.HandleFunc("GET /api/categories", categoriesHandler(c))
+	mux.HandleFunc("POST /api/admin/categories", createCategoryHandler(c))
+	mux.HandleFunc("PATCH /api/admin/categories/{id}", updateCategoryHandler(c))
+	mux.HandleFunc("DELETE /api/admin/categories/{id}", retireCategoryHandler(c))
+
 	// Register bids Api
 	mux.HandleFunc("/api/mybids", myBidsHandler(c))
 	mux.HandleFunc("/api/toplistings", topListingsHandler(c))
 
-	mux.HandleFunc("POST /api/auctions/{id}/bid", bidHandler(c, pg, rdb))
-	return mux
+	mux.Handle("POST /api/auctions/{id}/bid", ratelimit.Middleware(limiter, ratelimit.Bid,
+		idempotency.Middleware(idem, "bid", bidHandler(c, pg, rdb))))
+	mux.Handle("POST /api/auctions/{id}/accept", ratelimit.Middleware(limiter, ratelimit.Bid,
+		idempotency.Middleware(idem, "accept", acceptPriceHandler(c, pg, rdb))))
+	mux.HandleFunc("GET /api/listings/{id}/bids", bidHistoryHandler(c))
+	mux.HandleFunc("POST /api/listings/{id}/offers", makeOfferHandler(c))
+	mux.HandleFunc("GET /api/myoffers", myOffersHandler(c))
+	mux.HandleFunc("POST /api/offers/{id}/{action}", respondOfferHandler(c, pg, rdb))
+	mux.HandleFunc("POST /api/listings/{id}/second-chance", sendSecondChanceHandler(c))
+	mux.HandleFunc("POST /api/second-chance/{id}/{action}", respondSecondChanceHandler(c))
+	mux.HandleFunc("POST /api/listings/{id}/bids/{bid_id}/retract", retractBidHandler(c, rdb))
+
+	// Register orders and payments Api
+	mux.HandleFunc("GET /api/orders", myOrdersHandler(c))
+	mux.HandleFunc("GET /api/orders/{id}", orderHandler(c))
+	mux.Handle("POST /api/orders/{id}/checkout", idempotency.Middleware(idem, "checkout", checkoutHandler(c)))
+	mux.HandleFunc("POST /api/orders/{id}/ship", shipOrderHandler(c))
+	mux.HandleFunc("POST /api/orders/{id}/confirm-delivery", confirmDeliveryHandler(c))
+	mux.HandleFunc("POST /api/payments/webhook", paymentWebhookHandler())
+	mux.HandleFunc("POST /api/listings/{id}/relist", relistHandler(c))
+	mux.HandleFunc("GET /api/strikes", myStrikesHandler(c))
+	mux.HandleFunc("POST /api/admin/strikes/{id}/clear", clearStrikeHandler(c))
+
+	// Register disputes Api
+	mux.HandleFunc("POST /api/disputes", openDisputeHandler(c))
+	mux.HandleFunc("GET /api/disputes", disputesHandler(c))
+	mux.HandleFunc("GET /api/disputes/{id}", disputeHandler(c))
+	mux.HandleFunc("POST /api/disputes/{id}/evidence", addEvidenceHandler(c))
+	mux.HandleFunc("POST /api/disputes/{id}/respond", respondDisputeHandler(c))
+	mux.HandleFunc("POST /api/disputes/{id}/withdraw", withdrawDisputeHandler(c))
+	mux.HandleFunc("POST /api/disputes/{id}/resolve", resolveDisputeHandler(c))
+
+	// Register watchlist Api
+	mux.HandleFunc("GET /api/watchlist", watchlistHandler(c))
+	mux.HandleFunc("POST /api/watchlist/{listing_id}", watchHandler(c))
+	mux.HandleFunc("DELETE /api/watchlist/{listing_id}", unwatchHandler(c))
+
+	// Register saved searches Api
+	mux.HandleFunc("/api/saved-searches", savedSearchesHandler(c))
+	mux.HandleFunc("DELETE /api/saved-searches/{id}", deleteSavedSearchHandler(c))
+
+	// Register notifications Api
+	mux.HandleFunc("GET /api/notifications", notificationsHandler(c))
+	mux.HandleFunc("POST /api/notifications/{id}/read", markNotificationReadHandler(c))
+	mux.HandleFunc("POST /api/notifications/read-all", markAllNotificationsReadHandler(c))
+	mux.HandleFunc("/api/notifications/preferences", notificationPreferencesHandler(c))
+
+	// Register post-auction messaging Api
+	mux.HandleFunc("/api/listings/{id}/messages", listingMessagesHandler(c))
+	mux.HandleFunc("GET /api/messages/unread", unreadMessagesHandler(c))
+
+	// Register listing Q&A Api
+	mux.HandleFunc("/api/listings/{id}/questions", listingQuestionsHandler(c))
+	mux.HandleFunc("POST /api/listings/{id}/questions/{qid}/answer", answerQuestionHandler(c))
+
+	// Read APIs share a per-user budget
+	return ratelimit.Reads(limiter, ratelimit.Read, mux)
+}
+
+// requireUser resolves the bearer token on r to a Supabase user ID. If the
+// caller is not authenticated it writes the error response and returns false.
+func requireUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	token := r.Header.Get("Authorization")
+	if len(token) > 7 && token[:7] == "Bearer " {
+		token = token[7:]
+	}
+	if token == "" {
+		respondError(w, "Authorization header required", http.StatusUnauthorized)
+		return "", false
+	}
+
+	// Validate token by calling Supabase user endpoint
+	reqUser, _ := http.NewRequest("GET", os.Getenv("SUPABASE_URL")+"/auth/v1/user", nil)
+	reqUser.Header.Set("apikey", os.Getenv("SUPABASE_ANON_KEY"))
+	reqUser.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(reqUser)
+	if err != nil {
+		respondError(w, "Failed to get user", http.StatusInternalServerError)
+		return "", false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respondError(w, "Invalid or expired token", http.StatusUnauthorized)
+		return "", false
+	}
+
+	var userResp struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&userResp); err != nil {
+		respondError(w, "Invalid response from auth", http.StatusInternalServerError)
+		return "", false
+	}
+	return userResp.ID, true
+}
+
+// optionalUser returns the caller's user ID, or "" for anonymous callers and
+// invalid tokens.
+func optionalUser(r *http.Request) string {
+	token := r.Header.Get("Authorization")
+	if len(token) > 7 && token[:7] == "Bearer " {
+		token = token[7:]
+	}
+	if token == "" {
+		return ""
+	}
+
+	reqUser, _ := http.NewRequest("GET", os.Getenv("SUPABASE_URL")+"/auth/v1/user", nil)
+	reqUser.Header.Set("apikey", os.Getenv("SUPABASE_ANON_KEY"))
+	reqUser.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(reqUser)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	var userResp struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&userResp); err != nil {
+		return ""
+	}
+	return userResp.ID
 }
 
 func bidHandler(c *auth.Client, pg *pgxpool.Pool, rdb *redis.Client) http.HandlerFunc {
@@ -77,9 +228,14 @@ func bidHandler(c *auth.Client, pg *pgxpool.Pool, rdb *redis.Client) http.Handle
 		}
 
 		userID := userResp.ID
+		fraud.RecordFingerprint(fraud.FingerprintFromRequest(userID, r))
+		if !checkBidding(w, userID) {
+			return
+		}
 
 		var req struct {
-			Amount float64 `json:"amount"`
+			Amount   money.Money `json:"amount"`
+			Quantity int         `json:"quantity"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			respondError(w, "Invalid request body", http.StatusBadRequest)
@@ -95,15 +251,102 @@ func bidHandler(c *auth.Client, pg *pgxpool.Pool, rdb *redis.Client) http.Handle
 			return
 		}
 
-		// 2. Process Bid
-		if err := db.ProcessBidWithTx(ctx, rdb, auctionID, userID, req.Amount); err != nil {
+		// 2. Process Bid, sealed auctions on their own engine path
+		auctionType, err := db.AuctionType(ctx, rdb, auctionID)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if listing.AuctionType(auctionType) == listing.Dutch {
+			respondError(w, "Dutch auctions are bought by accepting the current price", http.StatusBadRequest)
+			return
+		}
+		units, err := db.AuctionUnits(ctx, rdb, auctionID)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sealed := listing.AuctionType(auctionType).Sealed()
+		var result db.BidResult
+		switch {
+		case units > 1:
+			result, err = db.ProcessMultiUnitBidWithTx(ctx, rdb, auctionID, userID, req.Amount, max(req.Quantity, 1))
+		case sealed:
+			result, err = db.ProcessSealedBidWithTx(ctx, rdb, auctionID, userID, req.Amount)
+		default:
+			result, err = db.ProcessBidWithTx(ctx, rdb, auctionID, userID, req.Amount)
+		}
+		if errors.Is(err, db.ErrSelfBid) {
+			respondError(w, err.Error(), http.StatusForbidden)
+			return
+		} else if errors.Is(err, db.ErrSellerUnknown) {
+			respondError(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		} else if err != nil {
 			// If error, return 400 Bad Request
 			respondError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		respondJSON(w, map[string]interface{}{
-			"message": "Bid placed successfully",
-		})
+		// 3. Record the accepted bid in the ledger. Settlement reads the
+		// ledger, so a bid that isn't recorded is taken back out of the cache
+		if err := ledger.Record(ledger.Bid{
+			ListingID:   auctionID,
+			UserID:      userID,
+			BidAmount:   result.Amount,
+			BidSequence: result.Sequence,
+			Quantity:    quantityFor(units, req.Quantity),
+		}); err != nil {
+			log.Printf("Error recording bid %d on %s: %v", result.Sequence, auctionID, err)
+			if err := db.UndoBid(context.WithoutCancel(ctx), rdb, auctionID, userID, result); err != nil {
+				log.Printf("Warning: unrecorded bid %d on %s not rolled back: %v", result.Sequence, auctionID, err)
+			}
+			respondError(w, "Failed to record bid, please try again", http.StatusInternalServerError)
+			return
+		}
+		if result.Revised {
+			if err := ledger.Supersede(auctionID, userID, result.Sequence); err != nil {
+				log.Printf("Warning: earlier sealed bids on %s not superseded: %v", auctionID, err)
+			}
+		}
+
+		// 4. Let the previous leader, or multi-unit bidders who lost units,
+		// know they were outbid
+		outbid := result.Outbid
+		if result.PreviousBidder != "" && result.PreviousBidder != userID {
+			outbid = append(outbid, result.PreviousBidder)
+		}
+		for _, id := range outbid {
+			events.Publish(events.Event{
+				Type:      events.Outbid,
+				UserID:    id,
+				ListingID: auctionID,
+				Data:      map[string]string{"amount": result.Amount.String()},
+			})
+		}
+
+		out := map[string]interface{}{
+			"message":  "Bid placed successfully",
+			"amount":   result.Amount,
+			"currency": result.Amount.Currency,
+		}
+		if sealed {
+			out["sealed"] = true
+			out["revised"] = result.Revised
+		}
+		if units > 1 {
+			out["quantity"] = quantityFor(units, req.Quantity)
+			out["revised"] = result.Revised
+		}
+		respondJSON(w, out)
+	}
+}
+
+// quantityFor is the number of units a bid is for, or 0 on single-unit
+// auctions.
+func quantityFor(units, requested int) int {
+	if units <= 1 {
+		return 0
 	}
+	return max(requested, 1)
 }
@@ -58,3 +58,21 @@ func TestBidHandler(t *testing.T) {
 	}()
 	handler.ServeHTTP(rr2, req2)
 }
+
+func TestNewRouterRateLimitsLogin(t *testing.T) {
+	handler := NewRouter(nil, nil, nil)
+
+	var last *httptest.ResponseRecorder
+	for i := 0; i < 11; i++ {
+		req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer([]byte(`{}`)))
+		req.RemoteAddr = "192.0.2.1:1234"
+		last = httptest.NewRecorder()
+		handler.ServeHTTP(last, req)
+	}
+	if last.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 Too Many Requests after 10 logins, got %d", last.Code)
+	}
+	if last.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected Retry-After header")
+	}
+}
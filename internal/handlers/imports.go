@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/bulkimport"
+)
+
+// maxImportBytes caps the size of an uploaded import file.
+const maxImportBytes = 5 << 20
+
+// importOptions reads the ?dry_run=, ?ends_at= and ?stagger_minutes= query
+// parameters of an import.
+func importOptions(r *http.Request) (bulkimport.Options, error) {
+	q := r.URL.Query()
+	opts := bulkimport.Options{DryRun: q.Get("dry_run") == "true"}
+	if v := q.Get("ends_at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return opts, fmt.Errorf("Invalid ends_at format (must be RFC3339)")
+		}
+		opts.EndsAt = t
+	}
+	if v := q.Get("stagger_minutes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 24*60 {
+			return opts, fmt.Errorf("stagger_minutes must be between 0 and 1440")
+		}
+		opts.Stagger = time.Duration(n) * time.Minute
+	}
+	return opts, nil
+}
+
+// importListingsHandler creates listings in bulk from a CSV or JSON lines
+// body. The format comes from ?format= or the Content-Type. Dry runs return
+// the validation report straight away; real imports return the job and carry
+// on in the background, creating every valid row.
+func importListingsHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		formatName := r.URL.Query().Get("format")
+		if formatName == "" {
+			formatName = r.Header.Get("Content-Type")
+		}
+		format, err := bulkimport.ParseFormat(formatName)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts, err := importOptions(r)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rows, err := bulkimport.Parse(http.MaxBytesReader(w, r.Body, maxImportBytes), format)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		job, built, err := bulkimport.Start(userID, format, rows, opts)
+		if err != nil {
+			respondError(w, "Failed to start import", http.StatusInternalServerError)
+			return
+		}
+		if opts.DryRun {
+			respondJSON(w, job)
+			return
+		}
+
+		// Respond before the job starts changing underneath the encoder
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+
+		go bulkimport.Run(context.Background(), job, built)
+	}
+}
+
+// importJobHandler reports the progress of one of the caller's imports.
+func importJobHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		job, err := bulkimport.Get(userID, r.PathValue("id"))
+		if errors.Is(err, bulkimport.ErrNotFound) {
+			respondError(w, "Import job not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch import job", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, job)
+	}
+}
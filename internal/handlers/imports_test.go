@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+func setupImportsMockServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "user123", "email": "test@example.com"}`))
+		case "/rest/v1/import_jobs":
+			w.WriteHeader(http.StatusOK)
+			if r.URL.Query().Get("id") == "eq.job1" {
+				w.Write([]byte(`[{"id": "job1", "seller_id": "user123", "status": "running", "total": 2, "succeeded": 1}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestImportListingsHandlerDryRun(t *testing.T) {
+	ts := setupImportsMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	body := `{"title": "Desk", "description": "Oak", "category": "home", "images": ["a.jpg"], "starting_bid": 40, "location": "Gainesville, FL"}
+{"title": "Lamp"}
+`
+	req := httptest.NewRequest("POST", "/api/imports?format=jsonl&dry_run=true&ends_at=2050-01-01T00:00:00Z", bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	importListingsHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var report struct {
+		Total     int `json:"total"`
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+		Rows      []struct {
+			Status  string   `json:"status"`
+			Missing []string `json:"missing"`
+		} `json:"rows"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if report.Total != 2 || report.Succeeded != 1 || report.Failed != 1 {
+		t.Errorf("Expected one valid and one failed row, got %+v", report)
+	}
+	if report.Rows[1].Status != "failed" || len(report.Rows[1].Missing) == 0 {
+		t.Errorf("Expected the failed row to list missing fields, got %+v", report.Rows[1])
+	}
+
+	req = httptest.NewRequest("POST", "/api/imports?format=xml", bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr = httptest.NewRecorder()
+	importListingsHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported format, got %d", rr.Code)
+	}
+}
+
+func TestImportJobHandler(t *testing.T) {
+	ts := setupImportsMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/imports/{id}", importJobHandler(auth.NewClient(ts.URL, "anon")))
+
+	req := httptest.NewRequest("GET", "/api/imports/job1", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/imports/other", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rr.Code)
+	}
+}
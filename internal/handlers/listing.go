@@ -1,15 +1,22 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/geo"
+	"github.com/quickswap/quickswap/internal/ledger"
 
 	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/savedsearch"
+	"github.com/quickswap/quickswap/internal/watchlist"
 )
 
 func createListingHandler(authClient *auth.Client) http.HandlerFunc {
@@ -55,72 +62,19 @@ func createListingHandler(authClient *auth.Client) http.HandlerFunc {
 		}
 
 		// Parse and validate request body
-
-		var req struct {
-			Title            string   `json:"title"`
-			Subtitle         string   `json:"subtitle"`
-			Description      string   `json:"description"`
-			Category         string   `json:"category"`
-			Subcategory      string   `json:"subcategory"`
-			Condition        string   `json:"condition"`
-			Brand            string   `json:"brand"`
-			Color            string   `json:"color"`
-			Size             string   `json:"size"`
-			Images           []string `json:"images"`
-			StartingBid      float64  `json:"starting_bid"`
-			BuyNowPrice      *float64 `json:"buy_now_price,omitempty"`
-			AuctionStartTime string   `json:"auction_start_time"`
-			AuctionEndTime   string   `json:"auction_end_time"`
-			Location         string   `json:"location"`
-			Notes            string   `json:"notes"`
-		}
+		var req listing.Input
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			respondError(w, "Invalid JSON", http.StatusBadRequest)
 			return
 		}
 
-		// Validate required fields
-		if req.Title == "" || req.Description == "" || req.Category == "" || len(req.Images) == 0 || req.StartingBid == 0 || req.AuctionEndTime == "" || req.Location == "" {
-			respondError(w, "Missing required fields", http.StatusBadRequest)
-			return
-		}
-
-		auctionEnd, err := time.Parse(time.RFC3339, req.AuctionEndTime)
+		l, err := req.Build(userResp.ID) // Use ID from token
 		if err != nil {
-			respondError(w, "Invalid auction_end_time format (must be RFC3339)", http.StatusBadRequest)
+			respondError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		var auctionStart time.Time
-		if req.AuctionStartTime != "" {
-			auctionStart, err = time.Parse(time.RFC3339, req.AuctionStartTime)
-			if err != nil {
-				respondError(w, "Invalid auction_start_time format (must be RFC3339)", http.StatusBadRequest)
-				return
-			}
-		}
-
-		l := &listing.Listing{
-			Title:            req.Title,
-			Subtitle:         req.Subtitle,
-			Description:      req.Description,
-			Category:         req.Category,
-			Subcategory:      req.Subcategory,
-			Condition:        req.Condition,
-			Brand:            req.Brand,
-			Color:            req.Color,
-			Size:             req.Size,
-			Images:           req.Images,
-			StartingBid:      req.StartingBid,
-			BuyNowPrice:      req.BuyNowPrice,
-			AuctionStartTime: auctionStart,
-			AuctionEndTime:   auctionEnd,
-			Location:         req.Location,
-			Notes:            req.Notes,
-			SellerID:         userResp.ID, // Use ID from token
-		}
-
-		id, err := listing.CreateListing(l)
+		id, err := publishListing(r.Context(), l)
 		if err != nil {
 			respondError(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -134,6 +88,38 @@ func createListingHandler(authClient *auth.Client) http.HandlerFunc {
 	}
 }
 
+// publishListing stores a validated listing: it is geocoded, inserted and,
+// if its auction has already started, matched against saved searches.
+func publishListing(ctx context.Context, l *listing.Listing) (string, error) {
+	// Geocode for radius search; unknown places are listed without coordinates
+	if err := l.Geocode(ctx, geo.Default); err != nil {
+		log.Printf("Warning: could not geocode listing location: %v", err)
+	}
+
+	// Listings that are live now are matched against saved searches right
+	// away; scheduled ones are picked up when they start
+	liveNow := !l.AuctionStartTime.After(time.Now())
+	if liveNow {
+		now := time.Now().UTC()
+		l.SearchAlertedAt = &now
+	}
+
+	id, err := listing.CreateListing(l)
+	if err != nil {
+		return "", err
+	}
+
+	if liveNow {
+		l.ID = id
+		go func() {
+			if _, err := savedsearch.Notify(context.Background(), l); err != nil {
+				log.Printf("Warning: failed to match listing %s against saved searches: %v", id, err)
+			}
+		}()
+	}
+	return id, nil
+}
+
 // Handler to fetch all listings for the current logged-in user
 func myListingHandler(authClient *auth.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -219,16 +205,17 @@ func myListingHandler(authClient *auth.Client) http.HandlerFunc {
 			ListingID  string  `json:"listing_id"`
 			Title      string  `json:"title"`
 			Image      string  `json:"image"`
-			CurrentBid float64 `json:"current_bid"`
-			TimeLeft   string  `json:"time_left"`
-			TotalBids  int     `json:"total_bids"`
-			Status     string  `json:"status"`
+			CurrentBid money.Money    `json:"current_bid"`
+			Currency   money.Currency `json:"currency"`
+			TimeLeft   string         `json:"time_left"`
+			TotalBids  int            `json:"total_bids"`
+			Status     string         `json:"status"`
 		}
 
 		var summaries []ListingSummary
 		for _, l := range listingsArr {
 			// Fetch bids for this listing
-			bidsURL := supaURL + "/rest/v1/bids?listing_id=eq." + l.ID
+			bidsURL := supaURL + "/rest/v1/bids?listing_id=eq." + l.ID + "&" + ledger.Standing
 			reqBids, _ := http.NewRequest("GET", bidsURL, nil)
 			reqBids.Header.Set("apikey", apiKey)
 			reqBids.Header.Set("Authorization", "Bearer "+apiKey)
@@ -242,6 +229,7 @@ func myListingHandler(authClient *auth.Client) http.HandlerFunc {
 					Title:      l.Title,
 					Image:      "",
 					CurrentBid: l.StartingBid,
+					Currency:   l.Currency,
 					TimeLeft:   "Ended",
 					TotalBids:  0,
 					Status:     "Ended",
@@ -249,7 +237,7 @@ func myListingHandler(authClient *auth.Client) http.HandlerFunc {
 				continue
 			}
 			var bids []struct {
-				BidAmount float64 `json:"bid_amount"`
+				BidAmount money.Money `json:"bid_amount"`
 			}
 			if err := json.NewDecoder(respBids.Body).Decode(&bids); err != nil {
 				respBids.Body.Close()
@@ -258,6 +246,7 @@ func myListingHandler(authClient *auth.Client) http.HandlerFunc {
 					Title:      l.Title,
 					Image:      "",
 					CurrentBid: l.StartingBid,
+					Currency:   l.Currency,
 					TimeLeft:   "Ended",
 					TotalBids:  0,
 					Status:     "Ended",
@@ -269,10 +258,14 @@ func myListingHandler(authClient *auth.Client) http.HandlerFunc {
 			// Calculate max bid
 			maxBid := l.StartingBid
 			for _, b := range bids {
-				if b.BidAmount > maxBid {
-					maxBid = b.BidAmount
+				if amt := b.BidAmount.Round(l.Currency); amt.Cmp(maxBid) > 0 {
+					maxBid = amt
 				}
 			}
+			// Sellers don't see sealed bids before close either
+			if l.BidsHidden(time.Now()) {
+				maxBid = l.StartingBid
+			}
 			totalBids := len(bids)
 
 			// Calculate time left
@@ -300,6 +293,7 @@ func myListingHandler(authClient *auth.Client) http.HandlerFunc {
 				Title:      l.Title,
 				Image:      image,
 				CurrentBid: maxBid,
+				Currency:   l.Currency,
 				TimeLeft:   timeLeft,
 				TotalBids:  totalBids,
 				Status:     status,
@@ -401,7 +395,7 @@ func singleListingHandler(authClient *auth.Client) http.HandlerFunc {
 		}
 
 		// --- Fetch bids for this listing ---
-		bidsURL := supaURL + "/rest/v1/bids?listing_id=eq." + listingID
+		bidsURL := supaURL + "/rest/v1/bids?listing_id=eq." + listingID + "&" + ledger.Standing
 		reqBids, _ := http.NewRequest("GET", bidsURL, nil)
 		reqBids.Header.Set("apikey", apiKey)
 		reqBids.Header.Set("Authorization", "Bearer "+apiKey)
@@ -415,8 +409,10 @@ func singleListingHandler(authClient *auth.Client) http.HandlerFunc {
 		defer respBids.Body.Close()
 
 		var bids []struct {
-			UserID    string  `json:"user_id"`
-			BidAmount float64 `json:"bid_amount"`
+			UserID      string      `json:"user_id"`
+			BidAmount   money.Money `json:"bid_amount"`
+			Quantity    int         `json:"quantity"`
+			BidSequence int64       `json:"bid_sequence"`
 		}
 		if err := json.NewDecoder(respBids.Body).Decode(&bids); err != nil {
 			respondError(w, "Invalid bids response", http.StatusInternalServerError)
@@ -426,19 +422,80 @@ func singleListingHandler(authClient *auth.Client) http.HandlerFunc {
 		// --- Compute bid stats ---
 		currentBid := l.StartingBid
 		highestBidderID := ""
-		var callerLastBid *float64
+		var callerLastBid *money.Money
 
 		for _, b := range bids {
-			if b.BidAmount > currentBid {
-				currentBid = b.BidAmount
+			amt := b.BidAmount.Round(l.Currency)
+			if amt.Cmp(currentBid) > 0 {
+				currentBid = amt
 				highestBidderID = b.UserID
 			}
-			if b.UserID == callerID && (callerLastBid == nil || b.BidAmount > *callerLastBid) {
-				amt := b.BidAmount
+			if b.UserID == callerID && (callerLastBid == nil || amt.Cmp(*callerLastBid) > 0) {
 				callerLastBid = &amt
 			}
 		}
 
+		// Multi-quantity listings rank every standing bid; the price shown is
+		// what a new bid has to beat
+		var multi map[string]interface{}
+		if units := l.Units(); units > 1 {
+			unitBids := make([]listing.UnitBid, len(bids))
+			for i, b := range bids {
+				unitBids[i] = listing.UnitBid{UserID: b.UserID, Amount: b.BidAmount.Round(l.Currency), Quantity: b.Quantity, Sequence: b.BidSequence}
+			}
+			awards := listing.Allocate(units, l.Pricing, unitBids)
+			taken, callerUnits := 0, 0
+			for _, a := range awards {
+				taken += a.Quantity
+				if callerID != "" && a.UserID == callerID {
+					callerUnits = a.Quantity
+				}
+			}
+			currentBid = l.StartingBid
+			if toBeat, full := listing.PriceToBeat(units, unitBids); full {
+				currentBid = toBeat
+			}
+			highestBidderID = ""
+			multi = map[string]interface{}{
+				"quantity":             units,
+				"pricing":              l.Pricing,
+				"units_remaining":      units - taken,
+				"caller_units_winning": callerUnits,
+			}
+		}
+
+		// Dutch auctions show the scheduled asking price until someone accepts
+		var nextDrop *time.Time
+		if l.AuctionType == listing.Dutch && l.DutchSchedule != nil && len(bids) == 0 {
+			now := time.Now()
+			currentBid = l.AskingPrice(now)
+			if t := l.DutchSchedule.NextDrop(l.StartingBid, l.AuctionStartTime, now); !t.IsZero() && t.Before(l.AuctionEndTime) {
+				nextDrop = &t
+			}
+		}
+
+		// Sealed bids stay hidden until the auction closes; callers only see
+		// their own bid
+		hidden := l.BidsHidden(time.Now())
+		var shownBid interface{} = currentBid
+		if hidden {
+			shownBid = nil
+			highestBidderID = ""
+		}
+
+		// --- Fetch watchers ---
+		watchers, err := watchlist.Watchers(listingID)
+		if err != nil {
+			log.Printf("Warning: failed to fetch watchers for %s: %v", listingID, err)
+		}
+		isWatching := false
+		for _, id := range watchers {
+			if callerID != "" && id == callerID {
+				isWatching = true
+				break
+			}
+		}
+
 		// --- Compute time left ---
 		duration := time.Until(l.AuctionEndTime)
 		var timeLeft, status string
@@ -458,6 +515,21 @@ func singleListingHandler(authClient *auth.Client) http.HandlerFunc {
 			image = l.Images[0]
 		}
 
+		// --- Approximate prices in the caller's currency ---
+		amounts := map[string]money.Money{
+			"starting_bid": l.StartingBid,
+		}
+		if !hidden {
+			amounts["current_bid"] = currentBid
+		}
+		if l.BuyNowPrice != nil {
+			amounts["buy_now_price"] = *l.BuyNowPrice
+		}
+		if callerLastBid != nil {
+			amounts["caller_last_bid"] = *callerLastBid
+		}
+		converted := convertedAmounts(r, displayCurrency(r, callerID), amounts)
+
 		respondJSON(w, map[string]interface{}{
 			"listing_id":          l.ID,
 			"title":               l.Title,
@@ -467,9 +539,18 @@ func singleListingHandler(authClient *auth.Client) http.HandlerFunc {
 			"image":               image,
 			"seller_id":           l.SellerID,
 			"seller_name":         sellerName,
-			"current_bid":         currentBid,
+			"current_bid":         shownBid,
 			"starting_bid":        l.StartingBid,
 			"buy_now_price":       l.BuyNowPrice,
+			"currency":            l.Currency,
+			"auction_type":        l.AuctionType,
+			"bids_hidden":         hidden,
+			"multi_quantity":      multi,
+			"accepts_offers":      l.AcceptsOffers && len(bids) == 0,
+			"fulfilment":          l.Fulfilment,
+			"dutch_schedule":      l.DutchSchedule,
+			"next_price_drop_at":  nextDrop,
+			"converted":           converted,
 			"total_bids":          len(bids),
 			"time_left":           timeLeft,
 			"status":              status,
@@ -478,9 +559,15 @@ func singleListingHandler(authClient *auth.Client) http.HandlerFunc {
 			"has_joined":          callerLastBid != nil,
 			"is_highest_bidder":   callerID != "" && callerID == highestBidderID,
 			"caller_last_bid":     callerLastBid,
-			"location":            l.Location,
+			"location":            l.PublicLocation(),
 			"condition":           l.Condition,
 			"brand":               l.Brand,
+			"category":            l.Category,
+			"subcategory":         l.Subcategory,
+			"size":                l.Size,
+			"attributes":          l.Attributes,
+			"watchers":            len(watchers),
+			"is_watching":         isWatching,
 		})
 	}
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -77,3 +78,64 @@ func TestCreateListingHandler(t *testing.T) {
 		t.Errorf("Expected 400 Bad Request for missing fields: got %v", status)
 	}
 }
+
+func TestCreateListingHandlerValidatesCategory(t *testing.T) {
+	ts := setupListingMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	handler := createListingHandler(auth.NewClient(ts.URL, "anon"))
+
+	base := `"title": "Phone", "description": "Works", "images": ["a.jpg"], "starting_bid": 10,
+		"auction_end_time": "2050-01-01T00:00:00Z", "location": "Gainesville, FL"`
+	for name, body := range map[string]string{
+		"unknown category": `{` + base + `, "category": "cars"}`,
+		"missing storage":  `{` + base + `, "category": "electronics", "subcategory": "Mobile phones"}`,
+		"bad condition":    `{` + base + `, "category": "books", "condition": "destroyed"}`,
+	} {
+		req := httptest.NewRequest("POST", "/api/createlisting", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer validtoken")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rr.Code)
+		}
+	}
+}
+
+func TestSingleListingHandlerHidesSealedBids(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "user123"}`))
+		case "/rest/v1/listings":
+			w.Write([]byte(`[{"id": "list1", "title": "Watch", "seller_id": "seller1", "starting_bid": 100, "auction_type": "sealed_second_price", "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		case "/rest/v1/bids":
+			w.Write([]byte(`[{"user_id": "user456", "bid_amount": 500}, {"user_id": "user123", "bid_amount": 300}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("GET", "/api/listing?id=list1", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	singleListingHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["current_bid"] != nil || resp["bids_hidden"] != true {
+		t.Errorf("Expected the current bid to be hidden, got %v", resp["current_bid"])
+	}
+	if resp["caller_last_bid"] != float64(300) {
+		t.Errorf("Expected the caller's own bid of 300, got %v", resp["caller_last_bid"])
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/events"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/messaging"
+)
+
+// listingMessagesHandler serves the post-auction conversation between the
+// seller and the winning bidder: GET reads the thread, POST sends a message.
+func listingMessagesHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		listingID := r.PathValue("id")
+		if listingID == "" {
+			respondError(w, "Listing ID is required", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		l, err := listing.Get(listingID)
+		if errors.Is(err, listing.ErrNotFound) {
+			respondError(w, "Listing not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch listing", http.StatusInternalServerError)
+			return
+		}
+
+		counterpartyID, err := messaging.Counterparty(l, userID)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			var req struct {
+				Body string `json:"body"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				respondError(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+
+			msg, err := messaging.Send(l, userID, req.Body)
+			if errors.Is(err, messaging.ErrEmpty) {
+				respondError(w, err.Error(), http.StatusBadRequest)
+				return
+			} else if err != nil {
+				respondError(w, "Failed to send message", http.StatusInternalServerError)
+				return
+			}
+
+			preview := []rune(msg.Body)
+			if len(preview) > 140 {
+				preview = append(preview[:140], '…')
+			}
+			events.Publish(events.Event{
+				Type:      events.NewMessage,
+				UserID:    counterpartyID,
+				ListingID: l.ID,
+				Data:      map[string]string{"title": l.Title, "preview": string(preview)},
+			})
+
+			respondJSON(w, map[string]interface{}{"message": msg})
+			return
+		}
+
+		thread, err := messaging.Thread(l.ID)
+		if err != nil {
+			respondError(w, "Failed to fetch messages", http.StatusInternalServerError)
+			return
+		}
+		if thread == nil {
+			thread = []messaging.Message{}
+		}
+
+		unread := 0
+		for _, m := range thread {
+			if m.RecipientID == userID && !m.Read {
+				unread++
+			}
+		}
+		if unread > 0 {
+			if err := messaging.MarkThreadRead(l.ID, userID); err != nil {
+				respondError(w, "Failed to update messages", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		respondJSON(w, map[string]interface{}{
+			"listing_id":      l.ID,
+			"counterparty_id": counterpartyID,
+			"messages":        thread,
+			"unread_count":    unread,
+		})
+	}
+}
+
+// unreadMessagesHandler returns the caller's unread message counts per listing.
+func unreadMessagesHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		counts, err := messaging.UnreadCounts(userID)
+		if err != nil {
+			respondError(w, "Failed to fetch unread messages", http.StatusInternalServerError)
+			return
+		}
+
+		total := 0
+		for _, n := range counts {
+			total += n
+		}
+		respondJSON(w, map[string]interface{}{
+			"unread":       counts,
+			"unread_total": total,
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+func setupMessagesMockServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "user123", "email": "test@example.com"}`))
+		case "/rest/v1/listings":
+			w.WriteHeader(http.StatusOK)
+			if r.URL.Query().Get("id") == "eq.open" {
+				w.Write([]byte(`[{"id": "open", "title": "Open Listing", "seller_id": "seller1", "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+				return
+			}
+			w.Write([]byte(`[{"id": "list1", "title": "Test Listing", "seller_id": "seller1", "auction_end_time": "2020-01-01T00:00:00Z",
+				"status": "sold", "winner_id": "user123", "settled_at": "2020-01-01T00:01:00Z"}]`))
+		case "/rest/v1/messages":
+			switch r.Method {
+			case "GET":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[{"id": "m1", "listing_id": "list1", "sender_id": "seller1", "recipient_id": "user123", "body": "Hi!", "read": false}]`))
+			case "POST":
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`[{"id": "m2", "listing_id": "list1", "sender_id": "user123", "recipient_id": "seller1", "body": "Hello"}]`))
+			case "PATCH":
+				w.WriteHeader(http.StatusNoContent)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestListingMessagesHandler(t *testing.T) {
+	ts := setupMessagesMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	c := auth.NewClient(ts.URL, "anon")
+	handler := listingMessagesHandler(c)
+
+	req1 := httptest.NewRequest("GET", "/api/listings/list1/messages", nil)
+	req1.SetPathValue("id", "list1")
+	rr1 := httptest.NewRecorder()
+	handler.ServeHTTP(rr1, req1)
+	if rr1.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 Unauthorized, got %d", rr1.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/api/listings/list1/messages", nil)
+	req.SetPathValue("id", "list1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	req2 := httptest.NewRequest("POST", "/api/listings/list1/messages", bytes.NewBuffer([]byte(`{"body": "Hello"}`)))
+	req2.SetPathValue("id", "list1")
+	req2.Header.Set("Authorization", "Bearer validtoken")
+	rr2 := httptest.NewRecorder()
+	handler.ServeHTTP(rr2, req2)
+	if status := rr2.Code; status != http.StatusOK {
+		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	req3 := httptest.NewRequest("GET", "/api/listings/open/messages", nil)
+	req3.SetPathValue("id", "open")
+	req3.Header.Set("Authorization", "Bearer validtoken")
+	rr3 := httptest.NewRecorder()
+	handler.ServeHTTP(rr3, req3)
+	if rr3.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 Forbidden before the auction closes, got %d", rr3.Code)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/notifications"
+)
+
+// notificationsHandler returns the caller's in-app inbox.
+func notificationsHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		unreadOnly := r.URL.Query().Get("unread") == "true"
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit <= 0 || limit > 100 {
+			limit = 50
+		}
+
+		inbox, err := notifications.Inbox(userID, unreadOnly, limit)
+		if err != nil {
+			respondError(w, "Failed to fetch notifications", http.StatusInternalServerError)
+			return
+		}
+		if inbox == nil {
+			inbox = []notifications.Notification{}
+		}
+
+		unread := 0
+		if unreadOnly {
+			unread = len(inbox)
+		} else if unread, err = notifications.UnreadCount(userID); err != nil {
+			respondError(w, "Failed to fetch notifications", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, map[string]interface{}{
+			"notifications": inbox,
+			"unread_count":  unread,
+		})
+	}
+}
+
+// markNotificationReadHandler marks a single notification as read.
+func markNotificationReadHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if id == "" {
+			respondError(w, "Notification ID is required", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		if err := notifications.MarkRead(userID, id); err != nil {
+			respondError(w, "Failed to update notification", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, map[string]string{"message": "Notification marked as read"})
+	}
+}
+
+// markAllNotificationsReadHandler marks the caller's whole inbox as read.
+func markAllNotificationsReadHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		if err := notifications.MarkAllRead(userID); err != nil {
+			respondError(w, "Failed to update notifications", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, map[string]string{"message": "All notifications marked as read"})
+	}
+}
+
+// notificationPreferencesHandler reads (GET) or replaces (POST) the caller's
+// notification preferences.
+func notificationPreferencesHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			prefs, err := notifications.GetPreferences(userID)
+			if err != nil {
+				respondError(w, "Failed to fetch preferences", http.StatusInternalServerError)
+				return
+			}
+			respondJSON(w, prefs)
+			return
+		}
+
+		var prefs notifications.Preferences
+		if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		prefs.UserID = userID
+		if prefs.Webhook && prefs.WebhookURL == "" {
+			respondError(w, "webhook_url is required when webhook notifications are enabled", http.StatusBadRequest)
+			return
+		}
+		if prefs.WebhookURL != "" {
+			if err := notifications.ValidateWebhookURL(r.Context(), prefs.WebhookURL); err != nil {
+				respondError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := notifications.SavePreferences(prefs); err != nil {
+			respondError(w, "Failed to save preferences", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, prefs)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+func setupNotificationsMockServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "user123", "email": "test@example.com"}`))
+		case "/rest/v1/notifications":
+			switch r.Method {
+			case "GET":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[{"id": "n1", "user_id": "user123", "type": "outbid", "title": "You've been outbid", "read": false}]`))
+			case "PATCH":
+				w.WriteHeader(http.StatusNoContent)
+			}
+		case "/rest/v1/notification_preferences":
+			switch r.Method {
+			case "GET":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[]`))
+			case "POST":
+				w.WriteHeader(http.StatusCreated)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestNotificationsHandler(t *testing.T) {
+	ts := setupNotificationsMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	c := auth.NewClient(ts.URL, "anon")
+	handler := notificationsHandler(c)
+
+	req1 := httptest.NewRequest("GET", "/api/notifications", nil)
+	rr1 := httptest.NewRecorder()
+	handler.ServeHTTP(rr1, req1)
+	if rr1.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 Unauthorized, got %d", rr1.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/api/notifications", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var body struct {
+		Notifications []map[string]interface{} `json:"notifications"`
+		UnreadCount   int                      `json:"unread_count"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if len(body.Notifications) != 1 || body.UnreadCount != 1 {
+		t.Errorf("Expected 1 unread notification, got %+v", body)
+	}
+}
+
+func TestMarkNotificationReadHandler(t *testing.T) {
+	ts := setupNotificationsMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	c := auth.NewClient(ts.URL, "anon")
+
+	req := httptest.NewRequest("POST", "/api/notifications/n1/read", nil)
+	req.SetPathValue("id", "n1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	markNotificationReadHandler(c).ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+}
+
+func TestNotificationPreferencesHandler(t *testing.T) {
+	ts := setupNotificationsMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	c := auth.NewClient(ts.URL, "anon")
+	handler := notificationPreferencesHandler(c)
+
+	req := httptest.NewRequest("GET", "/api/notifications/preferences", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	req2 := httptest.NewRequest("POST", "/api/notifications/preferences", bytes.NewBuffer([]byte(`{"in_app": true, "webhook": true}`)))
+	req2.Header.Set("Authorization", "Bearer validtoken")
+	rr2 := httptest.NewRecorder()
+	handler.ServeHTTP(rr2, req2)
+	if rr2.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 Bad Request for missing webhook_url, got %d", rr2.Code)
+	}
+
+	req3 := httptest.NewRequest("POST", "/api/notifications/preferences", bytes.NewBuffer([]byte(`{"in_app": true, "email": false, "muted": ["outbid"]}`)))
+	req3.Header.Set("Authorization", "Bearer validtoken")
+	rr3 := httptest.NewRecorder()
+	handler.ServeHTTP(rr3, req3)
+	if status := rr3.Code; status != http.StatusOK {
+		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+}
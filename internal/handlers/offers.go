@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/db"
+	"github.com/quickswap/quickswap/internal/events"
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/offers"
+	"github.com/quickswap/quickswap/internal/secondchance"
+	"github.com/redis/go-redis/v9"
+)
+
+// makeOfferHandler lets a buyer make a private offer on a listing in
+// best-offer mode. Offers close once bidding has started.
+func makeOfferHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listingID := r.PathValue("id")
+		if listingID == "" {
+			respondError(w, "Listing ID is required", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok || !checkBidding(w, userID) {
+			return
+		}
+
+		var req struct {
+			Amount  money.Money `json:"amount"`
+			Message string      `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		l, err := listing.Get(listingID)
+		if errors.Is(err, listing.ErrNotFound) {
+			respondError(w, "Listing not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch listing", http.StatusInternalServerError)
+			return
+		}
+
+		bids, err := ledger.Active(listingID, l.Currency)
+		if err != nil {
+			respondError(w, "Failed to fetch bids", http.StatusInternalServerError)
+			return
+		}
+		if len(bids) > 0 {
+			respondError(w, "Offers close once bidding starts", http.StatusConflict)
+			return
+		}
+
+		o, err := offers.Make(l, userID, req.Amount, strings.TrimSpace(req.Message), time.Now().UTC())
+		switch {
+		case errors.Is(err, offers.ErrNotAllowed), errors.Is(err, offers.ErrInvalid):
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, offers.ErrOpenOffer):
+			respondError(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			respondError(w, "Failed to make offer", http.StatusInternalServerError)
+			return
+		}
+
+		publishOffer(events.OfferReceived, l.SellerID, l, o.Amount, o.ExpiresAt)
+		respondJSON(w, map[string]interface{}{"offer": o})
+	}
+}
+
+// myOffersHandler lists the offers the caller has made and received, and
+// second-chance offers they have sent or been sent.
+func myOffersHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		made, err := offers.ForBuyer(userID)
+		if err != nil {
+			respondError(w, "Failed to fetch offers", http.StatusInternalServerError)
+			return
+		}
+		received, err := offers.ForSeller(userID)
+		if err != nil {
+			respondError(w, "Failed to fetch offers", http.StatusInternalServerError)
+			return
+		}
+		secondChance, err := secondchance.ForUser(userID)
+		if err != nil {
+			respondError(w, "Failed to fetch offers", http.StatusInternalServerError)
+			return
+		}
+		if made == nil {
+			made = []offers.Offer{}
+		}
+		if received == nil {
+			received = []offers.Offer{}
+		}
+		if secondChance == nil {
+			secondChance = []secondchance.Offer{}
+		}
+		respondJSON(w, map[string]interface{}{"made": made, "received": received, "second_chance": secondChance})
+	}
+}
+
+// respondOfferHandler accepts, counters or declines an offer. Sellers answer
+// offers and buyers answer counters. Acceptance claims the auction in Redis,
+// which fails if someone has bid in the meantime, and ends it.
+func respondOfferHandler(authClient *auth.Client, pg *pgxpool.Pool, rdb *redis.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offerID, action := r.PathValue("id"), r.PathValue("action")
+		if offerID == "" {
+			respondError(w, "Offer ID is required", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Amount *money.Money `json:"amount"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		o, err := offers.Get(offerID)
+		if errors.Is(err, offers.ErrNotFound) {
+			respondError(w, "Offer not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch offer", http.StatusInternalServerError)
+			return
+		}
+		if userID != o.BuyerID && userID != o.SellerID {
+			respondError(w, "Offer not found", http.StatusNotFound)
+			return
+		}
+
+		now := time.Now().UTC()
+		status, err := o.Next(userID, action, now)
+		switch {
+		case errors.Is(err, offers.ErrForbidden):
+			respondError(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, offers.ErrNotOpen):
+			respondError(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// A buyer accepting a counter is buying, so strikes apply
+		if status == offers.Accepted && userID == o.BuyerID && !checkBidding(w, userID) {
+			return
+		}
+		if status == offers.Accepted && rdb == nil {
+			respondError(w, "Bidding is unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		l, err := listing.Get(o.ListingID)
+		if err != nil {
+			respondError(w, "Failed to fetch listing", http.StatusInternalServerError)
+			return
+		}
+
+		price := o.Price()
+		prev := *o
+		if err := offers.Respond(o, l, status, req.Amount, now); err != nil {
+			switch {
+			case errors.Is(err, offers.ErrInvalid):
+				respondError(w, err.Error(), http.StatusBadRequest)
+			case errors.Is(err, offers.ErrNotOpen):
+				respondError(w, err.Error(), http.StatusConflict)
+			default:
+				respondError(w, "Failed to update offer", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		// The other party hears about the answer
+		other := o.BuyerID
+		if userID == o.BuyerID {
+			other = o.SellerID
+		}
+
+		switch status {
+		case offers.Accepted:
+			// Failures that may clear up put the offer back so it can be retried
+			revert := func() {
+				if err := offers.Revert(o, prev); err != nil {
+					log.Printf("Warning: failed to reopen offer %s: %v", o.ID, err)
+				}
+			}
+			ctx := r.Context()
+			if err := db.EnsureAuctionCached(ctx, rdb, pg, l.ID); err != nil {
+				log.Printf("Error caching auction %s: %v", l.ID, err)
+				revert()
+				respondError(w, "Auction not found or error loading auction", http.StatusNotFound)
+				return
+			}
+			result, err := db.ClaimAuction(ctx, rdb, l.ID, o.BuyerID, price)
+			if errors.Is(err, db.ErrSellerUnknown) {
+				revert()
+				respondError(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			} else if err != nil {
+				// Bids or another sale got there first; the offer can't stand
+				if err := offers.Respond(o, l, offers.Closed, nil, now); err != nil {
+					log.Printf("Warning: failed to close offer %s: %v", o.ID, err)
+				}
+				if errors.Is(err, db.ErrSold) {
+					respondError(w, "The auction already has bids or a buyer", http.StatusConflict)
+				} else {
+					respondError(w, err.Error(), http.StatusBadRequest)
+				}
+				return
+			}
+			if err := finishClaim(ctx, rdb, l.ID, o.BuyerID, result, now); err != nil {
+				log.Printf("Error finishing claim on %s: %v", l.ID, err)
+				revert()
+				respondError(w, "Failed to record sale, please try again", http.StatusInternalServerError)
+				return
+			}
+			if err := offers.CloseOthers(l.ID, o.ID, now); err != nil {
+				log.Printf("Warning: failed to close other offers on %s: %v", l.ID, err)
+			}
+			publishOffer(events.OfferAccepted, other, l, price, time.Time{})
+		case offers.Countered:
+			publishOffer(events.OfferCountered, other, l, *o.CounterAmount, o.ExpiresAt)
+		case offers.Declined:
+			publishOffer(events.OfferDeclined, other, l, price, time.Time{})
+		}
+
+		respondJSON(w, map[string]interface{}{"offer": o})
+	}
+}
+
+func publishOffer(t events.Type, userID string, l *listing.Listing, amount money.Money, expiresAt time.Time) {
+	data := map[string]string{"title": l.Title, "amount": amount.String()}
+	if !expiresAt.IsZero() {
+		data["expires_at"] = expiresAt.Format(time.RFC1123)
+	}
+	events.Publish(events.Event{Type: t, UserID: userID, ListingID: l.ID, Data: data})
+}
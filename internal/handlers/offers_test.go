@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+func setupOffersMockServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "seller1"}`))
+		case "/rest/v1/listings":
+			w.Write([]byte(`[{"id": "list1", "title": "Lamp", "seller_id": "seller1", "starting_bid": 20, "accepts_offers": true, "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		case "/rest/v1/bids":
+			w.Write([]byte(`[]`))
+		case "/rest/v1/bidder_strikes":
+			w.Write([]byte(`[]`))
+		case "/rest/v1/offers":
+			switch r.Method {
+			case "PATCH":
+				w.Write([]byte(`[{"id": "o1", "listing_id": "list1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 15, "status": "declined", "expires_at": "2049-01-01T00:00:00Z"}]`))
+			default:
+				w.Write([]byte(`[{"id": "o1", "listing_id": "list1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 15, "status": "pending", "expires_at": "2049-01-01T00:00:00Z"}]`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestMakeOfferHandler(t *testing.T) {
+	ts := setupOffersMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	// The caller is the seller, who can't make offers on their own listing
+	req := httptest.NewRequest("POST", "/api/listings/list1/offers", bytes.NewBufferString(`{"amount": 15}`))
+	req.SetPathValue("id", "list1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	makeOfferHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an offer on your own listing, got %d", rr.Code)
+	}
+}
+
+func TestRespondOfferHandler(t *testing.T) {
+	ts := setupOffersMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	handler := respondOfferHandler(auth.NewClient(ts.URL, "anon"), nil, nil)
+
+	respond := func(action, body string) int {
+		req := httptest.NewRequest("POST", "/api/offers/o1/"+action, bytes.NewBufferString(body))
+		req.SetPathValue("id", "o1")
+		req.SetPathValue("action", action)
+		req.Header.Set("Authorization", "Bearer validtoken")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := respond("counter", `{"amount": 10}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a counter below the offer, got %d", code)
+	}
+	if code := respond("decline", ``); code != http.StatusOK {
+		t.Errorf("Expected 200 declining an offer, got %d", code)
+	}
+	if code := respond("accept", ``); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 accepting without Redis, got %d", code)
+	}
+}
+
+func TestRespondOfferHandlerRestrictsStruckBuyers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "buyer1"}`))
+		case "/rest/v1/offers":
+			w.Write([]byte(`[{"id": "o1", "listing_id": "list1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 15, "counter_amount": 18, "status": "countered", "expires_at": "2049-01-01T00:00:00Z"}]`))
+		case "/rest/v1/bidder_strikes":
+			w.Write([]byte(`[{"id": "s1"}, {"id": "s2"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("POST", "/api/offers/o1/accept", nil)
+	req.SetPathValue("id", "o1")
+	req.SetPathValue("action", "accept")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	respondOfferHandler(auth.NewClient(ts.URL, "anon"), nil, nil).ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a struck buyer accepting a counter, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/fulfilment"
+	"github.com/quickswap/quickswap/internal/geo"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/payments"
+)
+
+// maxWebhookBody caps provider callback payloads.
+const maxWebhookBody = 1 << 20
+
+// myOrdersHandler lists the orders the caller is paying for and being paid for.
+func myOrdersHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		buying, err := payments.ForBuyer(userID)
+		if err != nil {
+			respondError(w, "Failed to fetch orders", http.StatusInternalServerError)
+			return
+		}
+		selling, err := payments.ForSeller(userID)
+		if err != nil {
+			respondError(w, "Failed to fetch orders", http.StatusInternalServerError)
+			return
+		}
+		if buying == nil {
+			buying = []payments.Order{}
+		}
+		if selling == nil {
+			selling = []payments.Order{}
+		}
+		respondJSON(w, map[string]interface{}{"buying": buying, "selling": selling})
+	}
+}
+
+// orderHandler returns one order to its buyer or seller.
+func orderHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		o, ok := partyOrder(w, r.PathValue("id"), userID)
+		if !ok {
+			return
+		}
+		respondJSON(w, map[string]interface{}{"order": o})
+	}
+}
+
+// checkoutHandler starts the buyer's payment for an order and returns what
+// the client needs to complete it with the provider. When the listing offers
+// pickup or shipping the buyer chooses one here, and shipping costs are added
+// to the amount charged.
+func checkoutHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Fulfilment *fulfilment.Choice `json:"fulfilment"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		o, ok := partyOrder(w, r.PathValue("id"), userID)
+		if !ok {
+			return
+		}
+		if o.BuyerID != userID {
+			respondError(w, "Only the buyer can pay for this order", http.StatusForbidden)
+			return
+		}
+		l, err := listing.Get(o.ListingID)
+		if err != nil {
+			respondError(w, "Failed to fetch listing", http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now().UTC()
+		if req.Fulfilment != nil {
+			if err := fulfilment.Choose(r.Context(), o, l, userID, *req.Fulfilment, geo.Default, now); err != nil {
+				respondOrderError(w, err)
+				return
+			}
+		}
+		if l.Fulfilment != nil && o.Method == "" {
+			respondError(w, "Choose pickup or shipping before paying", http.StatusBadRequest)
+			return
+		}
+
+		intent, err := payments.Checkout(r.Context(), payments.Default, o, userID, now)
+		if err != nil {
+			respondOrderError(w, err)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"order": o, "payment": intent})
+	}
+}
+
+// shipOrderHandler lets the seller add tracking to a shipped order, which
+// starts the automatic release timeout.
+func shipOrderHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Carrier        string `json:"carrier"`
+			TrackingNumber string `json:"tracking_number"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		o, ok := partyOrder(w, r.PathValue("id"), userID)
+		if !ok {
+			return
+		}
+		if err := fulfilment.Ship(o, userID, req.Carrier, req.TrackingNumber, time.Now().UTC()); err != nil {
+			respondOrderError(w, err)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"order": o})
+	}
+}
+
+// confirmDeliveryHandler lets the buyer confirm they have the item, which
+// releases escrow to the seller.
+func confirmDeliveryHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		o, ok := partyOrder(w, r.PathValue("id"), userID)
+		if !ok {
+			return
+		}
+		if err := fulfilment.ConfirmDelivery(r.Context(), payments.Default, o, userID, time.Now().UTC()); err != nil {
+			respondOrderError(w, err)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"order": o})
+	}
+}
+
+// respondOrderError maps payments and fulfilment errors to responses.
+func respondOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, payments.ErrForbidden):
+		respondError(w, "You can't do that on this order", http.StatusForbidden)
+	case errors.Is(err, payments.ErrState), errors.Is(err, payments.ErrOverdue), errors.Is(err, payments.ErrDisputed):
+		respondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, fulfilment.ErrInvalid), errors.Is(err, fulfilment.ErrNotOffered):
+		respondError(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Order request failed: %v", err)
+		respondError(w, "Payment provider request failed", http.StatusBadGateway)
+	}
+}
+
+// paymentWebhookHandler receives payment provider callbacks. Deliveries are
+// authenticated by their signature rather than a user token.
+func paymentWebhookHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+		if err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now().UTC()
+		e, err := payments.Default.ParseWebhook(payload, r.Header, now)
+		if errors.Is(err, payments.ErrSignature) {
+			respondError(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = payments.HandleWebhook(e, now)
+		if errors.Is(err, payments.ErrNotFound) {
+			respondError(w, "Order not found", http.StatusNotFound)
+			return
+		} else if errors.Is(err, payments.ErrMismatch) {
+			// Redelivery won't fix it, so acknowledge and leave it to support
+			log.Printf("Warning: payment webhook for order %s: %v", e.OrderID, err)
+		} else if err != nil && !errors.Is(err, payments.ErrState) {
+			log.Printf("Payment webhook for order %s failed: %v", e.OrderID, err)
+			respondError(w, "Failed to process webhook", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"received": true})
+	}
+}
+
+// partyOrder fetches an order the caller is the buyer or seller of, writing
+// the error response if there isn't one.
+func partyOrder(w http.ResponseWriter, orderID, userID string) (*payments.Order, bool) {
+	o, err := payments.Get(orderID)
+	if errors.Is(err, payments.ErrNotFound) || (err == nil && !o.Party(userID)) {
+		respondError(w, "Order not found", http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		respondError(w, "Failed to fetch order", http.StatusInternalServerError)
+		return nil, false
+	}
+	return o, true
+}
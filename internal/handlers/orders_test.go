@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/payments"
+)
+
+func setupOrdersMockServer(paid *bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "seller1"}`))
+		case "/rest/v1/orders":
+			if r.Method == "PATCH" {
+				*paid = true
+				w.Write([]byte(`[{"id": "o1", "listing_id": "list1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 40, "status": "paid", "due_at": "2049-01-01T00:00:00Z"}]`))
+				return
+			}
+			w.Write([]byte(`[{"id": "o1", "listing_id": "list1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 40, "status": "pending", "due_at": "2049-01-01T00:00:00Z"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestCheckoutHandler(t *testing.T) {
+	var paid bool
+	ts := setupOrdersMockServer(&paid)
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	// The caller is the seller, who can see the order but not pay for it
+	req := httptest.NewRequest("POST", "/api/orders/o1/checkout", nil)
+	req.SetPathValue("id", "o1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	checkoutHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for the seller paying, got %d", rr.Code)
+	}
+}
+
+func TestShipOrderHandler(t *testing.T) {
+	var paid bool
+	ts := setupOrdersMockServer(&paid)
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	// The order is still pending, so there is nothing to ship yet
+	req := httptest.NewRequest("POST", "/api/orders/o1/ship", bytes.NewBufferString(`{"carrier": "UPS", "tracking_number": "1Z999"}`))
+	req.SetPathValue("id", "o1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	shipOrderHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 shipping an unpaid order, got %d", rr.Code)
+	}
+}
+
+func TestPaymentWebhookHandler(t *testing.T) {
+	var paid bool
+	ts := setupOrdersMockServer(&paid)
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	fake := &payments.FakeProvider{Secret: "test"}
+	payments.Default = fake
+	payload := []byte(`{"type": "payment_succeeded", "payment_id": "fake_pi_o1", "order_id": "o1", "amount": 40, "currency": "USD"}`)
+
+	deliver := func(payload []byte, signature string) int {
+		req := httptest.NewRequest("POST", "/api/payments/webhook", bytes.NewReader(payload))
+		req.Header.Set(payments.FakeSignatureHeader, signature)
+		rr := httptest.NewRecorder()
+		paymentWebhookHandler().ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := deliver(payload, "00"); code != http.StatusUnauthorized || paid {
+		t.Errorf("Expected 401 and no payment for a bad signature, got %d (paid=%v)", code, paid)
+	}
+	short := []byte(`{"type": "payment_succeeded", "payment_id": "fake_pi_o1", "order_id": "o1", "amount": 0.5, "currency": "USD"}`)
+	if code := deliver(short, fake.Sign(short)); code != http.StatusOK || paid {
+		t.Errorf("Expected the underpayment acknowledged but the order left unpaid, got %d (paid=%v)", code, paid)
+	}
+	if code := deliver(payload, fake.Sign(payload)); code != http.StatusOK || !paid {
+		t.Errorf("Expected 200 and the order paid, got %d (paid=%v)", code, paid)
+	}
+}
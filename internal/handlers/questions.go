@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/events"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/questions"
+)
+
+// listingQuestionsHandler lists the public Q&A on a listing (GET) or asks a
+// new question (POST).
+func listingQuestionsHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		listingID := r.PathValue("id")
+		if listingID == "" {
+			respondError(w, "Listing ID is required", http.StatusBadRequest)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			qs, err := questions.ForListing(listingID)
+			if err != nil {
+				respondError(w, "Failed to fetch questions", http.StatusInternalServerError)
+				return
+			}
+			public, ok := publicQuestions(w, listingID, qs)
+			if !ok {
+				return
+			}
+			respondJSON(w, map[string]interface{}{"questions": public})
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Question string `json:"question"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		l, err := listing.Get(listingID)
+		if errors.Is(err, listing.ErrNotFound) {
+			respondError(w, "Listing not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch listing", http.StatusInternalServerError)
+			return
+		}
+
+		q, err := questions.Ask(l, userID, req.Question, time.Now().UTC())
+		switch {
+		case errors.Is(err, questions.ErrAuctionEnded), errors.Is(err, questions.ErrOwnListing):
+			respondError(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, questions.ErrInvalidText):
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			respondError(w, "Failed to post question", http.StatusInternalServerError)
+			return
+		}
+
+		public, ok := publicQuestions(w, l.ID, []questions.Question{*q})
+		if !ok {
+			return
+		}
+		respondJSON(w, map[string]interface{}{"question": public[0]})
+	}
+}
+
+// answerQuestionHandler lets the seller answer a question on their listing.
+// The asker and every bidder are notified of the answer.
+func answerQuestionHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listingID := r.PathValue("id")
+		questionID := r.PathValue("qid")
+		if listingID == "" || questionID == "" {
+			respondError(w, "Listing ID and question ID are required", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Answer string `json:"answer"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		l, err := listing.Get(listingID)
+		if errors.Is(err, listing.ErrNotFound) {
+			respondError(w, "Listing not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch listing", http.StatusInternalServerError)
+			return
+		}
+
+		q, err := questions.Answer(l, userID, questionID, req.Answer, time.Now().UTC())
+		switch {
+		case errors.Is(err, questions.ErrNotSeller):
+			respondError(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, questions.ErrNotFound):
+			respondError(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, questions.ErrAlreadyAnswered):
+			respondError(w, err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, questions.ErrInvalidText):
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			respondError(w, "Failed to post answer", http.StatusInternalServerError)
+			return
+		}
+
+		audience, err := questions.Audience(l.ID, q.AskerID)
+		if err != nil {
+			audience = []string{q.AskerID}
+		}
+		for _, uid := range audience {
+			events.Publish(events.Event{
+				Type:      events.QuestionAnswered,
+				UserID:    uid,
+				ListingID: l.ID,
+				Data:      map[string]string{"title": l.Title, "question": q.Question, "answer": q.Answer},
+			})
+		}
+
+		public, ok := publicQuestions(w, l.ID, []questions.Question{*q})
+		if !ok {
+			return
+		}
+		respondJSON(w, map[string]interface{}{"question": public[0]})
+	}
+}
+
+// publicQuestions masks the askers of qs, writing a 503 if handles can't be
+// derived.
+func publicQuestions(w http.ResponseWriter, listingID string, qs []questions.Question) ([]questions.PublicQuestion, bool) {
+	public, err := questions.Public(listingID, qs)
+	if err != nil {
+		log.Printf("Error masking askers on %s: %v", listingID, err)
+		respondError(w, "Questions are unavailable", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return public, true
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+func setupQuestionsMockServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "user123", "email": "test@example.com"}`))
+		case "/rest/v1/listings":
+			w.WriteHeader(http.StatusOK)
+			if r.URL.Query().Get("id") == "eq.ended" {
+				w.Write([]byte(`[{"id": "ended", "title": "Ended Listing", "seller_id": "seller1", "auction_end_time": "2020-01-01T00:00:00Z"}]`))
+				return
+			}
+			w.Write([]byte(`[{"id": "list1", "title": "Test Listing", "seller_id": "seller1", "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		case "/rest/v1/listing_questions":
+			switch r.Method {
+			case "GET":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[{"id": "q1", "listing_id": "list1", "asker_id": "user456", "question": "What size?"}]`))
+			case "POST":
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`[{"id": "q2", "listing_id": "list1", "asker_id": "user123", "question": "Any scratches?"}]`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestListingQuestionsHandler(t *testing.T) {
+	ts := setupQuestionsMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	os.Setenv("BIDDER_HANDLE_SECRET", "test-secret")
+
+	c := auth.NewClient(ts.URL, "anon")
+	handler := listingQuestionsHandler(c)
+
+	req := httptest.NewRequest("GET", "/api/listings/list1/questions", nil)
+	req.SetPathValue("id", "list1")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if strings.Contains(rr.Body.String(), "user456") || !strings.Contains(rr.Body.String(), `"asker":`) {
+		t.Errorf("Expected the asker shown as a handle, not their user ID: %s", rr.Body.String())
+	}
+
+	req1 := httptest.NewRequest("POST", "/api/listings/list1/questions", bytes.NewBuffer([]byte(`{"question": "Any scratches?"}`)))
+	req1.SetPathValue("id", "list1")
+	rr1 := httptest.NewRecorder()
+	handler.ServeHTTP(rr1, req1)
+	if rr1.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 Unauthorized, got %d", rr1.Code)
+	}
+
+	req2 := httptest.NewRequest("POST", "/api/listings/list1/questions", bytes.NewBuffer([]byte(`{"question": "Any scratches?"}`)))
+	req2.SetPathValue("id", "list1")
+	req2.Header.Set("Authorization", "Bearer validtoken")
+	rr2 := httptest.NewRecorder()
+	handler.ServeHTTP(rr2, req2)
+	if status := rr2.Code; status != http.StatusOK {
+		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	req3 := httptest.NewRequest("POST", "/api/listings/ended/questions", bytes.NewBuffer([]byte(`{"question": "Still available?"}`)))
+	req3.SetPathValue("id", "ended")
+	req3.Header.Set("Authorization", "Bearer validtoken")
+	rr3 := httptest.NewRecorder()
+	handler.ServeHTTP(rr3, req3)
+	if rr3.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 Forbidden after the auction ended, got %d", rr3.Code)
+	}
+}
+
+func TestAnswerQuestionHandler(t *testing.T) {
+	ts := setupQuestionsMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	c := auth.NewClient(ts.URL, "anon")
+
+	req := httptest.NewRequest("POST", "/api/listings/list1/questions/q1/answer", bytes.NewBuffer([]byte(`{"answer": "Size M"}`)))
+	req.SetPathValue("id", "list1")
+	req.SetPathValue("qid", "q1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	answerQuestionHandler(c).ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 Forbidden for non-seller answer, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/db"
+	"github.com/quickswap/quickswap/internal/events"
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/retraction"
+	"github.com/redis/go-redis/v9"
+)
+
+// retractBidHandler lets a bidder retract one of their bids when the
+// retraction policy allows it. The auction falls back to the best remaining
+// bid, in the ledger and in the Redis bid state.
+func retractBidHandler(authClient *auth.Client, rdb *redis.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listingID, bidID := r.PathValue("id"), r.PathValue("bid_id")
+		if listingID == "" || bidID == "" {
+			respondError(w, "Listing ID and bid ID are required", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Reason      string `json:"reason"`
+			Explanation string `json:"explanation"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		l, err := listing.Get(listingID)
+		if errors.Is(err, listing.ErrNotFound) {
+			respondError(w, "Listing not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch listing", http.StatusInternalServerError)
+			return
+		}
+
+		out, err := retraction.DefaultPolicy.Retract(l, bidID, userID, req.Reason, strings.TrimSpace(req.Explanation), time.Now().UTC())
+		switch {
+		case errors.Is(err, ledger.ErrNotFound):
+			respondError(w, "Bid not found", http.StatusNotFound)
+			return
+		case errors.Is(err, retraction.ErrIneligible):
+			respondError(w, err.Error(), http.StatusForbidden)
+			return
+		case err != nil:
+			log.Printf("Error retracting bid %s: %v", bidID, err)
+			respondError(w, "Failed to retract bid", http.StatusInternalServerError)
+			return
+		}
+		rec := out.Record
+
+		if out.WasLeading {
+			if rdb != nil {
+				if err := db.RestoreLead(r.Context(), rdb, listingID, rec.Amount, userID, rec.NewPrice, rec.NewLeaderID); err != nil {
+					log.Printf("Warning: failed to restore cached lead for %s: %v", listingID, err)
+				}
+			}
+			if rec.NewLeaderID != "" {
+				events.Publish(events.Event{
+					Type:      events.LeadRestored,
+					UserID:    rec.NewLeaderID,
+					ListingID: listingID,
+					Data:      map[string]string{"title": l.Title, "price": rec.NewPrice.String()},
+				})
+			}
+		}
+		events.Publish(events.Event{
+			Type:      events.BidRetracted,
+			UserID:    l.SellerID,
+			ListingID: listingID,
+			Data: map[string]string{
+				"title":  l.Title,
+				"amount": rec.Amount.String(),
+				"price":  rec.NewPrice.String(),
+			},
+		})
+
+		respondJSON(w, map[string]interface{}{
+			"message":       "Bid retracted",
+			"retraction":    rec,
+			"current_price": rec.NewPrice,
+			"currency":      rec.NewPrice.Currency,
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	"github.com/quickswap/quickswap/internal/savedsearch"
+)
+
+// savedSearchesHandler lists the caller's saved searches (GET) or saves a
+// new one (POST).
+func savedSearchesHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			searches, err := savedsearch.ForUser(userID)
+			if err != nil {
+				respondError(w, "Failed to fetch saved searches", http.StatusInternalServerError)
+				return
+			}
+			if searches == nil {
+				searches = []savedsearch.Search{}
+			}
+			respondJSON(w, map[string]interface{}{"saved_searches": searches})
+			return
+		}
+
+		var req struct {
+			Name      string                `json:"name"`
+			Criteria  savedsearch.Criteria  `json:"criteria"`
+			Frequency savedsearch.Frequency `json:"frequency"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		s := &savedsearch.Search{
+			UserID:    userID,
+			Name:      req.Name,
+			Criteria:  req.Criteria,
+			Frequency: req.Frequency,
+		}
+		if err := savedsearch.Save(s); err != nil {
+			switch {
+			case errors.Is(err, savedsearch.ErrInvalid), errors.Is(err, savedsearch.ErrTooMany):
+				respondError(w, err.Error(), http.StatusBadRequest)
+			default:
+				respondError(w, "Failed to save search", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(s)
+	}
+}
+
+// deleteSavedSearchHandler removes one of the caller's saved searches.
+func deleteSavedSearchHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		if err := savedsearch.Delete(userID, r.PathValue("id")); err != nil {
+			if errors.Is(err, savedsearch.ErrNotFound) {
+				respondError(w, "Saved search not found", http.StatusNotFound)
+				return
+			}
+			respondError(w, "Failed to delete saved search", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, map[string]interface{}{"id": r.PathValue("id"), "deleted": true})
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+func setupSavedSearchesMockServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "user123", "email": "test@example.com"}`))
+		case "/rest/v1/saved_searches":
+			switch r.Method {
+			case "GET":
+				w.WriteHeader(http.StatusOK)
+				if r.URL.Query().Get("id") == "eq.missing" {
+					w.Write([]byte(`[]`))
+					return
+				}
+				w.Write([]byte(`[{"id": "s1", "user_id": "user123", "name": "desk", "criteria": {"query": "desk"}, "frequency": "daily"}]`))
+			case "POST":
+				var rows []map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&rows)
+				rows[0]["id"] = "s2"
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(rows)
+			case "DELETE":
+				w.WriteHeader(http.StatusNoContent)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestSavedSearchesHandler(t *testing.T) {
+	ts := setupSavedSearchesMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	handler := savedSearchesHandler(auth.NewClient(ts.URL, "anon"))
+
+	req := httptest.NewRequest("GET", "/api/saved-searches", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	var list struct {
+		SavedSearches []map[string]interface{} `json:"saved_searches"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil || len(list.SavedSearches) != 1 {
+		t.Fatalf("Expected one saved search, got %v (%v)", list.SavedSearches, err)
+	}
+
+	req = httptest.NewRequest("POST", "/api/saved-searches", bytes.NewBufferString(`{"criteria": {"query": "standing desk", "category": "home"}, "frequency": "instant"}`))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var created map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&created)
+	if created["id"] != "s2" || created["name"] != "standing desk" {
+		t.Errorf("Expected the saved search back, got %v", created)
+	}
+
+	req = httptest.NewRequest("POST", "/api/saved-searches", bytes.NewBufferString(`{"criteria": {}}`))
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty search, got %d", rr.Code)
+	}
+}
+
+func TestDeleteSavedSearchHandler(t *testing.T) {
+	ts := setupSavedSearchesMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /api/saved-searches/{id}", deleteSavedSearchHandler(auth.NewClient(ts.URL, "anon")))
+
+	req := httptest.NewRequest("DELETE", "/api/saved-searches/s1", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest("DELETE", "/api/saved-searches/missing", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for someone else's search, got %d", rr.Code)
+	}
+}
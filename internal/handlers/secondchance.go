@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/secondchance"
+)
+
+// sendSecondChanceHandler lets the seller of a closed auction offer the item
+// to the next runner-up at their highest bid.
+func sendSecondChanceHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listingID := r.PathValue("id")
+		if listingID == "" {
+			respondError(w, "Listing ID is required", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			ExpiresInHours int `json:"expires_in_hours"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		l, err := listing.Get(listingID)
+		if errors.Is(err, listing.ErrNotFound) {
+			respondError(w, "Listing not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch listing", http.StatusInternalServerError)
+			return
+		}
+		if l.SellerID != userID {
+			respondError(w, "Only the seller can send second-chance offers", http.StatusForbidden)
+			return
+		}
+
+		o, err := secondchance.Send(l, userID, time.Duration(req.ExpiresInHours)*time.Hour, time.Now().UTC())
+		switch {
+		case errors.Is(err, secondchance.ErrIneligible), errors.Is(err, secondchance.ErrNoRunnerUp):
+			respondError(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			respondError(w, "Failed to send second-chance offer", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"second_chance_offer": o})
+	}
+}
+
+// respondSecondChanceHandler lets the runner-up accept or decline.
+func respondSecondChanceHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offerID, action := r.PathValue("id"), r.PathValue("action")
+		if action != "accept" && action != "decline" {
+			respondError(w, "Action must be accept or decline", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		o, err := secondchance.Get(offerID)
+		if err == nil {
+			err = secondchance.Respond(o, userID, action == "accept", time.Now().UTC())
+		}
+		switch {
+		case errors.Is(err, secondchance.ErrNotFound):
+			respondError(w, "Second-chance offer not found", http.StatusNotFound)
+			return
+		case errors.Is(err, secondchance.ErrNotOpen):
+			respondError(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			respondError(w, "Failed to answer second-chance offer", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"second_chance_offer": o})
+	}
+}
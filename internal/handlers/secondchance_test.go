@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+func setupSecondChanceMockServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "seller1"}`))
+		case "/rest/v1/listings":
+			w.Write([]byte(`[{"id": "list1", "title": "Lamp", "seller_id": "seller1", "starting_bid": 20, "status": "sold", "winner_id": "buyer1", "settled_at": "2049-01-01T00:00:00Z", "auction_end_time": "2049-01-01T00:00:00Z"}]`))
+		case "/rest/v1/bids":
+			w.Write([]byte(`[{"id": "b2", "listing_id": "list1", "user_id": "buyer1", "bid_amount": 40, "bid_sequence": 2, "status": "active"}, {"id": "b1", "listing_id": "list1", "user_id": "buyer2", "bid_amount": 35, "bid_sequence": 1, "status": "active"}]`))
+		case "/rest/v1/orders":
+			w.Write([]byte(`[{"id": "o1", "listing_id": "list1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 40, "status": "unpaid", "due_at": "2049-01-01T00:00:00Z"}]`))
+		case "/rest/v1/second_chance_offers":
+			w.Write([]byte(`[{"id": "sc1", "listing_id": "list1", "seller_id": "seller1", "bidder_id": "buyer2", "amount": 35, "status": "pending", "expires_at": "2049-01-03T00:00:00Z"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestSendSecondChanceHandler(t *testing.T) {
+	ts := setupSecondChanceMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	// buyer2 already has an offer, so nobody is left to offer the item to
+	req := httptest.NewRequest("POST", "/api/listings/list1/second-chance", bytes.NewBufferString(`{"expires_in_hours": 24}`))
+	req.SetPathValue("id", "list1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	sendSecondChanceHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 with no runner-up left, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestRespondSecondChanceHandler(t *testing.T) {
+	ts := setupSecondChanceMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+	handler := respondSecondChanceHandler(auth.NewClient(ts.URL, "anon"))
+
+	respond := func(action string) int {
+		req := httptest.NewRequest("POST", "/api/second-chance/sc1/"+action, nil)
+		req.SetPathValue("id", "sc1")
+		req.SetPathValue("action", action)
+		req.Header.Set("Authorization", "Bearer validtoken")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := respond("haggle"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown action, got %d", code)
+	}
+	// The caller is the seller, not the runner-up the offer went to
+	if code := respond("accept"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 answering someone else's offer, got %d", code)
+	}
+}
+
+func TestSendSecondChanceHandlerClaimsListingFirst(t *testing.T) {
+	var inserted bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "seller1"}`))
+		case "/rest/v1/listings":
+			if r.Method == "PATCH" {
+				// Another send already moved the listing to second_chance
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"id": "list1", "title": "Lamp", "seller_id": "seller1", "starting_bid": 20, "status": "sold", "winner_id": "buyer1", "settled_at": "2049-01-01T00:00:00Z", "auction_end_time": "2049-01-01T00:00:00Z"}]`))
+		case "/rest/v1/bids":
+			w.Write([]byte(`[{"id": "b2", "listing_id": "list1", "user_id": "buyer1", "bid_amount": 40, "bid_sequence": 2, "status": "active"}, {"id": "b1", "listing_id": "list1", "user_id": "buyer2", "bid_amount": 35, "bid_sequence": 1, "status": "active"}]`))
+		case "/rest/v1/orders":
+			w.Write([]byte(`[{"id": "o1", "listing_id": "list1", "buyer_id": "buyer1", "seller_id": "seller1", "amount": 40, "status": "unpaid", "due_at": "2049-01-01T00:00:00Z"}]`))
+		case "/rest/v1/second_chance_offers":
+			inserted = inserted || r.Method == "POST"
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("POST", "/api/listings/list1/second-chance", bytes.NewBufferString(`{}`))
+	req.SetPathValue("id", "list1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	sendSecondChanceHandler(auth.NewClient(ts.URL, "anon")).ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict || inserted {
+		t.Errorf("Expected 409 and no offer when the listing was already claimed, got %d (inserted=%v)", rr.Code, inserted)
+	}
+}
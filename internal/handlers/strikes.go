@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/payments"
+	"github.com/quickswap/quickswap/internal/secondchance"
+	"github.com/quickswap/quickswap/internal/strikes"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// listingRelisted marks a listing whose item was put up again as a new one.
+const listingRelisted = "relisted"
+
+// checkBidding writes a 403 and returns false if userID's unpaid-item strikes
+// restrict them from bidding. A failed strike lookup writes a 503 rather than
+// letting a restricted bidder through.
+func checkBidding(w http.ResponseWriter, userID string) bool {
+	err := strikes.Check(userID)
+	if errors.Is(err, strikes.ErrRestricted) {
+		respondError(w, err.Error(), http.StatusForbidden)
+		return false
+	} else if err != nil {
+		log.Printf("Warning: strike check failed for %s: %v", userID, err)
+		respondError(w, "Unable to verify bidding eligibility, try again shortly", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// myStrikesHandler returns the caller's strike history. Admins can pass
+// ?user_id= to see anyone's.
+func myStrikesHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		if other := r.URL.Query().Get("user_id"); other != "" && other != userID {
+			if !isAdmin(userID) {
+				respondError(w, "Admin access required", http.StatusForbidden)
+				return
+			}
+			userID = other
+		}
+
+		history, err := strikes.History(userID)
+		if err != nil {
+			respondError(w, "Failed to fetch strikes", http.StatusInternalServerError)
+			return
+		}
+		active := 0
+		for i := range history {
+			if history[i].Active() {
+				active++
+			}
+		}
+		if history == nil {
+			history = []strikes.Strike{}
+		}
+		respondJSON(w, map[string]interface{}{
+			"strikes":    history,
+			"active":     active,
+			"threshold":  strikes.Threshold,
+			"restricted": active >= strikes.Threshold,
+		})
+	}
+}
+
+// clearStrikeHandler lets an admin lift a strike, with a note for the record.
+func clearStrikeHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := requireAdmin(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Note string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Note == "" {
+			respondError(w, "A note explaining the clearance is required", http.StatusBadRequest)
+			return
+		}
+
+		s, err := strikes.Clear(r.PathValue("id"), adminID, req.Note, time.Now().UTC())
+		switch {
+		case errors.Is(err, strikes.ErrNotFound):
+			respondError(w, "Strike not found", http.StatusNotFound)
+			return
+		case errors.Is(err, strikes.ErrCleared):
+			respondError(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			respondError(w, "Failed to clear strike", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"strike": s})
+	}
+}
+
+// relistHandler puts the item of a closed listing up again as a new listing
+// when it didn't sell or its buyer never paid. The old listing is marked
+// relisted so it can't be relisted or offered again.
+func relistHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		l, err := listing.Get(r.PathValue("id"))
+		if errors.Is(err, listing.ErrNotFound) {
+			respondError(w, "Listing not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondError(w, "Failed to fetch listing", http.StatusInternalServerError)
+			return
+		}
+		if l.SellerID != userID {
+			respondError(w, "Only the seller can relist", http.StatusForbidden)
+			return
+		}
+		orders, err := payments.ForListing(l.ID)
+		if err != nil {
+			respondError(w, "Failed to fetch orders", http.StatusInternalServerError)
+			return
+		}
+		if msg := relistBlocked(l, orders); msg != "" {
+			respondError(w, msg, http.StatusConflict)
+			return
+		}
+
+		var marked []listing.Listing
+		query := "id=eq." + url.QueryEscape(l.ID) + "&status=eq." + url.QueryEscape(l.Status)
+		if err := supabase.Update("listings", query, map[string]string{"status": listingRelisted}, &marked); err != nil {
+			respondError(w, "Failed to relist", http.StatusInternalServerError)
+			return
+		}
+		if len(marked) == 0 {
+			respondError(w, "Listing changed while relisting; try again", http.StatusConflict)
+			return
+		}
+
+		id, err := publishListing(r.Context(), l.Relist(time.Now().UTC()))
+		if err != nil {
+			if err := supabase.Update("listings", "id=eq."+url.QueryEscape(l.ID), map[string]string{"status": l.Status}, nil); err != nil {
+				log.Printf("Warning: failed to restore status of %s: %v", l.ID, err)
+			}
+			respondError(w, "Failed to relist", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"listing_id": id, "relisted_from": l.ID})
+	}
+}
+
+// relistBlocked explains why l can't be relisted, or returns "" if it can.
+// Closed listings can be relisted when they didn't sell, or when every order
+// for them went unpaid.
+func relistBlocked(l *listing.Listing, orders []payments.Order) string {
+	switch {
+	case l.SettledAt == nil:
+		return "The auction hasn't closed yet"
+	case l.Status == listingRelisted:
+		return "The listing was already relisted"
+	case l.Status == secondchance.ListingOffered:
+		return "A second-chance offer is still open"
+	case l.WinnerID == nil:
+		return ""
+	case len(orders) == 0:
+		return "The listing sold"
+	}
+	for _, o := range orders {
+		if o.Status != payments.Unpaid {
+			return "The buyer's order is still " + string(o.Status)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/payments"
+)
+
+func TestBidHandlerRestrictsStruckBidders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.Write([]byte(`{"id": "user123"}`))
+		case "/rest/v1/bidder_strikes":
+			w.Write([]byte(`[{"id": "s1"}, {"id": "s2"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	req := httptest.NewRequest("POST", "/api/auctions/list1/bid", bytes.NewBufferString(`{"amount": 50}`))
+	req.SetPathValue("id", "list1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	bidHandler(auth.NewClient(ts.URL, "anon"), nil, nil).ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a bidder over the strike threshold, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCheckBiddingFailsClosed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	rr := httptest.NewRecorder()
+	if checkBidding(rr, "user123") || rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected a failed strike lookup to block the bid with 503, got %d", rr.Code)
+	}
+}
+
+func TestRelistBlocked(t *testing.T) {
+	now := time.Now()
+	winner := "buyer"
+	unsold := listing.Listing{Status: "unsold", SettledAt: &now}
+	sold := listing.Listing{Status: "sold", SettledAt: &now, WinnerID: &winner}
+	open := listing.Listing{}
+	relisted := unsold
+	relisted.Status = listingRelisted
+	offered := sold
+	offered.Status = "second_chance"
+
+	unpaid := []payments.Order{{Status: payments.Unpaid}}
+	paid := []payments.Order{{Status: payments.Unpaid}, {Status: payments.Paid}}
+
+	for _, tc := range []struct {
+		name    string
+		l       listing.Listing
+		orders  []payments.Order
+		allowed bool
+	}{
+		{"unsold", unsold, nil, true},
+		{"buyer never paid", sold, unpaid, true},
+		{"buyer paid", sold, paid, false},
+		{"sold without an order", sold, nil, false},
+		{"still open", open, nil, false},
+		{"already relisted", relisted, nil, false},
+		{"second-chance offer out", offered, unpaid, false},
+	} {
+		if got := relistBlocked(&tc.l, tc.orders) == ""; got != tc.allowed {
+			t.Errorf("%s: allowed=%v, want %v", tc.name, got, tc.allowed)
+		}
+	}
+}
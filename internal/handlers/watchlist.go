@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/quickswap/quickswap/internal/auth"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/supabase"
+	"github.com/quickswap/quickswap/internal/watchlist"
+)
+
+// watchlistHandler returns the caller's watched listings.
+func watchlistHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		entries, err := watchlist.ForUser(userID)
+		if err != nil {
+			respondError(w, "Failed to fetch watchlist", http.StatusInternalServerError)
+			return
+		}
+
+		items := []map[string]interface{}{}
+		if len(entries) == 0 {
+			respondJSON(w, map[string]interface{}{"watchlist": items})
+			return
+		}
+
+		ids := make([]string, 0, len(entries))
+		for _, e := range entries {
+			ids = append(ids, url.QueryEscape(e.ListingID))
+		}
+		var listingsArr []listing.Listing
+		if err := supabase.Select("listings", "id=in.("+strings.Join(ids, ",")+")", &listingsArr); err != nil {
+			respondError(w, "Failed to fetch listings", http.StatusInternalServerError)
+			return
+		}
+		byID := make(map[string]listing.Listing, len(listingsArr))
+		for _, l := range listingsArr {
+			byID[l.ID] = l
+		}
+
+		for _, e := range entries {
+			l, found := byID[e.ListingID]
+			if !found {
+				continue // listing was removed
+			}
+			image := ""
+			if len(l.Images) > 0 {
+				image = l.Images[0]
+			}
+			items = append(items, map[string]interface{}{
+				"listing_id":       l.ID,
+				"title":            l.Title,
+				"image":            image,
+				"starting_bid":     l.StartingBid,
+				"currency":         l.Currency,
+				"auction_end_time": l.AuctionEndTime,
+				"watched_at":       e.CreatedAt,
+			})
+		}
+
+		respondJSON(w, map[string]interface{}{"watchlist": items})
+	}
+}
+
+// watchHandler adds a listing to the caller's watchlist.
+func watchHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listingID := r.PathValue("listing_id")
+		if listingID == "" {
+			respondError(w, "Listing ID is required", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		if err := watchlist.Watch(userID, listingID); err != nil {
+			respondError(w, "Failed to watch listing", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, map[string]interface{}{
+			"listing_id":  listingID,
+			"is_watching": true,
+		})
+	}
+}
+
+// unwatchHandler removes a listing from the caller's watchlist.
+func unwatchHandler(authClient *auth.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listingID := r.PathValue("listing_id")
+		if listingID == "" {
+			respondError(w, "Listing ID is required", http.StatusBadRequest)
+			return
+		}
+
+		userID, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		if err := watchlist.Unwatch(userID, listingID); err != nil {
+			respondError(w, "Failed to unwatch listing", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, map[string]interface{}{
+			"listing_id":  listingID,
+			"is_watching": false,
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/auth"
+)
+
+func setupWatchlistMockServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v1/user":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "user123", "email": "test@example.com"}`))
+		case "/rest/v1/watchlist":
+			switch r.Method {
+			case "GET":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[{"user_id": "user123", "listing_id": "list1", "created_at": "2050-01-01T00:00:00Z"}]`))
+			case "POST":
+				w.WriteHeader(http.StatusCreated)
+			case "DELETE":
+				w.WriteHeader(http.StatusNoContent)
+			}
+		case "/rest/v1/listings":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[{"id": "list1", "title": "Test Listing", "starting_bid": 10, "auction_end_time": "2050-01-01T00:00:00Z"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestWatchlistHandler(t *testing.T) {
+	ts := setupWatchlistMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	c := auth.NewClient(ts.URL, "anon")
+	handler := watchlistHandler(c)
+
+	req1 := httptest.NewRequest("GET", "/api/watchlist", nil)
+	rr1 := httptest.NewRecorder()
+	handler.ServeHTTP(rr1, req1)
+	if rr1.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 Unauthorized, got %d", rr1.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/api/watchlist", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var body struct {
+		Watchlist []map[string]interface{} `json:"watchlist"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if len(body.Watchlist) != 1 || body.Watchlist[0]["listing_id"] != "list1" {
+		t.Errorf("Expected list1 in watchlist, got %v", body.Watchlist)
+	}
+}
+
+func TestWatchAndUnwatchHandler(t *testing.T) {
+	ts := setupWatchlistMockServer()
+	defer ts.Close()
+	os.Setenv("SUPABASE_URL", ts.URL)
+	os.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	c := auth.NewClient(ts.URL, "anon")
+
+	req := httptest.NewRequest("POST", "/api/watchlist/list1", nil)
+	req.SetPathValue("listing_id", "list1")
+	req.Header.Set("Authorization", "Bearer validtoken")
+	rr := httptest.NewRecorder()
+	watchHandler(c).ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("Watch returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	req2 := httptest.NewRequest("DELETE", "/api/watchlist/list1", nil)
+	req2.SetPathValue("listing_id", "list1")
+	req2.Header.Set("Authorization", "Bearer validtoken")
+	rr2 := httptest.NewRecorder()
+	unwatchHandler(c).ServeHTTP(rr2, req2)
+	if status := rr2.Code; status != http.StatusOK {
+		t.Errorf("Unwatch returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+}
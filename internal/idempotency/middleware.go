@@ -0,0 +1,159 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/ratelimit"
+)
+
+// Header is the request header clients put the key in.
+const Header = "Idempotency-Key"
+
+// TTL is how long a key and its response are remembered.
+const TTL = 24 * time.Hour
+
+// InFlightTTL bounds how long a reserved key blocks retries if the server
+// dies before the request finishes.
+const InFlightTTL = time.Minute
+
+// MaxKeyLength bounds the header value.
+const MaxKeyLength = 255
+
+// recorder passes the response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func respondError(w http.ResponseWriter, msg string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// callerScope identifies who sent r so two users' keys can't collide. A
+// verified user keeps the same scope across token refreshes; anything else is
+// scoped by its Authorization header.
+func callerScope(r *http.Request) string {
+	if userID, ok := ratelimit.VerifiedUser(r); ok {
+		return "user:" + userID
+	}
+	caller := sha256.Sum256([]byte(r.Header.Get("Authorization")))
+	return "auth:" + hex.EncodeToString(caller[:12])
+}
+
+// Middleware makes next safe to retry. Requests carrying an Idempotency-Key
+// header are processed once per caller and key; duplicates replay the
+// original response, and reusing a key with a different payload is rejected.
+// Server errors are not remembered so the client can retry them.
+func Middleware(store Store, scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > MaxKeyLength {
+			respondError(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := scope + ":" + callerScope(r) + ":" + key
+
+		payload := sha256.New()
+		payload.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+		payload.Write(body)
+		fingerprint := hex.EncodeToString(payload.Sum(nil))
+
+		// Store writes outlive the request: a client that hangs up mid-request
+		// is the one most likely to retry, and must find its key settled
+		ctx := context.WithoutCancel(r.Context())
+		existing, ok, err := store.Reserve(ctx, storeKey, fingerprint, InFlightTTL)
+		if err != nil {
+			log.Printf("Warning: idempotency store unavailable: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !ok {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				respondError(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+			case !existing.Done:
+				respondError(w, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+			default:
+				if existing.ContentType != "" {
+					w.Header().Set("Content-Type", existing.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(existing.Status)
+				w.Write(existing.Body)
+			}
+			return
+		}
+
+		// Free the key if next fails or panics so the client can retry
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := store.Release(ctx, storeKey); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}()
+
+		rec := &recorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if rec.status >= 500 {
+			return
+		}
+
+		// If storing fails the reservation still lapses after InFlightTTL
+		completed = true
+		err = store.Complete(ctx, storeKey, &Record{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      rec.status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}, TTL)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// contextStore fails like a network-backed store when handed a cancelled
+// context.
+type contextStore struct {
+	*MemoryStore
+}
+
+func (s contextStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	return s.MemoryStore.Reserve(ctx, key, fingerprint, ttl)
+}
+
+func (s contextStore) Complete(ctx context.Context, key string, rec *Record, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Complete(ctx, key, rec, ttl)
+}
+
+func (s contextStore) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Release(ctx, key)
+}
+
+func TestMiddleware(t *testing.T) {
+	calls := 0
+	handler := Middleware(NewMemoryStore(), "bid", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"message": "Bid placed successfully"}`))
+	}))
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/auctions/123/bid", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer validtoken")
+		if key != "" {
+			req.Header.Set(Header, key)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := send("key-1", `{"amount": 50}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", first.Code)
+	}
+
+	retry := send("key-1", `{"amount": 50}`)
+	if retry.Code != http.StatusOK || retry.Body.String() != first.Body.String() {
+		t.Errorf("Expected replay of original response, got %d %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected Idempotent-Replayed header on replay")
+	}
+	if calls != 1 {
+		t.Errorf("Expected handler to run once, ran %d times", calls)
+	}
+
+	reuse := send("key-1", `{"amount": 60}`)
+	if reuse.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for key reuse with another payload, got %d", reuse.Code)
+	}
+
+	send("", `{"amount": 70}`)
+	send("", `{"amount": 70}`)
+	if calls != 3 {
+		t.Errorf("Expected requests without a key to always run, handler ran %d times", calls)
+	}
+}
+
+func TestMiddlewareScopesKeysByVerifiedUser(t *testing.T) {
+	t.Setenv("SUPABASE_JWT_SECRET", "secret")
+	sign := func(sub string, exp int64) string {
+		seg := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+		unsigned := seg(`{"alg":"HS256","typ":"JWT"}`) + "." + seg(fmt.Sprintf(`{"sub":%q,"exp":%d}`, sub, exp))
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(unsigned))
+		return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	calls := 0
+	handler := Middleware(NewMemoryStore(), "bid", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"message": "Bid placed successfully"}`))
+	}))
+	send := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/auctions/123/bid", bytes.NewBufferString(`{"amount": 50}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(Header, "key-1")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	send(sign("user123", exp))
+	// A refreshed token for the same user still finds the key
+	if retry := send(sign("user123", exp+60)); retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected a retry with a refreshed token to replay")
+	}
+	send(sign("user456", exp))
+	if calls != 2 {
+		t.Errorf("Expected one call per user, handler ran %d times", calls)
+	}
+}
+
+func TestMiddlewareDoesNotRememberServerErrors(t *testing.T) {
+	fail := true
+	handler := Middleware(NewMemoryStore(), "listing", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("POST", "/api/createlisting", bytes.NewBufferString(`{}`))
+	req.Header.Set(Header, "key-2")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	fail = false
+	req2 := httptest.NewRequest("POST", "/api/createlisting", bytes.NewBufferString(`{}`))
+	req2.Header.Set(Header, "key-2")
+	rr2 := httptest.NewRecorder()
+	handler.ServeHTTP(rr2, req2)
+	if rr2.Code != http.StatusOK {
+		t.Errorf("Expected retry after a server error to run again, got %d", rr2.Code)
+	}
+}
+
+func TestMiddlewareSettlesKeyAfterClientHangsUp(t *testing.T) {
+	calls := 0
+	handler := Middleware(contextStore{NewMemoryStore()}, "checkout", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"paid": true}`))
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("POST", "/api/orders/o1/checkout", nil).WithContext(ctx)
+	req.Header.Set(Header, "key-3")
+	// The client drops the connection while the request is in flight
+	cancel()
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	retry := httptest.NewRequest("POST", "/api/orders/o1/checkout", nil)
+	retry.Header.Set(Header, "key-3")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, retry)
+	if rr.Code != http.StatusOK || rr.Header().Get("Idempotent-Replayed") != "true" || calls != 1 {
+		t.Errorf("Expected the retry to replay the stored response, got %d (calls=%d)", rr.Code, calls)
+	}
+}
+
+func TestMiddlewareReleasesKeyOnPanic(t *testing.T) {
+	panics := true
+	handler := Middleware(NewMemoryStore(), "checkout", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if panics {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/orders/o1/checkout", nil)
+		req.Header.Set(Header, "key-4")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	func() {
+		defer func() { recover() }()
+		send()
+	}()
+	panics = false
+	if rr := send(); rr.Code != http.StatusOK {
+		t.Errorf("Expected the retry after a panic to run again, got %d", rr.Code)
+	}
+}
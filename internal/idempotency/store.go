@@ -0,0 +1,149 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Record is what is kept for each idempotency key.
+type Record struct {
+	// Fingerprint is a hash of the request the key was first used with.
+	Fingerprint string `json:"fingerprint"`
+	// Done is false while the original request is still being processed.
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Store persists idempotency records.
+type Store interface {
+	// Reserve claims key for a new request. If the key is already taken the
+	// existing record is returned and ok is false.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (existing *Record, ok bool, err error)
+	// Complete stores the response for a reserved key.
+	Complete(ctx context.Context, key string, rec *Record, ttl time.Duration) error
+	// Release frees a reserved key so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// RedisStore keeps records in Redis so every server instance sees them.
+type RedisStore struct {
+	rdb *redis.Client
+}
+
+// NewRedisStore creates a store backed by rdb.
+func NewRedisStore(rdb *redis.Client) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+func redisKey(key string) string {
+	return "idempotency:" + key
+}
+
+func (s *RedisStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	pending, err := json.Marshal(Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, false, err
+	}
+
+	ok, err := s.rdb.SetNX(ctx, redisKey(key), pending, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("redis reserve idempotency key: %w", err)
+	}
+	if ok {
+		return nil, true, nil
+	}
+
+	raw, err := s.rdb.Get(ctx, redisKey(key)).Bytes()
+	if err == redis.Nil {
+		// Expired between SETNX and GET; try once more
+		return s.Reserve(ctx, key, fingerprint, ttl)
+	} else if err != nil {
+		return nil, false, fmt.Errorf("redis get idempotency key: %w", err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, false, fmt.Errorf("decode idempotency record: %w", err)
+	}
+	return &rec, false, nil
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string, rec *Record, ttl time.Duration) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := s.rdb.Set(ctx, redisKey(key), b, ttl).Err(); err != nil {
+		return fmt.Errorf("redis store idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	if err := s.rdb.Del(ctx, redisKey(key)).Err(); err != nil {
+		return fmt.Errorf("redis release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// MemoryStore keeps records in this process. It is used when Redis is not
+// configured.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	rec     Record
+	expires time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.records[key]; ok && now.Before(e.expires) {
+		rec := e.rec
+		return &rec, false, nil
+	}
+	s.records[key] = memoryEntry{rec: Record{Fingerprint: fingerprint}, expires: now.Add(ttl)}
+	return nil, true, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, rec *Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = memoryEntry{rec: *rec, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// New returns a Redis store, or an in-memory store when rdb is nil.
+func New(rdb *redis.Client) Store {
+	if rdb == nil {
+		log.Println("Idempotency keys stored in memory (Redis not configured)")
+		return NewMemoryStore()
+	}
+	return NewRedisStore(rdb)
+}
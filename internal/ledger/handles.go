@@ -0,0 +1,60 @@
+package ledger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"strconv"
+)
+
+// ErrNoHandleSecret is returned when BIDDER_HANDLE_SECRET isn't set. Without
+// it handles would be a public function of the user ID.
+var ErrNoHandleSecret = errors.New("BIDDER_HANDLE_SECRET must be set")
+
+// CheckHandleSecret reports whether handles can be derived, for startup.
+func CheckHandleSecret() error {
+	_, err := handleSecret()
+	return err
+}
+
+// handleSecret keys bidder handles so they can't be reversed by hashing
+// known user IDs.
+func handleSecret() ([]byte, error) {
+	s := os.Getenv("BIDDER_HANDLE_SECRET")
+	if s == "" {
+		return nil, ErrNoHandleSecret
+	}
+	return []byte(s), nil
+}
+
+// handle derives a masked handle such as "b***3" for userID on listingID.
+func handle(secret []byte, listingID, userID string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(listingID + ":" + userID))
+	sum := mac.Sum(nil)
+	return string(rune('a'+sum[0]%26)) + "***" + strconv.Itoa(int(sum[1]%10))
+}
+
+// Handles assigns each bidder a pseudonymous handle that is stable for the
+// auction and differs between auctions. bidders must be in order of first
+// bid: when two bidders derive the same handle, the later one gets a numeric
+// suffix, so existing handles never change as new bidders join.
+func Handles(listingID string, bidders []string) (map[string]string, error) {
+	secret, err := handleSecret()
+	if err != nil {
+		return nil, err
+	}
+	handles := make(map[string]string, len(bidders))
+	taken := make(map[string]bool, len(bidders))
+	for _, userID := range bidders {
+		base := handle(secret, listingID, userID)
+		h := base
+		for n := 2; taken[h]; n++ {
+			h = base + "-" + strconv.Itoa(n)
+		}
+		taken[h] = true
+		handles[userID] = h
+	}
+	return handles, nil
+}
@@ -0,0 +1,62 @@
+package ledger
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestHandles(t *testing.T) {
+	t.Setenv("BIDDER_HANDLE_SECRET", "test-secret")
+	bidders := []string{"user1", "user2", "user3"}
+	handles, err := Handles("list1", bidders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := regexp.MustCompile(`^[a-z]\*\*\*[0-9](-[0-9]+)?$`)
+	seen := map[string]bool{}
+	for _, u := range bidders {
+		h := handles[u]
+		if !pattern.MatchString(h) {
+			t.Errorf("Unexpected handle format %q", h)
+		}
+		if seen[h] {
+			t.Errorf("Expected distinct handles, %q repeated", h)
+		}
+		seen[h] = true
+	}
+
+	// A later bidder never changes earlier handles
+	more, _ := Handles("list1", append(bidders, "user4", "user5"))
+	for _, u := range bidders {
+		if more[u] != handles[u] {
+			t.Errorf("Handle for %s changed from %q to %q", u, handles[u], more[u])
+		}
+	}
+}
+
+func TestHandlesCollisionSuffix(t *testing.T) {
+	t.Setenv("BIDDER_HANDLE_SECRET", "test-secret")
+	// With 260 possible handles, 300 bidders must collide
+	bidders := make([]string, 300)
+	for i := range bidders {
+		bidders[i] = "user" + string(rune('A'+i%26)) + string(rune('a'+i/26))
+	}
+	handles, _ := Handles("list1", bidders)
+	seen := map[string]bool{}
+	for _, h := range handles {
+		if seen[h] {
+			t.Fatalf("Duplicate handle %q", h)
+		}
+		seen[h] = true
+	}
+}
+
+func TestHandlesRequireSecret(t *testing.T) {
+	t.Setenv("BIDDER_HANDLE_SECRET", "")
+	t.Setenv("SUPABASE_SERVICE_KEY", "service-key")
+	if _, err := Handles("list1", []string{"user1"}); !errors.Is(err, ErrNoHandleSecret) {
+		t.Errorf("Expected ErrNoHandleSecret without BIDDER_HANDLE_SECRET, got %v", err)
+	}
+}
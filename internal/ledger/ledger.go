@@ -0,0 +1,150 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Accepted bids are recorded in the Supabase `bids` table. Redis holds the
+// live auction state; the ledger is the durable history.
+const table = "bids"
+
+// Bid statuses.
+const (
+	StatusActive    = "active"
+	StatusRetracted = "retracted"
+	// StatusRevised marks a sealed bid replaced by the bidder's later bid.
+	StatusRevised = "revised"
+)
+
+// Standing is a PostgREST filter matching bids that are neither retracted nor
+// revised. Bids recorded before statuses existed have none and still stand.
+const Standing = "or=(status.is.null,status.not.in.(retracted,revised))"
+
+// ErrNotFound is returned when no bid matches the requested ID.
+var ErrNotFound = errors.New("bid not found")
+
+// Bid is one accepted bid.
+type Bid struct {
+	ID          string      `json:"id,omitempty"`
+	ListingID   string      `json:"listing_id"`
+	UserID      string      `json:"user_id"`
+	BidAmount   money.Money `json:"bid_amount"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Status      string      `json:"status"`
+	IsAutoBid   bool        `json:"is_auto_bid"`
+	BidSequence int64       `json:"bid_sequence"`
+	// Quantity is the number of units bid for on multi-quantity listings
+	Quantity int `json:"quantity,omitempty"`
+}
+
+// Record appends an accepted bid to the ledger.
+func Record(b Bid) error {
+	if b.Timestamp.IsZero() {
+		b.Timestamp = time.Now().UTC()
+	}
+	if b.Status == "" {
+		b.Status = StatusActive
+	}
+	if err := supabase.Insert(table, []Bid{b}, nil); err != nil {
+		return fmt.Errorf("failed to record bid: %w", err)
+	}
+	return nil
+}
+
+// MaxPageSize caps how many bids History returns at once.
+const MaxPageSize = 100
+
+// History returns up to limit bids on listingID with a sequence greater than
+// after, oldest first. Amounts are in currency c.
+func History(listingID string, c money.Currency, after int64, limit int) ([]Bid, error) {
+	if limit <= 0 || limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	var bids []Bid
+	query := "listing_id=eq." + url.QueryEscape(listingID) +
+		"&bid_sequence=gt." + strconv.FormatInt(after, 10) +
+		"&order=bid_sequence.asc&limit=" + strconv.Itoa(limit)
+	if err := supabase.Select(table, query, &bids); err != nil {
+		return nil, err
+	}
+	for i := range bids {
+		bids[i].BidAmount = bids[i].BidAmount.Round(c)
+	}
+	return bids, nil
+}
+
+// Bidders returns every bidder on listingID in order of their first bid.
+func Bidders(listingID string) ([]string, error) {
+	var rows []struct {
+		UserID string `json:"user_id"`
+	}
+	query := "listing_id=eq." + url.QueryEscape(listingID) + "&select=user_id&order=bid_sequence.asc"
+	if err := supabase.Select(table, query, &rows); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var bidders []string
+	for _, r := range rows {
+		if !seen[r.UserID] {
+			seen[r.UserID] = true
+			bidders = append(bidders, r.UserID)
+		}
+	}
+	return bidders, nil
+}
+
+// Get returns the bid with the given ID.
+func Get(id string) (*Bid, error) {
+	var bids []Bid
+	if err := supabase.Select(table, "id=eq."+url.QueryEscape(id), &bids); err != nil {
+		return nil, err
+	}
+	if len(bids) == 0 {
+		return nil, ErrNotFound
+	}
+	return &bids[0], nil
+}
+
+// Active returns the bids still standing on listingID, highest first. Amounts
+// are in currency c.
+func Active(listingID string, c money.Currency) ([]Bid, error) {
+	var bids []Bid
+	query := "listing_id=eq." + url.QueryEscape(listingID) +
+		"&" + Standing + "&order=bid_amount.desc,bid_sequence.asc"
+	if err := supabase.Select(table, query, &bids); err != nil {
+		return nil, err
+	}
+	for i := range bids {
+		bids[i].BidAmount = bids[i].BidAmount.Round(c)
+	}
+	return bids, nil
+}
+
+// MarkRetracted sets an active bid's status to retracted. It reports false
+// if the bid was no longer active.
+func MarkRetracted(id string) (bool, error) {
+	var updated []Bid
+	query := "id=eq." + url.QueryEscape(id) + "&" + Standing
+	if err := supabase.Update(table, query, map[string]string{"status": StatusRetracted}, &updated); err != nil {
+		return false, fmt.Errorf("failed to retract bid: %w", err)
+	}
+	return len(updated) > 0, nil
+}
+
+// Supersede marks userID's active bids on listingID placed before sequence
+// as revised, leaving their latest sealed bid as the only one standing.
+func Supersede(listingID, userID string, sequence int64) error {
+	query := "listing_id=eq." + url.QueryEscape(listingID) + "&user_id=eq." + url.QueryEscape(userID) +
+		"&" + Standing + "&bid_sequence=lt." + strconv.FormatInt(sequence, 10)
+	if err := supabase.Update(table, query, map[string]string{"status": StatusRevised}, nil); err != nil {
+		return fmt.Errorf("failed to supersede bids: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package listings
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+// AuctionType is a listing's auction format.
+type AuctionType string
+
+const (
+	// English is the ascending open auction. Listings without an
+	// auction_type are English auctions.
+	English AuctionType = "english"
+	// SealedFirstPrice hides bids until close; the highest bid wins and pays
+	// what they bid.
+	SealedFirstPrice AuctionType = "sealed_first_price"
+	// SealedSecondPrice is a Vickrey auction: bids are hidden until close and
+	// the highest bidder pays the second-highest bid.
+	SealedSecondPrice AuctionType = "sealed_second_price"
+	// Dutch starts high and drops on a schedule until the first buyer accepts
+	// the current price.
+	Dutch AuctionType = "dutch"
+)
+
+// ParseAuctionType validates an auction_type; "" means English.
+func ParseAuctionType(s string) (AuctionType, error) {
+	switch t := AuctionType(s); t {
+	case "", English:
+		return English, nil
+	case SealedFirstPrice, SealedSecondPrice, Dutch:
+		return t, nil
+	}
+	return "", fmt.Errorf("Invalid auction_type %q", s)
+}
+
+// Sealed reports whether bids are hidden until the auction closes.
+func (t AuctionType) Sealed() bool {
+	return t == SealedFirstPrice || t == SealedSecondPrice
+}
+
+// ClearingPrice is what the winner pays given the standing bids, highest
+// first, and the starting bid. It is the starting bid when there are no bids.
+func (t AuctionType) ClearingPrice(startingBid money.Money, bids []money.Money) money.Money {
+	if len(bids) == 0 {
+		return startingBid
+	}
+	if t == SealedSecondPrice {
+		if len(bids) > 1 && bids[1].Cmp(startingBid) > 0 {
+			return bids[1]
+		}
+		return startingBid
+	}
+	return bids[0]
+}
+
+// BidsHidden reports whether bid amounts and bidders must be hidden at now.
+func (l *Listing) BidsHidden(now time.Time) bool {
+	return l.AuctionType.Sealed() && now.Before(l.AuctionEndTime)
+}
+
+// DutchSchedule is how a Dutch auction's price falls: by Decrement every
+// IntervalMinutes from the starting bid, never below Floor.
+type DutchSchedule struct {
+	Decrement       money.Money `json:"dutch_decrement"`
+	IntervalMinutes int         `json:"dutch_interval_minutes"`
+	Floor           money.Money `json:"dutch_floor"`
+}
+
+func (s *DutchSchedule) interval() time.Duration {
+	return time.Duration(s.IntervalMinutes) * time.Minute
+}
+
+// steps is how many drops have happened by now since startAt.
+func (s *DutchSchedule) steps(startAt, now time.Time) int64 {
+	if s.IntervalMinutes <= 0 || !now.After(startAt) {
+		return 0
+	}
+	return int64(now.Sub(startAt) / s.interval())
+}
+
+// Price is the asking price at now for an auction starting at start on
+// startAt.
+func (s *DutchSchedule) Price(start money.Money, startAt, now time.Time) money.Money {
+	p := start.Sub(s.Decrement.Mul(s.steps(startAt, now)))
+	if p.Cmp(s.Floor) < 0 {
+		return s.Floor
+	}
+	return p
+}
+
+// NextDrop is when the price next falls, or the zero time once it has
+// reached the floor.
+func (s *DutchSchedule) NextDrop(start money.Money, startAt, now time.Time) time.Time {
+	if s.IntervalMinutes <= 0 || s.Price(start, startAt, now).Cmp(s.Floor) <= 0 {
+		return time.Time{}
+	}
+	if now.Before(startAt) {
+		return startAt.Add(s.interval())
+	}
+	return startAt.Add(time.Duration(s.steps(startAt, now)+1) * s.interval())
+}
+
+// AskingPrice is a Dutch listing's current price.
+func (l *Listing) AskingPrice(now time.Time) money.Money {
+	if l.AuctionType != Dutch || l.DutchSchedule == nil {
+		return l.StartingBid
+	}
+	return l.DutchSchedule.Price(l.StartingBid, l.AuctionStartTime, now)
+}
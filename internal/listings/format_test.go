@@ -0,0 +1,108 @@
+package listings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+func TestClearingPrice(t *testing.T) {
+	usd := func(minor int64) money.Money { return money.Money{Amount: minor, Currency: "USD"} }
+	start := usd(1000)
+	bids := []money.Money{usd(5000), usd(3000)}
+
+	for _, tc := range []struct {
+		t    AuctionType
+		bids []money.Money
+		want money.Money
+	}{
+		{English, bids, usd(5000)},
+		{SealedFirstPrice, bids, usd(5000)},
+		{SealedSecondPrice, bids, usd(3000)},
+		{SealedSecondPrice, bids[:1], start},
+		{SealedSecondPrice, nil, start},
+	} {
+		if got := tc.t.ClearingPrice(start, tc.bids); !got.Equal(tc.want) {
+			t.Errorf("%s with %v: got %v, want %v", tc.t, tc.bids, got, tc.want)
+		}
+	}
+}
+
+func TestAuctionTypeInput(t *testing.T) {
+	if _, err := ParseAuctionType("dutch_tulip"); err == nil {
+		t.Error("Expected unknown auction_type to be rejected")
+	}
+	if at, _ := ParseAuctionType(""); at != English {
+		t.Errorf("Expected empty auction_type to mean English, got %q", at)
+	}
+
+	in := decodeInput(t, `{"auction_type": "sealed_second_price", "starting_bid": 25, "buy_now_price": 100}`)
+	if err := in.Check(); err == nil {
+		t.Error("Expected sealed auctions with buy_now_price to be rejected")
+	}
+
+	end := time.Now().Add(time.Hour)
+	l := &Listing{AuctionType: SealedFirstPrice, AuctionEndTime: end}
+	if !l.BidsHidden(time.Now()) || l.BidsHidden(end.Add(time.Second)) {
+		t.Error("Expected sealed bids hidden only until the auction ends")
+	}
+	if (&Listing{AuctionType: English, AuctionEndTime: end}).BidsHidden(time.Now()) {
+		t.Error("Expected English auction bids to be visible")
+	}
+}
+
+func TestDutchSchedule(t *testing.T) {
+	usd := func(minor int64) money.Money { return money.Money{Amount: minor, Currency: "USD"} }
+	startAt := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &DutchSchedule{Decrement: usd(1000), IntervalMinutes: 30, Floor: usd(2500)}
+	start := usd(5000)
+
+	for _, tc := range []struct {
+		at   time.Duration
+		want int64
+		next time.Duration
+	}{
+		{-time.Hour, 5000, 30 * time.Minute},
+		{0, 5000, 30 * time.Minute},
+		{29 * time.Minute, 5000, 30 * time.Minute},
+		{30 * time.Minute, 4000, time.Hour},
+		{61 * time.Minute, 3000, 90 * time.Minute},
+		{90 * time.Minute, 2500, 0},
+		{24 * time.Hour, 2500, 0},
+	} {
+		now := startAt.Add(tc.at)
+		if got := s.Price(start, startAt, now); got.Amount != tc.want {
+			t.Errorf("At %v: price %d, want %d", tc.at, got.Amount, tc.want)
+		}
+		next := s.NextDrop(start, startAt, now)
+		if (tc.next == 0) != next.IsZero() || (tc.next != 0 && !next.Equal(startAt.Add(tc.next))) {
+			t.Errorf("At %v: next drop %v, want +%v", tc.at, next, tc.next)
+		}
+	}
+}
+
+func TestDutchInput(t *testing.T) {
+	base := `"title": "Sofa", "description": "Comfy", "category": "home", "images": ["a.jpg"], "location": "Austin, TX",
+		"starting_bid": 100, "auction_type": "dutch", "auction_start_time": "2030-01-01T12:00:00Z", "auction_end_time": "2030-01-02T12:00:00Z"`
+
+	in := decodeInput(t, `{`+base+`, "dutch_decrement": 5, "dutch_interval_minutes": 60, "dutch_floor": 40}`)
+	l, err := in.Build("seller1")
+	if err != nil {
+		t.Fatalf("Expected a valid Dutch listing, got %v", err)
+	}
+	if l.DutchSchedule == nil || l.DutchSchedule.Floor.Amount != 4000 {
+		t.Errorf("Expected the schedule floor in minor units, got %+v", l.DutchSchedule)
+	}
+
+	for name, extra := range map[string]string{
+		"missing decrement": `"dutch_interval_minutes": 60, "dutch_floor": 40`,
+		"floor too high":    `"dutch_decrement": 5, "dutch_interval_minutes": 60, "dutch_floor": 100`,
+		"missing interval":  `"dutch_decrement": 5, "dutch_floor": 40`,
+	} {
+		in := decodeInput(t, `{`+base+`, `+extra+`}`)
+		if _, err := in.Build("seller1"); err == nil {
+			t.Errorf("%s: expected Build to fail", name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package listings
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/geo"
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+// MaxPickupSlots caps the meet-up slots a seller can offer.
+const MaxPickupSlots = 20
+
+// Fulfilment is how a sold item can reach the buyer. Listings without it are
+// arranged between buyer and seller in messages.
+type Fulfilment struct {
+	Pickup   *Pickup   `json:"pickup,omitempty"`
+	Shipping *Shipping `json:"shipping,omitempty"`
+}
+
+// Pickup offers local collection in one of the seller's meet-up slots.
+type Pickup struct {
+	Slots []PickupSlot `json:"slots"`
+}
+
+// PickupSlot is a window in which the seller can meet the buyer.
+type PickupSlot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ShippingRate is how a shipping cost is worked out.
+type ShippingRate string
+
+const (
+	// FlatRate charges Cost wherever the buyer is.
+	FlatRate ShippingRate = "flat"
+	// CalculatedRate charges Cost plus PerKm for each km from the listing's
+	// location to the buyer's address.
+	CalculatedRate ShippingRate = "calculated"
+)
+
+// Shipping offers delivery to the buyer's address.
+type Shipping struct {
+	Rate  ShippingRate `json:"rate"`
+	Cost  money.Money  `json:"cost"`
+	PerKm money.Money  `json:"per_km,omitempty"`
+}
+
+// Quote returns the cost of shipping from one point to another. Calculated
+// rates charge each started km.
+func (s *Shipping) Quote(from, to geo.Point) money.Money {
+	if s.Rate != CalculatedRate {
+		return s.Cost
+	}
+	km := math.Ceil(geo.DistanceKm(from, to))
+	return s.Cost.Add(s.PerKm.Mul(int64(km)))
+}
+
+// upcoming returns a copy of f without pickup slots that ended before now,
+// or nil if no options are left.
+func (f *Fulfilment) upcoming(now time.Time) *Fulfilment {
+	if f == nil {
+		return nil
+	}
+	c := Fulfilment{Shipping: f.Shipping}
+	if f.Pickup != nil {
+		var slots []PickupSlot
+		for _, slot := range f.Pickup.Slots {
+			if slot.End.After(now) {
+				slots = append(slots, slot)
+			}
+		}
+		if len(slots) > 0 {
+			c.Pickup = &Pickup{Slots: slots}
+		}
+	}
+	if c.Pickup == nil && c.Shipping == nil {
+		return nil
+	}
+	return &c
+}
+
+// normalize checks f and puts its amounts in currency c.
+func (f *Fulfilment) normalize(c money.Currency) error {
+	if f.Pickup == nil && f.Shipping == nil {
+		return fmt.Errorf("fulfilment needs pickup or shipping")
+	}
+	if p := f.Pickup; p != nil {
+		if len(p.Slots) == 0 || len(p.Slots) > MaxPickupSlots {
+			return fmt.Errorf("pickup needs between 1 and %d slots", MaxPickupSlots)
+		}
+		for _, slot := range p.Slots {
+			if !slot.End.After(slot.Start) {
+				return fmt.Errorf("pickup slot end must be after its start")
+			}
+		}
+	}
+	if s := f.Shipping; s != nil {
+		var err error
+		if s.Cost, err = s.Cost.In(c); err != nil || s.Cost.Amount < 0 {
+			return fmt.Errorf("Invalid shipping cost for %s", c)
+		}
+		switch s.Rate {
+		case FlatRate:
+			s.PerKm = money.Money{}
+		case CalculatedRate:
+			if s.PerKm, err = s.PerKm.In(c); err != nil || !s.PerKm.IsPositive() {
+				return fmt.Errorf("Calculated shipping needs a positive per_km for %s", c)
+			}
+		default:
+			return fmt.Errorf("shipping rate must be %q or %q", FlatRate, CalculatedRate)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package listings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/geo"
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+func TestFulfilmentInput(t *testing.T) {
+	start := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	slot := PickupSlot{Start: start, End: start.Add(time.Hour)}
+
+	for _, tc := range []struct {
+		name string
+		f    Fulfilment
+		ok   bool
+	}{
+		{"pickup", Fulfilment{Pickup: &Pickup{Slots: []PickupSlot{slot}}}, true},
+		{"flat shipping", Fulfilment{Shipping: &Shipping{Rate: FlatRate, Cost: money.New(500, "USD")}}, true},
+		{"calculated shipping", Fulfilment{Shipping: &Shipping{Rate: CalculatedRate, Cost: money.New(200, "USD"), PerKm: money.New(10, "USD")}}, true},
+		{"nothing offered", Fulfilment{}, false},
+		{"no slots", Fulfilment{Pickup: &Pickup{}}, false},
+		{"backwards slot", Fulfilment{Pickup: &Pickup{Slots: []PickupSlot{{Start: slot.End, End: slot.Start}}}}, false},
+		{"calculated without per_km", Fulfilment{Shipping: &Shipping{Rate: CalculatedRate, Cost: money.New(200, "USD")}}, false},
+		{"negative cost", Fulfilment{Shipping: &Shipping{Rate: FlatRate, Cost: money.New(-1, "USD")}}, false},
+		{"unknown rate", Fulfilment{Shipping: &Shipping{Rate: "free"}}, false},
+	} {
+		if err := tc.f.normalize("USD"); (err == nil) != tc.ok {
+			t.Errorf("%s: got error %v, want ok=%v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+func TestShippingQuote(t *testing.T) {
+	from := geo.Point{Lat: 29.65, Lng: -82.32}
+	to := geo.Point{Lat: 29.65, Lng: -82.20}
+
+	flat := Shipping{Rate: FlatRate, Cost: money.New(500, "USD")}
+	if got := flat.Quote(from, to); got.Amount != 500 {
+		t.Errorf("Expected flat shipping to cost 500, got %v", got)
+	}
+
+	// About 11.6km, charged as 12 started km
+	calculated := Shipping{Rate: CalculatedRate, Cost: money.New(200, "USD"), PerKm: money.New(10, "USD")}
+	if got := calculated.Quote(from, to); got.Amount != 320 {
+		t.Errorf("Expected calculated shipping to cost 320, got %v", got)
+	}
+}
+
+func TestFulfilmentUpcoming(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	past := PickupSlot{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}
+	future := PickupSlot{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}
+
+	f := &Fulfilment{Pickup: &Pickup{Slots: []PickupSlot{past, future}}}
+	if got := f.upcoming(now); got == nil || len(got.Pickup.Slots) != 1 || got.Pickup.Slots[0] != future {
+		t.Errorf("Expected only the future slot, got %+v", got)
+	}
+	if got := (&Fulfilment{Pickup: &Pickup{Slots: []PickupSlot{past}}}).upcoming(now); got != nil {
+		t.Errorf("Expected no options once every slot passed, got %+v", got)
+	}
+}
@@ -0,0 +1,237 @@
+package listings
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/categories"
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+// Input is a listing as submitted by a seller, before validation. Drafts
+// store it as-is, so every field may be missing.
+type Input struct {
+	Title       string            `json:"title"`
+	Subtitle    string            `json:"subtitle"`
+	Description string            `json:"description"`
+	Category    string            `json:"category"`
+	Subcategory string            `json:"subcategory"`
+	Condition   string            `json:"condition"`
+	Brand       string            `json:"brand"`
+	Color       string            `json:"color"`
+	Size        string            `json:"size"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	Images      []string          `json:"images"`
+	StartingBid *money.Money      `json:"starting_bid,omitempty"`
+	BuyNowPrice *money.Money      `json:"buy_now_price,omitempty"`
+	Currency    string            `json:"currency"`
+	AuctionType string            `json:"auction_type,omitempty"`
+	// Dutch auction schedule
+	DutchDecrement       *money.Money `json:"dutch_decrement,omitempty"`
+	DutchIntervalMinutes int          `json:"dutch_interval_minutes,omitempty"`
+	DutchFloor           *money.Money `json:"dutch_floor,omitempty"`
+	// Multi-quantity listings
+	Quantity int    `json:"quantity,omitempty"`
+	Pricing  string `json:"pricing,omitempty"`
+	// AcceptsOffers turns on best-offer mode
+	AcceptsOffers bool `json:"accepts_offers,omitempty"`
+	// Fulfilment lists the pickup and shipping options
+	Fulfilment       *Fulfilment `json:"fulfilment,omitempty"`
+	AuctionStartTime string      `json:"auction_start_time"`
+	AuctionEndTime   string      `json:"auction_end_time"`
+	Location         string      `json:"location"`
+	Notes            string      `json:"notes"`
+}
+
+// ErrMissingFields is returned by Build when required fields are empty.
+var ErrMissingFields = fmt.Errorf("Missing required fields")
+
+// Missing lists the required fields that are still empty.
+func (in *Input) Missing() []string {
+	var missing []string
+	check := func(field string, empty bool) {
+		if empty {
+			missing = append(missing, field)
+		}
+	}
+	check("title", strings.TrimSpace(in.Title) == "")
+	check("description", strings.TrimSpace(in.Description) == "")
+	check("category", in.Category == "")
+	check("images", len(in.Images) == 0)
+	check("starting_bid", in.StartingBid == nil || in.StartingBid.IsZero())
+	check("auction_end_time", in.AuctionEndTime == "")
+	check("location", strings.TrimSpace(in.Location) == "")
+	return missing
+}
+
+// Check validates the fields that are present, ignoring missing ones. It is
+// what draft autosaves run.
+func (in *Input) Check() error {
+	_, err := in.build("", true)
+	return err
+}
+
+// Build validates every field and returns the listing for sellerID.
+func (in *Input) Build(sellerID string) (*Listing, error) {
+	if len(in.Missing()) > 0 {
+		return nil, ErrMissingFields
+	}
+	return in.build(sellerID, false)
+}
+
+func (in *Input) build(sellerID string, partial bool) (*Listing, error) {
+	l := &Listing{
+		Title:       in.Title,
+		Subtitle:    in.Subtitle,
+		Description: in.Description,
+		Brand:       in.Brand,
+		Color:       in.Color,
+		Size:        in.Size,
+		Images:      in.Images,
+		Location:    in.Location,
+		Notes:       in.Notes,
+		SellerID:    sellerID,
+	}
+
+	// Check the classification against the category schema. Drafts may not
+	// have picked a category yet, or filled in its attributes.
+	if in.Category != "" {
+		class, err := categories.Current().Validate(categories.Input{
+			Category:    in.Category,
+			Subcategory: in.Subcategory,
+			Condition:   in.Condition,
+			Attributes:  in.Attributes,
+			Size:        in.Size,
+			Partial:     partial,
+		})
+		if err != nil {
+			return nil, err
+		}
+		l.Category, l.Subcategory, l.Condition = class.Category, class.Subcategory, class.Condition
+		l.Size, l.Attributes = class.Size, class.Attributes
+	}
+
+	l.Currency = money.DefaultCurrency
+	if in.Currency != "" {
+		c, err := money.ParseCurrency(in.Currency)
+		if err != nil {
+			return nil, err
+		}
+		l.Currency = c
+	}
+
+	auctionType, err := ParseAuctionType(in.AuctionType)
+	if err != nil {
+		return nil, err
+	}
+	l.AuctionType = auctionType
+
+	if in.StartingBid != nil {
+		startingBid, err := in.StartingBid.In(l.Currency)
+		if err != nil || !startingBid.IsPositive() {
+			return nil, fmt.Errorf("Invalid starting_bid for %s", l.Currency)
+		}
+		l.StartingBid = startingBid
+	}
+	if in.BuyNowPrice != nil {
+		p, err := in.BuyNowPrice.In(l.Currency)
+		if err != nil || (in.StartingBid != nil && p.Cmp(l.StartingBid) <= 0) {
+			return nil, fmt.Errorf("buy_now_price must be greater than starting_bid")
+		}
+		l.BuyNowPrice = &p
+	}
+	if (l.AuctionType.Sealed() || l.AuctionType == Dutch) && l.BuyNowPrice != nil {
+		return nil, fmt.Errorf("%s auctions can't have a buy_now_price", l.AuctionType)
+	}
+
+	if in.AuctionEndTime != "" {
+		if l.AuctionEndTime, err = time.Parse(time.RFC3339, in.AuctionEndTime); err != nil {
+			return nil, fmt.Errorf("Invalid auction_end_time format (must be RFC3339)")
+		}
+	}
+	if in.AuctionStartTime != "" {
+		if l.AuctionStartTime, err = time.Parse(time.RFC3339, in.AuctionStartTime); err != nil {
+			return nil, fmt.Errorf("Invalid auction_start_time format (must be RFC3339)")
+		}
+	}
+	if !l.AuctionStartTime.IsZero() && !l.AuctionEndTime.IsZero() && !l.AuctionEndTime.After(l.AuctionStartTime) {
+		return nil, fmt.Errorf("auction_end_time must be after auction_start_time")
+	}
+	if l.AuctionType == Dutch {
+		if err := in.buildDutch(l, partial); err != nil {
+			return nil, err
+		}
+	}
+
+	if in.Quantity < 0 || in.Quantity > MaxQuantity {
+		return nil, fmt.Errorf("quantity must be between 1 and %d", MaxQuantity)
+	}
+	if in.Quantity > 1 {
+		if l.AuctionType != English {
+			return nil, fmt.Errorf("Only English auctions can sell more than one unit")
+		}
+		if l.BuyNowPrice != nil {
+			return nil, fmt.Errorf("Multi-quantity listings can't have a buy_now_price")
+		}
+		if l.Pricing, err = ParsePricing(in.Pricing); err != nil {
+			return nil, err
+		}
+		l.Quantity = in.Quantity
+	}
+
+	if in.AcceptsOffers {
+		if l.AuctionType.Sealed() || l.Quantity > 1 {
+			return nil, fmt.Errorf("Best offers need a single-unit, open auction")
+		}
+		l.AcceptsOffers = true
+	}
+
+	if in.Fulfilment != nil {
+		f := *in.Fulfilment
+		if err := f.normalize(l.Currency); err != nil {
+			return nil, err
+		}
+		l.Fulfilment = &f
+	}
+	return l, nil
+}
+
+// buildDutch validates the price schedule of a Dutch auction. The price
+// drops from the starting bid, so the schedule needs a start time.
+func (in *Input) buildDutch(l *Listing, partial bool) error {
+	s := &DutchSchedule{IntervalMinutes: in.DutchIntervalMinutes}
+	if in.DutchDecrement != nil {
+		d, err := in.DutchDecrement.In(l.Currency)
+		if err != nil || !d.IsPositive() {
+			return fmt.Errorf("Invalid dutch_decrement for %s", l.Currency)
+		}
+		s.Decrement = d
+	}
+	if in.DutchFloor != nil {
+		f, err := in.DutchFloor.In(l.Currency)
+		if err != nil || !f.IsPositive() || (in.StartingBid != nil && f.Cmp(l.StartingBid) >= 0) {
+			return fmt.Errorf("dutch_floor must be positive and below starting_bid")
+		}
+		s.Floor = f
+	}
+	if in.DutchIntervalMinutes < 0 {
+		return fmt.Errorf("dutch_interval_minutes must be positive")
+	}
+	l.DutchSchedule = s
+	if partial {
+		return nil
+	}
+
+	switch {
+	case in.DutchDecrement == nil:
+		return fmt.Errorf("Dutch auctions need a dutch_decrement")
+	case in.DutchFloor == nil:
+		return fmt.Errorf("Dutch auctions need a dutch_floor")
+	case in.DutchIntervalMinutes == 0:
+		return fmt.Errorf("Dutch auctions need a dutch_interval_minutes")
+	case l.AuctionStartTime.IsZero():
+		return fmt.Errorf("Dutch auctions need an auction_start_time")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package listings
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func decodeInput(t *testing.T, s string) Input {
+	t.Helper()
+	var in Input
+	if err := json.Unmarshal([]byte(s), &in); err != nil {
+		t.Fatalf("Invalid input JSON: %v", err)
+	}
+	return in
+}
+
+func TestInputCheckIsPartial(t *testing.T) {
+	// A half-filled form: no images or end time yet, phone storage not chosen
+	in := decodeInput(t, `{"title": "Phone", "category": "electronics", "subcategory": "mobile-phones", "starting_bid": 25}`)
+	if err := in.Check(); err != nil {
+		t.Errorf("Expected partial input to pass Check, got %v", err)
+	}
+	if missing := in.Missing(); len(missing) != 4 {
+		t.Errorf("Expected description, images, auction_end_time and location missing, got %v", missing)
+	}
+	if _, err := in.Build("seller1"); !errors.Is(err, ErrMissingFields) {
+		t.Errorf("Expected Build to reject missing fields, got %v", err)
+	}
+
+	bad := decodeInput(t, `{"starting_bid": 25.123}`)
+	if err := bad.Check(); err == nil {
+		t.Errorf("Expected Check to reject a present but invalid starting_bid")
+	}
+}
+
+func TestInputBuild(t *testing.T) {
+	in := decodeInput(t, `{"title": "Phone", "description": "Works", "category": "Electronics",
+		"subcategory": "Mobile phones", "attributes": {"storage": "128gb"}, "images": ["a.jpg"],
+		"starting_bid": 25, "buy_now_price": 100, "currency": "eur",
+		"auction_start_time": "2050-01-01T00:00:00Z", "auction_end_time": "2050-01-02T00:00:00Z",
+		"location": "Gainesville, FL"}`)
+	l, err := in.Build("seller1")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if l.SellerID != "seller1" || l.Currency != "EUR" || l.StartingBid.Amount != 2500 || l.Attributes["storage"] != "128GB" {
+		t.Errorf("Unexpected listing: %+v", l)
+	}
+
+	in.AuctionEndTime = "2049-12-31T00:00:00Z"
+	if _, err := in.Build("seller1"); err == nil {
+		t.Errorf("Expected an end time before the start time to be rejected")
+	}
+	in.AuctionEndTime = "2050-01-02T00:00:00Z"
+	in.Attributes = nil
+	if _, err := in.Build("seller1"); err == nil {
+		t.Errorf("Expected the required storage attribute to be enforced on Build")
+	}
+}
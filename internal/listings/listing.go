@@ -2,32 +2,150 @@ package listings
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
+
+	"github.com/quickswap/quickswap/internal/geo"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/supabase"
 )
 
+// ErrNotFound is returned when no listing matches the requested ID.
+var ErrNotFound = fmt.Errorf("listing not found")
+
 type Listing struct {
-	ID               string    `json:"id,omitempty"`
-	Title            string    `json:"title"`
-	Subtitle         string    `json:"subtitle"`
-	Description      string    `json:"description"`
-	Category         string    `json:"category"`
-	Subcategory      string    `json:"subcategory"`
-	Condition        string    `json:"condition"`
-	Brand            string    `json:"brand"`
-	Color            string    `json:"color"`
-	Size             string    `json:"size"`
-	Images           []string  `json:"images"`
-	StartingBid      float64   `json:"starting_bid"`
-	BuyNowPrice      *float64  `json:"buy_now_price,omitempty"`
-	AuctionStartTime time.Time `json:"auction_start_time"`
-	AuctionEndTime   time.Time `json:"auction_end_time"`
-	Location         string    `json:"location"`
-	Notes            string    `json:"notes"`
-	SellerID         string    `json:"seller_id"`
+	ID          string `json:"id,omitempty"`
+	Title       string `json:"title"`
+	Subtitle    string `json:"subtitle"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Subcategory string `json:"subcategory"`
+	Condition   string `json:"condition"`
+	Brand       string `json:"brand"`
+	Color       string `json:"color"`
+	Size        string `json:"size"`
+	// Attributes holds the category-specific fields, e.g. storage for phones
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	Images      []string          `json:"images"`
+	StartingBid money.Money       `json:"starting_bid"`
+	BuyNowPrice *money.Money      `json:"buy_now_price,omitempty"`
+	Currency    money.Currency    `json:"currency"`
+	AuctionType AuctionType       `json:"auction_type,omitempty"`
+	// DutchSchedule is set for Dutch auctions
+	*DutchSchedule
+	// Quantity identical units are sold, winners paying per Pricing
+	Quantity int     `json:"quantity,omitempty"`
+	Pricing  Pricing `json:"pricing,omitempty"`
+	// AcceptsOffers lets buyers make private best offers
+	AcceptsOffers bool `json:"accepts_offers,omitempty"`
+	// Fulfilment lists the pickup and shipping options offered to the buyer
+	Fulfilment       *Fulfilment `json:"fulfilment,omitempty"`
+	AuctionStartTime time.Time   `json:"auction_start_time"`
+	AuctionEndTime   time.Time   `json:"auction_end_time"`
+	Location         string      `json:"location"`
+	Notes            string      `json:"notes"`
+	SellerID         string      `json:"seller_id"`
+
+	// Geocoded location. Coordinates are fuzzed before they are stored and
+	// LocationArea is the coarse place name shown to buyers.
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+	LocationArea string   `json:"location_area,omitempty"`
+
+	// SearchAlertedAt is set once the listing has been matched against
+	// saved searches.
+	SearchAlertedAt *time.Time `json:"search_alerted_at,omitempty"`
+	// UpdatedAt is when the seller last edited the listing, if ever.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+
+	// Settlement state, filled in once the auction closes
+	Status     string       `json:"status,omitempty"`
+	WinnerID   *string      `json:"winner_id,omitempty"`
+	FinalPrice *money.Money `json:"final_price,omitempty"`
+	SettledAt  *time.Time   `json:"settled_at,omitempty"`
+}
+
+// UnmarshalJSON decodes a listing and puts its amounts in the listing's
+// currency. Rows written before listings had a currency default to USD, and
+// rows written before auction_type default to English.
+func (l *Listing) UnmarshalJSON(b []byte) error {
+	type plain Listing
+	if err := json.Unmarshal(b, (*plain)(l)); err != nil {
+		return err
+	}
+	if l.Currency == "" {
+		l.Currency = money.DefaultCurrency
+	}
+	if l.AuctionType == "" {
+		l.AuctionType = English
+	}
+	if l.AuctionType != Dutch {
+		// Null schedule columns still allocate the embedded struct
+		l.DutchSchedule = nil
+	}
+
+	// Stored amounts are rounded rather than checked, so float noise in
+	// older rows can't fail every read of the table
+	l.StartingBid = l.StartingBid.Round(l.Currency)
+	amounts := []*money.Money{l.BuyNowPrice, l.FinalPrice}
+	if l.DutchSchedule != nil {
+		amounts = append(amounts, &l.DutchSchedule.Decrement, &l.DutchSchedule.Floor)
+	}
+	if l.Fulfilment != nil && l.Fulfilment.Shipping != nil {
+		amounts = append(amounts, &l.Fulfilment.Shipping.Cost, &l.Fulfilment.Shipping.PerKm)
+	}
+	for _, m := range amounts {
+		if m == nil {
+			continue
+		}
+		*m = m.Round(l.Currency)
+	}
+	return nil
+}
+
+// Geocode resolves l.Location with g and records the fuzzed coordinates and
+// area name.
+func (l *Listing) Geocode(ctx context.Context, g geo.Geocoder) error {
+	place, err := g.Geocode(ctx, l.Location)
+	if err != nil {
+		return err
+	}
+	p := geo.Fuzz(place.Point)
+	l.Latitude, l.Longitude = &p.Lat, &p.Lng
+	l.LocationArea = place.Name
+	return nil
+}
+
+// Point returns the listing's fuzzed coordinates, if it has been geocoded.
+func (l *Listing) Point() (geo.Point, bool) {
+	if l.Latitude == nil || l.Longitude == nil {
+		return geo.Point{}, false
+	}
+	return geo.Point{Lat: *l.Latitude, Lng: *l.Longitude}, true
+}
+
+// PublicLocation is the location shown to buyers: the geocoded area rather
+// than whatever address the seller typed. It is empty when geocoding failed,
+// since the typed location may be a street address.
+func (l *Listing) PublicLocation() string {
+	return l.LocationArea
+}
+
+// Get fetches a single listing by ID from the Supabase listings table.
+func Get(id string) (*Listing, error) {
+	var rows []Listing
+	if err := supabase.Select("listings", "id=eq."+url.QueryEscape(id), &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return &rows[0], nil
 }
 
 // CreateListing inserts a new listing into the Supabase listings table.
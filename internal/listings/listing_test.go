@@ -0,0 +1,29 @@
+package listings
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestListingDecodesFloatNoise(t *testing.T) {
+	var ls []Listing
+	rows := `[{"id": "a", "starting_bid": 10.300000000000001, "currency": "USD"}, {"id": "b", "starting_bid": 5, "final_price": 7.0000001, "currency": "JPY"}]`
+	if err := json.Unmarshal([]byte(rows), &ls); err != nil {
+		t.Fatalf("Expected stored rows with float noise to decode, got %v", err)
+	}
+	if ls[0].StartingBid.Amount != 1030 || ls[1].FinalPrice == nil || ls[1].FinalPrice.Amount != 7 {
+		t.Errorf("Unexpected amounts %v and %v", ls[0].StartingBid, ls[1].FinalPrice)
+	}
+}
+
+func TestPublicLocation(t *testing.T) {
+	l := Listing{Location: "12 Elm Street, Ocala, FL", LocationArea: "Ocala, FL"}
+	if got := l.PublicLocation(); got != "Ocala, FL" {
+		t.Errorf("Expected the geocoded area, got %q", got)
+	}
+	// Geocoding failed, so only the seller's typed address is known
+	l.LocationArea = ""
+	if got := l.PublicLocation(); got != "" {
+		t.Errorf("Expected no public location when geocoding failed, got %q", got)
+	}
+}
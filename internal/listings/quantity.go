@@ -0,0 +1,104 @@
+package listings
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+// MaxQuantity caps how many identical units one listing may offer.
+const MaxQuantity = 1000
+
+// Pricing is how winners of a multi-quantity listing pay.
+type Pricing string
+
+const (
+	// Uniform charges every winner the lowest winning bid.
+	Uniform Pricing = "uniform"
+	// PayAsBid charges every winner what they bid.
+	PayAsBid Pricing = "pay_as_bid"
+)
+
+// ParsePricing validates a pricing rule; "" means Uniform.
+func ParsePricing(s string) (Pricing, error) {
+	switch p := Pricing(s); p {
+	case "":
+		return Uniform, nil
+	case Uniform, PayAsBid:
+		return p, nil
+	}
+	return "", fmt.Errorf("Invalid pricing %q", s)
+}
+
+// Units is how many units the listing offers; listings without a quantity
+// offer one.
+func (l *Listing) Units() int {
+	if l.Quantity < 1 {
+		return 1
+	}
+	return l.Quantity
+}
+
+// UnitBid is a standing bid for one or more units at a per-unit amount.
+type UnitBid struct {
+	UserID   string
+	Amount   money.Money
+	Quantity int
+	Sequence int64
+}
+
+// Award is what one bidder wins.
+type Award struct {
+	UserID    string      `json:"user_id"`
+	Requested int         `json:"requested"`
+	Quantity  int         `json:"quantity"`
+	UnitPrice money.Money `json:"unit_price"`
+}
+
+// Allocate ranks bids by amount, earlier bids first on ties, and hands the
+// units out from the top until they run out; the last winner may get fewer
+// units than they asked for. Bidders who win nothing are left out.
+func Allocate(units int, pricing Pricing, bids []UnitBid) []Award {
+	ranked := append([]UnitBid(nil), bids...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if c := ranked[i].Amount.Cmp(ranked[j].Amount); c != 0 {
+			return c > 0
+		}
+		return ranked[i].Sequence < ranked[j].Sequence
+	})
+
+	var awards []Award
+	for _, b := range ranked {
+		if units == 0 {
+			break
+		}
+		q := max(b.Quantity, 1)
+		won := min(q, units)
+		units -= won
+		awards = append(awards, Award{UserID: b.UserID, Requested: q, Quantity: won, UnitPrice: b.Amount})
+	}
+
+	if pricing != PayAsBid && len(awards) > 0 {
+		clearing := awards[len(awards)-1].UnitPrice
+		for i := range awards {
+			awards[i].UnitPrice = clearing
+		}
+	}
+	return awards
+}
+
+// PriceToBeat is the per-unit amount a new bid must exceed to win a unit:
+// the lowest winning bid once every unit is taken. It reports false while
+// units are still free.
+func PriceToBeat(units int, bids []UnitBid) (money.Money, bool) {
+	awards := Allocate(units, PayAsBid, bids)
+	taken := 0
+	for _, a := range awards {
+		taken += a.Quantity
+	}
+	if taken < units {
+		return money.Money{}, false
+	}
+	return awards[len(awards)-1].UnitPrice, true
+}
@@ -0,0 +1,67 @@
+package listings
+
+import (
+	"testing"
+
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+func TestAllocate(t *testing.T) {
+	usd := func(minor int64) money.Money { return money.Money{Amount: minor, Currency: "USD"} }
+	bids := []UnitBid{
+		{UserID: "a", Amount: usd(1000), Quantity: 2, Sequence: 1},
+		{UserID: "b", Amount: usd(1500), Quantity: 2, Sequence: 2},
+		{UserID: "c", Amount: usd(1000), Quantity: 3, Sequence: 3},
+		{UserID: "d", Amount: usd(900), Quantity: 1, Sequence: 4},
+	}
+
+	// 5 units: b gets 2, a wins the tie with c for 2, c is left with 1
+	awards := Allocate(5, Uniform, bids)
+	want := []struct {
+		user string
+		qty  int
+	}{{"b", 2}, {"a", 2}, {"c", 1}}
+	if len(awards) != len(want) {
+		t.Fatalf("Expected %d awards, got %+v", len(want), awards)
+	}
+	for i, w := range want {
+		if awards[i].UserID != w.user || awards[i].Quantity != w.qty {
+			t.Errorf("Award %d: got %s x%d, want %s x%d", i, awards[i].UserID, awards[i].Quantity, w.user, w.qty)
+		}
+		if !awards[i].UnitPrice.Equal(usd(1000)) {
+			t.Errorf("Expected uniform price of 10.00, got %v", awards[i].UnitPrice)
+		}
+	}
+	if awards[2].Requested != 3 {
+		t.Errorf("Expected the partial winner to have requested 3, got %d", awards[2].Requested)
+	}
+
+	if paid := Allocate(5, PayAsBid, bids); !paid[0].UnitPrice.Equal(usd(1500)) {
+		t.Errorf("Expected pay-as-bid to charge b 15.00, got %v", paid[0].UnitPrice)
+	}
+
+	if toBeat, full := PriceToBeat(5, bids); !full || !toBeat.Equal(usd(1000)) {
+		t.Errorf("Expected 10.00 to beat, got %v (full %v)", toBeat, full)
+	}
+	if _, full := PriceToBeat(10, bids); full {
+		t.Error("Expected free units when demand is below supply")
+	}
+}
+
+func TestQuantityInput(t *testing.T) {
+	in := decodeInput(t, `{"quantity": 5, "pricing": "pay_as_bid", "starting_bid": 5}`)
+	if err := in.Check(); err != nil {
+		t.Errorf("Expected a multi-quantity input to pass, got %v", err)
+	}
+	for name, body := range map[string]string{
+		"bad pricing":  `{"quantity": 5, "pricing": "auction"}`,
+		"sealed":       `{"quantity": 5, "auction_type": "sealed_first_price"}`,
+		"too many":     `{"quantity": 5000}`,
+		"with buy now": `{"quantity": 5, "starting_bid": 5, "buy_now_price": 10}`,
+	} {
+		in := decodeInput(t, body)
+		if err := in.Check(); err == nil {
+			t.Errorf("%s: expected Check to fail", name)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package listings
+
+import "time"
+
+// defaultRelistDuration is used when the original auction's length is unknown.
+const defaultRelistDuration = 7 * 24 * time.Hour
+
+// Relist returns a copy of l to list again from now, running as long as the
+// original auction did. The ID, bids and settlement state are not copied, nor
+// are pickup slots that have already ended.
+func (l *Listing) Relist(now time.Time) *Listing {
+	d := l.AuctionEndTime.Sub(l.AuctionStartTime)
+	if l.AuctionStartTime.IsZero() || d <= 0 {
+		d = defaultRelistDuration
+	}
+
+	c := *l
+	c.ID = ""
+	c.AuctionStartTime = now
+	c.AuctionEndTime = now.Add(d)
+	c.SearchAlertedAt = nil
+	c.UpdatedAt = nil
+	c.Status = ""
+	c.WinnerID = nil
+	c.FinalPrice = nil
+	c.SettledAt = nil
+	if l.DutchSchedule != nil {
+		schedule := *l.DutchSchedule
+		c.DutchSchedule = &schedule
+	}
+	c.Fulfilment = l.Fulfilment.upcoming(now)
+	return &c
+}
@@ -0,0 +1,58 @@
+package listings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+func TestRelist(t *testing.T) {
+	start := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	winner := "winner"
+	final := money.New(4000, "USD")
+	settled := start.Add(72 * time.Hour)
+	l := Listing{
+		ID:               "old",
+		Title:            "Lamp",
+		StartingBid:      money.New(1000, "USD"),
+		Currency:         "USD",
+		AuctionStartTime: start,
+		AuctionEndTime:   start.Add(72 * time.Hour),
+		SellerID:         "seller",
+		Status:           "sold",
+		WinnerID:         &winner,
+		FinalPrice:       &final,
+		SettledAt:        &settled,
+		DutchSchedule:    &DutchSchedule{IntervalMinutes: 60},
+	}
+
+	now := start.Add(10 * 24 * time.Hour)
+	c := l.Relist(now)
+	if c.ID != "" || c.Status != "" || c.WinnerID != nil || c.FinalPrice != nil || c.SettledAt != nil {
+		t.Errorf("Expected settlement state to be cleared, got %+v", c)
+	}
+	if !c.AuctionStartTime.Equal(now) || c.AuctionEndTime.Sub(c.AuctionStartTime) != 72*time.Hour {
+		t.Errorf("Expected a 72h auction from now, got %v to %v", c.AuctionStartTime, c.AuctionEndTime)
+	}
+	if c.Title != l.Title || c.SellerID != l.SellerID || !c.StartingBid.Equal(l.StartingBid) {
+		t.Errorf("Expected the item details to be copied, got %+v", c)
+	}
+	c.DutchSchedule.IntervalMinutes = 5
+	if l.DutchSchedule.IntervalMinutes != 60 {
+		t.Error("Expected the relisted Dutch schedule to be a copy")
+	}
+	if l.ID != "old" || l.Status != "sold" {
+		t.Error("Expected the original listing to be unchanged")
+	}
+}
+
+func TestRelistWithoutStartTime(t *testing.T) {
+	now := time.Date(2030, 1, 11, 12, 0, 0, 0, time.UTC)
+	// Older listings were stored without auction_start_time
+	l := Listing{ID: "old", AuctionEndTime: time.Date(2030, 1, 4, 12, 0, 0, 0, time.UTC)}
+	c := l.Relist(now)
+	if got := c.AuctionEndTime.Sub(c.AuctionStartTime); got != defaultRelistDuration {
+		t.Errorf("Expected the default %v auction, got %v", defaultRelistDuration, got)
+	}
+}
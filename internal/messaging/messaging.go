@@ -0,0 +1,128 @@
+package messaging
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/moderation"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// MaxBodyLength caps the size of a single message.
+const MaxBodyLength = 2000
+
+var (
+	// ErrLocked is returned while the conversation is not open yet.
+	ErrLocked = fmt.Errorf("conversation opens once the auction has closed with a winner")
+	// ErrForbidden is returned to anyone other than the seller and winner.
+	ErrForbidden = fmt.Errorf("only the seller and winning bidder can access this conversation")
+	// ErrEmpty is returned for blank or oversized messages.
+	ErrEmpty = fmt.Errorf("message must be between 1 and %d characters", MaxBodyLength)
+)
+
+// Message is a single entry in a listing's buyer–seller conversation.
+type Message struct {
+	ID          string    `json:"id,omitempty"`
+	ListingID   string    `json:"listing_id"`
+	SenderID    string    `json:"sender_id"`
+	RecipientID string    `json:"recipient_id"`
+	Body        string    `json:"body"`
+	Redacted    bool      `json:"redacted"`
+	Read        bool      `json:"read"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Counterparty checks that userID may take part in the conversation about l
+// and returns the other participant.
+func Counterparty(l *listing.Listing, userID string) (string, error) {
+	if l.SettledAt == nil || l.WinnerID == nil || *l.WinnerID == "" {
+		return "", ErrLocked
+	}
+	switch userID {
+	case l.SellerID:
+		return *l.WinnerID, nil
+	case *l.WinnerID:
+		return l.SellerID, nil
+	default:
+		return "", ErrForbidden
+	}
+}
+
+// saleFinal reports whether the sale is complete, after which buyer and
+// seller are free to exchange contact details.
+func saleFinal(l *listing.Listing) bool {
+	return l.Status == "completed"
+}
+
+// Send stores a message from senderID about l.
+func Send(l *listing.Listing, senderID, body string) (*Message, error) {
+	recipientID, err := Counterparty(l, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" || len(body) > MaxBodyLength {
+		return nil, ErrEmpty
+	}
+
+	redacted := false
+	if !saleFinal(l) {
+		body, redacted = moderation.RedactContactInfo(body)
+	}
+
+	msg := Message{
+		ListingID:   l.ID,
+		SenderID:    senderID,
+		RecipientID: recipientID,
+		Body:        body,
+		Redacted:    redacted,
+		CreatedAt:   time.Now().UTC(),
+	}
+	var inserted []Message
+	if err := supabase.Insert("messages", []Message{msg}, &inserted); err != nil {
+		return nil, fmt.Errorf("store message: %w", err)
+	}
+	if len(inserted) > 0 {
+		msg = inserted[0]
+	}
+	return &msg, nil
+}
+
+// Thread returns the conversation about listingID, oldest first.
+func Thread(listingID string) ([]Message, error) {
+	var thread []Message
+	query := "listing_id=eq." + url.QueryEscape(listingID) + "&order=created_at.asc"
+	if err := supabase.Select("messages", query, &thread); err != nil {
+		return nil, fmt.Errorf("fetch messages: %w", err)
+	}
+	return thread, nil
+}
+
+// MarkThreadRead marks every message in the conversation sent to userID as read.
+func MarkThreadRead(listingID, userID string) error {
+	query := "listing_id=eq." + url.QueryEscape(listingID) + "&recipient_id=eq." + url.QueryEscape(userID) + "&read=is.false"
+	if err := supabase.Update("messages", query, map[string]bool{"read": true}, nil); err != nil {
+		return fmt.Errorf("mark messages read: %w", err)
+	}
+	return nil
+}
+
+// UnreadCounts returns the number of unread messages for userID per listing.
+func UnreadCounts(userID string) (map[string]int, error) {
+	var rows []struct {
+		ListingID string `json:"listing_id"`
+	}
+	query := "recipient_id=eq." + url.QueryEscape(userID) + "&read=is.false&select=listing_id"
+	if err := supabase.Select("messages", query, &rows); err != nil {
+		return nil, fmt.Errorf("count unread messages: %w", err)
+	}
+	counts := make(map[string]int)
+	for _, r := range rows {
+		counts[r.ListingID]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,33 @@
+package moderation
+
+import "regexp"
+
+// Redacted replaces contact details removed from user-supplied text.
+const Redacted = "[contact details removed]"
+
+var (
+	emailPattern = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+\s*(@|\(at\)|\[at\])\s*[a-z0-9.\-]+\s*(\.|\(dot\)|\[dot\])\s*[a-z]{2,}`)
+	// Seven or more digits, optionally separated by spaces, dots, dashes or
+	// brackets, with an optional leading +.
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d\s().\-]{5,}\d`)
+)
+
+// RedactContactInfo removes email addresses and phone numbers from text so
+// buyers and sellers can't take the deal off-platform before a sale. It
+// reports whether anything was removed.
+func RedactContactInfo(text string) (string, bool) {
+	out := emailPattern.ReplaceAllString(text, Redacted)
+	out = phonePattern.ReplaceAllStringFunc(out, func(m string) string {
+		digits := 0
+		for _, r := range m {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits < 7 {
+			return m
+		}
+		return Redacted
+	})
+	return out, out != text
+}
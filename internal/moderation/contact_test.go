@@ -0,0 +1,24 @@
+package moderation
+
+import "testing"
+
+func TestRedactContactInfo(t *testing.T) {
+	tests := []struct {
+		in       string
+		redacted bool
+	}{
+		{"Is this still available?", false},
+		{"I can pick it up at 5pm on the 12th", false},
+		{"Email me at jane.doe@example.com", true},
+		{"jane (at) example (dot) com", true},
+		{"Call 555-123-4567 after work", true},
+		{"WhatsApp +44 7700 900123", true},
+		{"Size 42, bought in 2023 for 120", false},
+	}
+	for _, tt := range tests {
+		out, redacted := RedactContactInfo(tt.in)
+		if redacted != tt.redacted {
+			t.Errorf("RedactContactInfo(%q) = %q, redacted=%v, want %v", tt.in, out, redacted, tt.redacted)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+// DefaultCurrency is used for listings created without a currency.
+const DefaultCurrency Currency = "USD"
+
+// pendingExponent is the precision amounts are parsed at before their
+// currency is known. It must be at least the largest currency exponent.
+const pendingExponent = 3
+
+// exponents lists the supported currencies and their number of minor units.
+var exponents = map[Currency]int{
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"INR": 2,
+	"CAD": 2,
+	"AUD": 2,
+	"CHF": 2,
+	"CNY": 2,
+	"MXN": 2,
+	"SGD": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"BHD": 3,
+}
+
+var symbols = map[Currency]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"INR": "₹",
+	"JPY": "¥",
+}
+
+// ParseCurrency validates and normalizes an ISO 4217 code.
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := exponents[c]; !ok {
+		return "", fmt.Errorf("unsupported currency %q", code)
+	}
+	return c, nil
+}
+
+// Exponent returns the number of minor units of c.
+func (c Currency) Exponent() int {
+	if e, ok := exponents[c]; ok {
+		return e
+	}
+	return 2
+}
+
+// Money is an exact amount in a currency's minor units, e.g. cents.
+//
+// In JSON it is a plain decimal number in major units so it stays compatible
+// with numeric database columns and existing clients; the currency travels in
+// a sibling field. Values decoded from JSON have no currency until In is
+// called.
+type Money struct {
+	Amount   int64
+	Currency Currency
+	// inexact marks a decoded amount that had more decimal places than
+	// pendingExponent, such as float noise in an old row. In rejects it;
+	// Round accepts it.
+	inexact bool
+}
+
+// New returns amount minor units of c.
+func New(amount int64, c Currency) Money {
+	return Money{Amount: amount, Currency: c}
+}
+
+// exponent is the scale Amount is stored at.
+func (m Money) exponent() int {
+	if m.Currency == "" {
+		return pendingExponent
+	}
+	return m.Currency.Exponent()
+}
+
+// Parse reads a decimal string such as "15.5" as an amount of c. It fails if
+// the value has more decimal places than c allows.
+func Parse(s string, c Currency) (Money, error) {
+	exp := pendingExponent
+	if c != "" {
+		exp = c.Exponent()
+	}
+	amount, err := parseDecimal(s, exp)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: c}, nil
+}
+
+// FromFloat converts a float amount of c, rounding to the nearest minor unit.
+// It exists for boundaries that still hand out floats.
+func FromFloat(f float64, c Currency) Money {
+	scale := math.Pow10(c.Exponent())
+	return Money{Amount: int64(math.Round(f * scale)), Currency: c}
+}
+
+func parseDecimal(s string, exp int) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("invalid amount: empty")
+	}
+	neg := false
+	if s[0] == '-' || s[0] == '+' {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > exp {
+		return 0, fmt.Errorf("invalid amount %q: more than %d decimal places", s, exp)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	digits := whole + frac
+	if digits == "" {
+		digits = "0"
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	if neg {
+		n = -n
+	}
+	return n, nil
+}
+
+// In assigns currency c to an amount decoded without one, or checks that m
+// is already in c. It fails if m has more precision than c allows.
+func (m Money) In(c Currency) (Money, error) {
+	if m.Currency == c {
+		return m, nil
+	}
+	if m.Currency != "" {
+		return Money{}, fmt.Errorf("currency mismatch: %s amount where %s is required", m.Currency, c)
+	}
+	if m.inexact {
+		return Money{}, fmt.Errorf("invalid %s amount: more than %d decimal places", c, c.Exponent())
+	}
+	amount, err := rescale(m.Amount, pendingExponent, c.Exponent())
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid %s amount: %w", c, err)
+	}
+	return Money{Amount: amount, Currency: c}, nil
+}
+
+// Round is like In but rounds extra precision to the nearest minor unit
+// instead of failing. It is meant for amounts read back from storage.
+func (m Money) Round(c Currency) Money {
+	if m.Currency != "" {
+		return m
+	}
+	div := int64(math.Pow10(pendingExponent - c.Exponent()))
+	amount, rem := m.Amount/div, m.Amount%div
+	if 2*rem >= div {
+		amount++
+	} else if 2*rem <= -div {
+		amount--
+	}
+	return Money{Amount: amount, Currency: c}
+}
+
+func rescale(amount int64, from, to int) (int64, error) {
+	for ; from > to; from-- {
+		if amount%10 != 0 {
+			return 0, fmt.Errorf("more than %d decimal places", to)
+		}
+		amount /= 10
+	}
+	for ; from < to; from++ {
+		amount *= 10
+	}
+	return amount, nil
+}
+
+// IsZero reports whether m is zero.
+func (m Money) IsZero() bool { return m.Amount == 0 }
+
+// IsPositive reports whether m is greater than zero.
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+
+// Cmp compares two amounts of the same currency, returning -1, 0 or +1.
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.Amount < o.Amount:
+		return -1
+	case m.Amount > o.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Equal reports whether m and o are the same amount in the same currency.
+func (m Money) Equal(o Money) bool {
+	return m.Amount == o.Amount && m.Currency == o.Currency
+}
+
+// Add returns m+o. Both must share a currency.
+func (m Money) Add(o Money) Money {
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}
+}
+
+// Sub returns m-o. Both must share a currency.
+func (m Money) Sub(o Money) Money {
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}
+}
+
+// Mul returns m multiplied by n.
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Decimal formats m in major units with the currency's decimal places,
+// e.g. "15.00".
+func (m Money) Decimal() string {
+	exp := m.exponent()
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	s := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+// Float returns m in major units as a float, for display-only maths.
+func (m Money) Float() float64 {
+	return float64(m.Amount) / math.Pow10(m.exponent())
+}
+
+// String formats m for people, e.g. "$15.00" or "KWD 1.250".
+func (m Money) String() string {
+	if sym, ok := symbols[m.Currency]; ok {
+		if m.Amount < 0 {
+			return "-" + sym + Money{Amount: -m.Amount, Currency: m.Currency}.Decimal()
+		}
+		return sym + m.Decimal()
+	}
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return string(m.Currency) + " " + m.Decimal()
+}
+
+// MarshalJSON writes m as a decimal number in major units.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON reads a decimal number (or numeric string) in major units.
+// The result has no currency; call In once the currency is known, or Round
+// for stored amounts that may carry float noise.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	} else if strings.ContainsAny(s, "eE") {
+		// Expand exponent notation such as 1.5e2 to a plain decimal
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid amount %s", s)
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	amount, err := parseDecimal(s, pendingExponent)
+	if err != nil {
+		f, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return err
+		}
+		*m = Money{Amount: int64(math.Round(f * math.Pow10(pendingExponent))), inexact: true}
+		return nil
+	}
+	*m = Money{Amount: amount}
+	return nil
+}
@@ -0,0 +1,104 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAndFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		cur     Currency
+		amount  int64
+		decimal string
+		str     string
+	}{
+		{"15", "USD", 1500, "15.00", "$15.00"},
+		{"15.5", "USD", 1550, "15.50", "$15.50"},
+		{"0.07", "EUR", 7, "0.07", "€0.07"},
+		{"1500", "JPY", 1500, "1500", "¥1500"},
+		{"1.250", "KWD", 1250, "1.250", "KWD 1.250"},
+		{"-3.10", "GBP", -310, "-3.10", "-£3.10"},
+	}
+	for _, tt := range tests {
+		m, err := Parse(tt.in, tt.cur)
+		if err != nil {
+			t.Fatalf("Parse(%q, %s) failed: %v", tt.in, tt.cur, err)
+		}
+		if m.Amount != tt.amount {
+			t.Errorf("Parse(%q, %s) = %d minor units, want %d", tt.in, tt.cur, m.Amount, tt.amount)
+		}
+		if got := m.Decimal(); got != tt.decimal {
+			t.Errorf("Decimal() = %q, want %q", got, tt.decimal)
+		}
+		if got := m.String(); got != tt.str {
+			t.Errorf("String() = %q, want %q", got, tt.str)
+		}
+	}
+
+	if _, err := Parse("10.005", "USD"); err == nil {
+		t.Errorf("Expected error for sub-cent USD amount")
+	}
+	if _, err := Parse("1.5", "JPY"); err == nil {
+		t.Errorf("Expected error for fractional JPY amount")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var v struct {
+		Amount Money `json:"amount"`
+	}
+	for _, in := range []string{`{"amount": 19.99}`, `{"amount": "19.99"}`, `{"amount": 1.999e1}`} {
+		if err := json.Unmarshal([]byte(in), &v); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", in, err)
+		}
+		m, err := v.Amount.In("USD")
+		if err != nil {
+			t.Fatalf("In(USD) failed: %v", err)
+		}
+		if m.Amount != 1999 {
+			t.Errorf("Unmarshal(%s) = %d cents, want 1999", in, m.Amount)
+		}
+	}
+
+	b, err := json.Marshal(map[string]Money{"amount": New(1999, "USD")})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(b) != `{"amount":19.99}` {
+		t.Errorf("Marshal = %s, want {\"amount\":19.99}", b)
+	}
+}
+
+func TestFloatEqualityPitfall(t *testing.T) {
+	// 0.1 + 0.2 != 0.3 in float64, but must be equal as money
+	a, _ := Parse("0.1", "USD")
+	b, _ := Parse("0.2", "USD")
+	c, _ := Parse("0.3", "USD")
+	if !a.Add(b).Equal(c) {
+		t.Errorf("Expected 0.10 + 0.20 to equal 0.30")
+	}
+}
+
+func TestRound(t *testing.T) {
+	var m Money
+	if err := json.Unmarshal([]byte(`10.005`), &m); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got := m.Round("USD"); got.Amount != 1001 {
+		t.Errorf("Round(10.005, USD) = %d cents, want 1001", got.Amount)
+	}
+	if got := m.Round("JPY"); got.Amount != 10 {
+		t.Errorf("Round(10.005, JPY) = %d, want 10", got.Amount)
+	}
+	// Float columns can hand back binary noise past any currency's precision
+	if err := json.Unmarshal([]byte(`10.300000000000001`), &m); err != nil {
+		t.Fatalf("Unmarshal of float noise failed: %v", err)
+	}
+	if got := m.Round("USD"); got.Amount != 1030 {
+		t.Errorf("Round(10.300000000000001, USD) = %d cents, want 1030", got.Amount)
+	}
+	if _, err := m.In("USD"); err == nil {
+		t.Error("Expected In to reject an amount with float noise")
+	}
+}
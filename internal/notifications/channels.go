@@ -0,0 +1,224 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrUnsafeWebhook is returned for webhook URLs that aren't https or that
+// point at loopback, private or link-local addresses.
+var ErrUnsafeWebhook = errors.New("webhook url must be https and publicly reachable")
+
+// Recipient is who a message is delivered to.
+type Recipient struct {
+	UserID     string
+	Email      string
+	WebhookURL string
+}
+
+// Message is a rendered notification ready for delivery.
+type Message struct {
+	Type      string    `json:"type"`
+	ListingID string    `json:"listing_id,omitempty"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// Channel delivers messages outside the app.
+type Channel interface {
+	// Name identifies the channel in logs and preferences.
+	Name() string
+	// Enabled reports whether the recipient opted into this channel.
+	Enabled(p Preferences) bool
+	Send(to Recipient, msg Message) error
+}
+
+// LogChannel writes every message to the server log. It is meant for local
+// development where no mail server or webhook receiver is available.
+type LogChannel struct{}
+
+func (LogChannel) Name() string { return "log" }
+
+func (LogChannel) Enabled(p Preferences) bool { return true }
+
+func (LogChannel) Send(to Recipient, msg Message) error {
+	log.Printf("Notification [%s] to %s: %s — %s", msg.Type, to.UserID, msg.Subject, msg.Body)
+	return nil
+}
+
+// WebhookChannel POSTs messages as JSON to the recipient's webhook URL.
+type WebhookChannel struct {
+	// Client overrides the default client, which refuses to connect to
+	// internal addresses.
+	Client *http.Client
+}
+
+// lookupIP resolves webhook hosts. Tests replace it.
+var lookupIP = net.DefaultResolver.LookupIPAddr
+
+// ValidateWebhookURL checks that raw is an https URL whose host resolves only
+// to public addresses, so user-supplied webhooks can't reach the server's own
+// network.
+func ValidateWebhookURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return ErrUnsafeWebhook
+	}
+	addrs, err := lookupIP(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: %s doesn't resolve", ErrUnsafeWebhook, u.Hostname())
+	}
+	for _, a := range addrs {
+		if !publicIP(a.IP) {
+			return ErrUnsafeWebhook
+		}
+	}
+	return nil
+}
+
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// webhookClient checks the address it actually connects to as well, so a
+// host that resolves differently after validation still can't reach an
+// internal address.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+					return ErrUnsafeWebhook
+				}
+				return nil
+			},
+		}).DialContext,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+func (WebhookChannel) Name() string { return "webhook" }
+
+func (WebhookChannel) Enabled(p Preferences) bool { return p.Webhook && p.WebhookURL != "" }
+
+func (c WebhookChannel) Send(to Recipient, msg Message) error {
+	if to.WebhookURL == "" {
+		return fmt.Errorf("no webhook URL for user %s", to.UserID)
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		"user_id": to.UserID,
+		"message": msg,
+	})
+	if err != nil {
+		return err
+	}
+
+	client := c.Client
+	if client == nil {
+		if err := ValidateWebhookURL(context.Background(), to.WebhookURL); err != nil {
+			return err
+		}
+		client = webhookClient
+	}
+	resp, err := client.Post(to.WebhookURL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook failed: status=%d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPChannel sends messages as plain-text email.
+type SMTPChannel struct {
+	Addr string // host:port
+	From string
+	Auth smtp.Auth
+}
+
+func (SMTPChannel) Name() string { return "email" }
+
+func (SMTPChannel) Enabled(p Preferences) bool { return p.Email }
+
+func (c SMTPChannel) Send(to Recipient, msg Message) error {
+	if to.Email == "" {
+		return fmt.Errorf("no email address for user %s", to.UserID)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(c.From))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(to.Email))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(msg.Subject)))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	b.WriteString(msg.Body)
+	b.WriteString("\r\n")
+
+	if err := smtp.SendMail(c.Addr, c.Auth, c.From, []string{to.Email}, []byte(b.String())); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
+
+// headerValue strips line breaks so text such as a seller's listing title
+// can't add headers of its own.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// ChannelsFromEnv builds the delivery channels configured in the environment.
+// SMTP is enabled by SMTP_HOST, webhooks are always available, and the log
+// sink is added when NOTIFY_LOG is set or no SMTP server is configured.
+func ChannelsFromEnv() []Channel {
+	var channels []Channel
+
+	host := os.Getenv("SMTP_HOST")
+	if host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		from := os.Getenv("SMTP_FROM")
+		if from == "" {
+			from = "no-reply@quickswap.local"
+		}
+		var auth smtp.Auth
+		if user := os.Getenv("SMTP_USERNAME"); user != "" {
+			auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+		}
+		channels = append(channels, SMTPChannel{Addr: host + ":" + port, From: from, Auth: auth})
+	}
+
+	channels = append(channels, WebhookChannel{})
+
+	if host == "" || os.Getenv("NOTIFY_LOG") != "" {
+		channels = append(channels, LogChannel{})
+	}
+	return channels
+}
@@ -0,0 +1,54 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	hosts := map[string]string{
+		"hooks.example.com": "93.184.216.34",
+		"internal.example":  "10.0.0.5",
+		"metadata.example":  "169.254.169.254",
+	}
+	lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if ip, ok := hosts[host]; ok {
+			return []net.IPAddr{{IP: net.ParseIP(ip)}}, nil
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IPAddr{{IP: ip}}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	defer func() { lookupIP = net.DefaultResolver.LookupIPAddr }()
+
+	for raw, ok := range map[string]bool{
+		"https://hooks.example.com/quickswap":  true,
+		"http://hooks.example.com/quickswap":   false,
+		"https://127.0.0.1/admin":              false,
+		"https://[::1]/admin":                  false,
+		"https://internal.example/hook":        false,
+		"https://metadata.example/latest/meta": false,
+		"https://192.168.1.1/hook":             false,
+		"https://unresolvable.example/hook":    false,
+		"file:///etc/passwd":                   false,
+	} {
+		err := ValidateWebhookURL(context.Background(), raw)
+		if ok && err != nil {
+			t.Errorf("%s: expected valid, got %v", raw, err)
+		} else if !ok && !errors.Is(err, ErrUnsafeWebhook) {
+			t.Errorf("%s: expected ErrUnsafeWebhook, got %v", raw, err)
+		}
+	}
+}
+
+func TestHeaderValueStripsLineBreaks(t *testing.T) {
+	subject := mime.QEncoding.Encode("utf-8", headerValue("You won Lamp\r\nBcc: victim@example.com"))
+	if strings.ContainsAny(subject, "\r\n") {
+		t.Errorf("Expected no line breaks in the subject header, got %q", subject)
+	}
+}
@@ -0,0 +1,77 @@
+package notifications
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Notification is a single entry in a user's in-app inbox.
+type Notification struct {
+	ID        string    `json:"id,omitempty"`
+	UserID    string    `json:"user_id"`
+	Type      string    `json:"type"`
+	ListingID string    `json:"listing_id,omitempty"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store saves n to the Supabase notifications table.
+func Store(n *Notification) error {
+	if err := supabase.Insert("notifications", []Notification{*n}, nil); err != nil {
+		return fmt.Errorf("store notification: %w", err)
+	}
+	return nil
+}
+
+// Inbox returns the user's notifications, newest first. When unreadOnly is
+// set, notifications already marked read are skipped.
+func Inbox(userID string, unreadOnly bool, limit int) ([]Notification, error) {
+	query := "user_id=eq." + url.QueryEscape(userID) + "&order=created_at.desc"
+	if unreadOnly {
+		query += "&read=is.false"
+	}
+	if limit > 0 {
+		query += fmt.Sprintf("&limit=%d", limit)
+	}
+
+	var inbox []Notification
+	if err := supabase.Select("notifications", query, &inbox); err != nil {
+		return nil, fmt.Errorf("fetch notifications: %w", err)
+	}
+	return inbox, nil
+}
+
+// UnreadCount returns how many unread notifications the user has.
+func UnreadCount(userID string) (int, error) {
+	var rows []struct {
+		ID string `json:"id"`
+	}
+	query := "user_id=eq." + url.QueryEscape(userID) + "&read=is.false&select=id"
+	if err := supabase.Select("notifications", query, &rows); err != nil {
+		return 0, fmt.Errorf("count notifications: %w", err)
+	}
+	return len(rows), nil
+}
+
+// MarkRead marks one of the user's notifications as read.
+func MarkRead(userID, id string) error {
+	query := "user_id=eq." + url.QueryEscape(userID) + "&id=eq." + url.QueryEscape(id)
+	if err := supabase.Update("notifications", query, map[string]bool{"read": true}, nil); err != nil {
+		return fmt.Errorf("mark notification read: %w", err)
+	}
+	return nil
+}
+
+// MarkAllRead marks every notification of the user as read.
+func MarkAllRead(userID string) error {
+	query := "user_id=eq." + url.QueryEscape(userID) + "&read=is.false"
+	if err := supabase.Update("notifications", query, map[string]bool{"read": true}, nil); err != nil {
+		return fmt.Errorf("mark notifications read: %w", err)
+	}
+	return nil
+}
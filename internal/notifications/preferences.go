@@ -0,0 +1,60 @@
+package notifications
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Preferences controls which channels a user is notified on.
+type Preferences struct {
+	UserID     string   `json:"user_id"`
+	InApp      bool     `json:"in_app"`
+	Email      bool     `json:"email"`
+	Webhook    bool     `json:"webhook"`
+	WebhookURL string   `json:"webhook_url,omitempty"`
+	Muted      []string `json:"muted"` // event types the user opted out of
+}
+
+// DefaultPreferences are used for users who never saved any.
+func DefaultPreferences(userID string) Preferences {
+	return Preferences{UserID: userID, InApp: true, Email: true, Muted: []string{}}
+}
+
+// IsMuted reports whether the user opted out of eventType.
+func (p Preferences) IsMuted(eventType string) bool {
+	for _, m := range p.Muted {
+		if m == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPreferences loads the user's preferences, falling back to the defaults.
+func GetPreferences(userID string) (Preferences, error) {
+	var rows []Preferences
+	query := "user_id=eq." + url.QueryEscape(userID)
+	if err := supabase.Select("notification_preferences", query, &rows); err != nil {
+		return DefaultPreferences(userID), fmt.Errorf("fetch notification preferences: %w", err)
+	}
+	if len(rows) == 0 {
+		return DefaultPreferences(userID), nil
+	}
+	if rows[0].Muted == nil {
+		rows[0].Muted = []string{}
+	}
+	return rows[0], nil
+}
+
+// SavePreferences creates or replaces the user's preferences.
+func SavePreferences(p Preferences) error {
+	if p.Muted == nil {
+		p.Muted = []string{}
+	}
+	if err := supabase.Upsert("notification_preferences", []Preferences{p}, nil); err != nil {
+		return fmt.Errorf("save notification preferences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package notifications
+
+import (
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/events"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// dedupWindows is how long repeat events for the same user and listing are
+// suppressed, so a bidding war produces one outbid message rather than fifty.
+var dedupWindows = map[events.Type]time.Duration{
+	events.Outbid:     15 * time.Minute,
+	events.EndingSoon: 1 * time.Hour,
+	events.AuctionWon: 24 * time.Hour,
+	events.ItemSold:   24 * time.Hour,
+	events.NewMessage: 5 * time.Minute,
+}
+
+// Service turns domain events into inbox entries and channel deliveries.
+type Service struct {
+	channels []Channel
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewService creates a notification service delivering on channels.
+func NewService(channels ...Channel) *Service {
+	return &Service{channels: channels, seen: make(map[string]time.Time)}
+}
+
+// Subscribe registers the service for every event type on bus.
+func (s *Service) Subscribe(bus *events.Bus) {
+	for t := range templates {
+		bus.Subscribe(t, s.Handle)
+	}
+}
+
+// Handle delivers a single event according to the recipient's preferences.
+func (s *Service) Handle(e events.Event) {
+	if e.UserID == "" {
+		return
+	}
+
+	prefs, err := GetPreferences(e.UserID)
+	if err != nil {
+		log.Printf("Warning: using default notification preferences for %s: %v", e.UserID, err)
+	}
+	if prefs.IsMuted(string(e.Type)) {
+		return
+	}
+	if s.duplicate(e) {
+		return
+	}
+
+	if e.Data["title"] == "" && e.ListingID != "" {
+		data := make(map[string]string, len(e.Data)+1)
+		for k, v := range e.Data {
+			data[k] = v
+		}
+		data["title"] = lookupTitle(e.ListingID)
+		e.Data = data
+	}
+
+	msg, err := Render(e)
+	if err != nil {
+		log.Printf("Warning: failed to render %s notification: %v", e.Type, err)
+		return
+	}
+
+	if prefs.InApp {
+		n := &Notification{
+			UserID:    e.UserID,
+			Type:      string(e.Type),
+			ListingID: e.ListingID,
+			Title:     msg.Subject,
+			Body:      msg.Body,
+			CreatedAt: e.At,
+		}
+		if err := Store(n); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	to := Recipient{UserID: e.UserID, WebhookURL: prefs.WebhookURL}
+	for _, ch := range s.channels {
+		if !ch.Enabled(prefs) {
+			continue
+		}
+		if ch.Name() == "email" && to.Email == "" {
+			to.Email = lookupEmail(e.UserID)
+		}
+		if err := ch.Send(to, msg); err != nil {
+			log.Printf("Warning: %s notification to %s failed: %v", ch.Name(), e.UserID, err)
+		}
+	}
+}
+
+// duplicate reports whether an equivalent event was already delivered within
+// its dedup window, and records e otherwise.
+func (s *Service) duplicate(e events.Event) bool {
+	window, ok := dedupWindows[e.Type]
+	if !ok {
+		return false
+	}
+	key := e.UserID + "|" + string(e.Type) + "|" + e.ListingID
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, at := range s.seen {
+		if e.At.Sub(at) > 24*time.Hour {
+			delete(s.seen, k)
+		}
+	}
+	if last, ok := s.seen[key]; ok && e.At.Sub(last) < window {
+		return true
+	}
+	s.seen[key] = e.At
+	return false
+}
+
+// lookupEmail reads the user's email address from the profiles table.
+func lookupEmail(userID string) string {
+	var profiles []struct {
+		Email string `json:"email"`
+	}
+	if err := supabase.Select("profiles", "id=eq."+url.QueryEscape(userID)+"&select=email", &profiles); err != nil || len(profiles) == 0 {
+		return ""
+	}
+	return profiles[0].Email
+}
+
+// lookupTitle reads a listing's title from the listings table.
+func lookupTitle(listingID string) string {
+	var rows []struct {
+		Title string `json:"title"`
+	}
+	if err := supabase.Select("listings", "id=eq."+url.QueryEscape(listingID)+"&select=title", &rows); err != nil || len(rows) == 0 {
+		return ""
+	}
+	return rows[0].Title
+}
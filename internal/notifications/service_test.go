@@ -0,0 +1,53 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/events"
+)
+
+func TestServiceDeduplicatesBiddingWar(t *testing.T) {
+	s := NewService()
+	start := time.Now()
+
+	e := events.Event{Type: events.Outbid, UserID: "user123", ListingID: "list1", At: start}
+	if s.duplicate(e) {
+		t.Fatalf("First outbid event should not be a duplicate")
+	}
+	e.At = start.Add(time.Minute)
+	if !s.duplicate(e) {
+		t.Errorf("Outbid event within the window should be a duplicate")
+	}
+	e.At = start.Add(20 * time.Minute)
+	if s.duplicate(e) {
+		t.Errorf("Outbid event after the window should not be a duplicate")
+	}
+
+	other := events.Event{Type: events.Outbid, UserID: "user123", ListingID: "list2", At: start.Add(time.Minute)}
+	if s.duplicate(other) {
+		t.Errorf("Outbid event for another listing should not be a duplicate")
+	}
+}
+
+func TestRender(t *testing.T) {
+	msg, err := Render(events.Event{
+		Type:      events.Outbid,
+		ListingID: "list1",
+		Data:      map[string]string{"title": "Vintage T-Shirt", "amount": "40.00"},
+	})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if msg.Subject != "You've been outbid on Vintage T-Shirt" {
+		t.Errorf("Unexpected subject: %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Body, "40.00") {
+		t.Errorf("Expected amount in body, got %q", msg.Body)
+	}
+
+	if _, err := Render(events.Event{Type: "unknown"}); err == nil {
+		t.Errorf("Expected error for unknown event type")
+	}
+}
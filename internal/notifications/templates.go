@@ -0,0 +1,168 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/quickswap/quickswap/internal/events"
+)
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func newTemplate(subject, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New("subject").Option("missingkey=zero").Parse(subject)),
+		body:    template.Must(template.New("body").Option("missingkey=zero").Parse(body)),
+	}
+}
+
+// templates maps each event type to its subject and body. Templates are
+// executed against the event's Data map.
+var templates = map[events.Type]messageTemplate{
+	events.Outbid: newTemplate(
+		`You've been outbid on {{.title}}`,
+		`Someone placed a higher bid of {{.amount}} on "{{.title}}". Bid again before the auction ends to stay in the lead.`,
+	),
+	events.AuctionWon: newTemplate(
+		`You won {{.title}}!`,
+		`Congratulations! Your bid of {{.amount}} won "{{.title}}". The seller will be in touch to arrange the handoff.`,
+	),
+	events.ItemSold: newTemplate(
+		`{{.title}} sold for {{.amount}}`,
+		`Your auction "{{.title}}" ended with a winning bid of {{.amount}}.`,
+	),
+	events.EndingSoon: newTemplate(
+		`{{.title}} is ending soon`,
+		`An auction on your watchlist, "{{.title}}", ends at {{.ends_at}}. Place your bid before it closes.`,
+	),
+	events.NewMessage: newTemplate(
+		`New message about {{.title}}`,
+		`{{.preview}}`,
+	),
+	events.QuestionAnswered: newTemplate(
+		`The seller answered a question about {{.title}}`,
+		`Q: {{.question}}
+A: {{.answer}}`,
+	),
+	events.SavedSearchMatch: newTemplate(
+		`New match for "{{.search}}": {{.title}}`,
+		`"{{.title}}" was just listed starting at {{.price}} and matches your saved search "{{.search}}".`,
+	),
+	events.SavedSearchDigest: newTemplate(
+		`{{.count}} new listings for "{{.search}}"`,
+		`New listings matching your saved search "{{.search}}":
+{{.titles}}`,
+	),
+	events.BidRetracted: newTemplate(
+		`A bid on {{.title}} was retracted`,
+		`A bid of {{.amount}} on "{{.title}}" was retracted. The current price is now {{.price}}.`,
+	),
+	events.LeadRestored: newTemplate(
+		`You're the highest bidder on {{.title}} again`,
+		`A higher bid on "{{.title}}" was retracted, so your bid of {{.price}} is in the lead again.`,
+	),
+	events.OfferReceived: newTemplate(
+		`New offer of {{.amount}} on {{.title}}`,
+		`A buyer offered {{.amount}} for "{{.title}}". Accept, counter or decline it before {{.expires_at}}.`,
+	),
+	events.OfferCountered: newTemplate(
+		`The seller countered your offer on {{.title}}`,
+		`The seller of "{{.title}}" countered your offer with {{.amount}}. Accept or decline it before {{.expires_at}}.`,
+	),
+	events.OfferAccepted: newTemplate(
+		`Offer accepted for {{.title}}`,
+		`The offer of {{.amount}} for "{{.title}}" was accepted and the auction has ended.`,
+	),
+	events.OfferDeclined: newTemplate(
+		`Offer declined for {{.title}}`,
+		`The offer of {{.amount}} for "{{.title}}" was declined.`,
+	),
+	events.SecondChanceOffer: newTemplate(
+		`Second chance to buy {{.title}}`,
+		`The winner of "{{.title}}" didn't complete the purchase. You can buy it for your highest bid of {{.amount}} until {{.expires_at}}.`,
+	),
+	events.SecondChanceAccepted: newTemplate(
+		`Your second-chance offer was accepted`,
+		`The runner-up accepted your second-chance offer of {{.amount}}.`,
+	),
+	events.SecondChanceDeclined: newTemplate(
+		`Your second-chance offer was declined`,
+		`The runner-up declined your second-chance offer of {{.amount}}. You can offer the item to the next bidder.`,
+	),
+	events.PaymentReceived: newTemplate(
+		`Payment received for {{.title}}`,
+		`The buyer paid {{.amount}} for "{{.title}}". We're holding it until the buyer has the item.`,
+	),
+	events.PaymentOverdue: newTemplate(
+		`Payment overdue for {{.title}}`,
+		`Your {{.amount}} payment for "{{.title}}" was due by {{.due_at}} and wasn't made. An unpaid-item strike has been added to your account.`,
+	),
+	events.UnpaidItem: newTemplate(
+		`The buyer didn't pay for {{.title}}`,
+		`The {{.amount}} payment for "{{.title}}" was due by {{.due_at}} and wasn't made. You can relist the item or send a second-chance offer to the runner-up.`,
+	),
+	events.BiddingRestricted: newTemplate(
+		`Your bidding has been restricted`,
+		`You have {{.count}} unpaid items, so you can't bid, buy or make offers until they are cleared. Contact support to resolve them.`,
+	),
+	events.PaymentReleased: newTemplate(
+		`Payment released for {{.title}}`,
+		`{{.amount}} for "{{.title}}" has been released to you.`,
+	),
+	events.PaymentRefunded: newTemplate(
+		`Refund issued for {{.title}}`,
+		`Your payment of {{.amount}} for "{{.title}}" has been refunded.`,
+	),
+	events.OrderShipped: newTemplate(
+		`{{.title}} has shipped`,
+		`The seller shipped "{{.title}}" with {{.carrier}}, tracking number {{.tracking_number}}. Confirm delivery once it arrives.`,
+	),
+	events.DisputeOpened: newTemplate(
+		`The buyer opened a dispute on {{.title}}`,
+		`The buyer of "{{.title}}" opened a dispute ({{.reason}}). The payment is on hold until it is resolved. Respond within 3 days or it goes to review without your side.`,
+	),
+	events.DisputeResponded: newTemplate(
+		`The seller responded to your dispute`,
+		`The seller responded to your dispute about "{{.title}}". Our team will review the case and let you know the outcome.`,
+	),
+	events.DisputeResolved: newTemplate(
+		`Dispute about {{.title}} resolved`,
+		`The dispute about "{{.title}}" has been resolved with the outcome: {{.resolution}}.`,
+	),
+}
+
+// Render builds the message for e from its template.
+func Render(e events.Event) (Message, error) {
+	tmpl, ok := templates[e.Type]
+	if !ok {
+		return Message{}, fmt.Errorf("no template for event type %q", e.Type)
+	}
+
+	data := make(map[string]string, len(e.Data)+1)
+	for k, v := range e.Data {
+		data[k] = v
+	}
+	if data["title"] == "" {
+		data["title"] = "a listing"
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return Message{}, fmt.Errorf("render subject: %w", err)
+	}
+	if err := tmpl.body.Execute(&body, data); err != nil {
+		return Message{}, fmt.Errorf("render body: %w", err)
+	}
+
+	return Message{
+		Type:      string(e.Type),
+		ListingID: e.ListingID,
+		Subject:   subject.String(),
+		Body:      body.String(),
+		SentAt:    e.At,
+	}, nil
+}
@@ -0,0 +1,288 @@
+// Package offers implements best-offer mode: buyers make private offers on
+// listings that accept them and sellers accept, counter or decline them.
+package offers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+const table = "offers"
+
+// DefaultExpiry is how long an offer or counter-offer stays open.
+const DefaultExpiry = 48 * time.Hour
+
+// Status is where an offer is in the negotiation.
+type Status string
+
+const (
+	// Pending offers await the seller.
+	Pending Status = "pending"
+	// Countered offers await the buyer's answer to CounterAmount.
+	Countered Status = "countered"
+	Accepted  Status = "accepted"
+	Declined  Status = "declined"
+	Expired   Status = "expired"
+	// Closed offers were still open when the listing sold another way.
+	Closed Status = "closed"
+)
+
+// Open reports whether the offer still awaits an answer.
+func (s Status) Open() bool { return s == Pending || s == Countered }
+
+// Actions on an offer.
+const (
+	Accept  = "accept"
+	Counter = "counter"
+	Decline = "decline"
+)
+
+var (
+	ErrNotFound   = errors.New("offer not found")
+	ErrForbidden  = errors.New("not your turn to answer this offer")
+	ErrInvalid    = errors.New("invalid offer")
+	ErrOpenOffer  = errors.New("you already have an open offer on this listing")
+	ErrNotAllowed = errors.New("listing does not accept offers")
+	ErrNotOpen    = errors.New("offer is no longer open")
+)
+
+// Offer is a private offer from a buyer on a listing.
+type Offer struct {
+	ID            string         `json:"id,omitempty"`
+	ListingID     string         `json:"listing_id"`
+	BuyerID       string         `json:"buyer_id"`
+	SellerID      string         `json:"seller_id"`
+	Amount        money.Money    `json:"amount"`
+	Currency      money.Currency `json:"currency"`
+	Message       string         `json:"message,omitempty"`
+	Status        Status         `json:"status"`
+	CounterAmount *money.Money   `json:"counter_amount,omitempty"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	RespondedAt   *time.Time     `json:"responded_at,omitempty"`
+}
+
+// round puts amounts read back from the table in the offer's currency.
+func (o *Offer) round() {
+	if o.Currency == "" {
+		o.Currency = money.DefaultCurrency
+	}
+	o.Amount = o.Amount.Round(o.Currency)
+	if o.CounterAmount != nil {
+		c := o.CounterAmount.Round(o.Currency)
+		o.CounterAmount = &c
+	}
+}
+
+// Price is what the buyer pays if the offer is accepted now.
+func (o *Offer) Price() money.Money {
+	if o.Status == Countered && o.CounterAmount != nil {
+		return *o.CounterAmount
+	}
+	return o.Amount
+}
+
+// Next checks that userID may take action on o at now and returns the status
+// it leads to. Sellers answer pending offers; buyers answer counters.
+func (o *Offer) Next(userID, action string, now time.Time) (Status, error) {
+	if !o.Status.Open() || !now.Before(o.ExpiresAt) {
+		return "", ErrNotOpen
+	}
+	turn := o.SellerID
+	if o.Status == Countered {
+		turn = o.BuyerID
+	}
+	if userID != turn {
+		return "", ErrForbidden
+	}
+	switch action {
+	case Accept:
+		return Accepted, nil
+	case Decline:
+		return Declined, nil
+	case Counter:
+		if o.Status == Countered {
+			return "", fmt.Errorf("%w: buyers answer a counter by making a new offer", ErrInvalid)
+		}
+		return Countered, nil
+	}
+	return "", fmt.Errorf("%w: unknown action %q", ErrInvalid, action)
+}
+
+// Make records a new offer from buyerID on l.
+func Make(l *listing.Listing, buyerID string, amount money.Money, message string, now time.Time) (*Offer, error) {
+	if !l.AcceptsOffers {
+		return nil, ErrNotAllowed
+	}
+	if l.SellerID == buyerID {
+		return nil, fmt.Errorf("%w: sellers can't make offers on their own listing", ErrInvalid)
+	}
+	if !now.Before(l.AuctionEndTime) || now.Before(l.AuctionStartTime) {
+		return nil, fmt.Errorf("%w: the listing isn't live", ErrInvalid)
+	}
+	amount, err := amount.In(l.Currency)
+	if err != nil || !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be positive in %s", ErrInvalid, l.Currency)
+	}
+
+	var open []Offer
+	query := "listing_id=eq." + url.QueryEscape(l.ID) + "&buyer_id=eq." + url.QueryEscape(buyerID) +
+		"&status=in.(" + string(Pending) + "," + string(Countered) + ")" +
+		"&expires_at=gt." + url.QueryEscape(now.Format(time.RFC3339))
+	if err := supabase.Select(table, query, &open); err != nil {
+		return nil, err
+	}
+	if len(open) > 0 {
+		return nil, ErrOpenOffer
+	}
+
+	o := Offer{
+		ListingID: l.ID,
+		BuyerID:   buyerID,
+		SellerID:  l.SellerID,
+		Amount:    amount,
+		Currency:  l.Currency,
+		Message:   message,
+		Status:    Pending,
+		ExpiresAt: expiry(now, l.AuctionEndTime),
+		CreatedAt: now,
+	}
+	var created []Offer
+	if err := supabase.Insert(table, []Offer{o}, &created); err != nil {
+		return nil, err
+	}
+	if len(created) > 0 {
+		o = created[0]
+		o.round()
+	}
+	return &o, nil
+}
+
+// expiry is DefaultExpiry from now, cut short by the end of the auction.
+func expiry(now, auctionEnd time.Time) time.Time {
+	if t := now.Add(DefaultExpiry); t.Before(auctionEnd) {
+		return t
+	}
+	return auctionEnd
+}
+
+// Get returns the offer with the given ID.
+func Get(id string) (*Offer, error) {
+	var rows []Offer
+	if err := supabase.Select(table, "id=eq."+url.QueryEscape(id), &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	rows[0].round()
+	return &rows[0], nil
+}
+
+// ForBuyer returns the offers buyerID has made, newest first.
+func ForBuyer(buyerID string) ([]Offer, error) {
+	return list("buyer_id=eq." + url.QueryEscape(buyerID))
+}
+
+// ForSeller returns the offers sellerID has received, newest first.
+func ForSeller(sellerID string) ([]Offer, error) {
+	return list("seller_id=eq." + url.QueryEscape(sellerID))
+}
+
+func list(filter string) ([]Offer, error) {
+	var rows []Offer
+	if err := supabase.Select(table, filter+"&order=created_at.desc", &rows); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].round()
+	}
+	return rows, nil
+}
+
+// Respond moves o to status. A counter sets the counter amount and gives the
+// buyer a fresh expiry. The update only applies if o is still in the status
+// it was read in, so a seller and an expiry sweep can't both win.
+func Respond(o *Offer, l *listing.Listing, status Status, counter *money.Money, now time.Time) error {
+	patch := map[string]interface{}{"status": status, "responded_at": now}
+	if status == Countered {
+		if counter == nil {
+			return fmt.Errorf("%w: a counter needs an amount", ErrInvalid)
+		}
+		c, err := counter.In(l.Currency)
+		if err != nil || c.Cmp(o.Amount) <= 0 {
+			return fmt.Errorf("%w: a counter must be above the offer", ErrInvalid)
+		}
+		patch["counter_amount"] = c
+		patch["expires_at"] = expiry(now, l.AuctionEndTime)
+	}
+
+	var updated []Offer
+	query := "id=eq." + url.QueryEscape(o.ID) + "&status=eq." + string(o.Status)
+	if err := supabase.Update(table, query, patch, &updated); err != nil {
+		return err
+	}
+	if len(updated) == 0 {
+		return ErrNotOpen
+	}
+	*o = updated[0]
+	o.round()
+	return nil
+}
+
+// Revert puts an offer accepted with Respond back to how it was in prev, for
+// when the sale behind the acceptance couldn't go through and may be retried.
+func Revert(o *Offer, prev Offer) error {
+	var updated []Offer
+	query := "id=eq." + url.QueryEscape(o.ID) + "&status=eq." + string(Accepted)
+	patch := map[string]interface{}{"status": prev.Status, "responded_at": prev.RespondedAt}
+	if err := supabase.Update(table, query, patch, &updated); err != nil {
+		return err
+	}
+	if len(updated) == 0 {
+		return ErrNotOpen
+	}
+	*o = updated[0]
+	o.round()
+	return nil
+}
+
+// CloseOthers closes every other open offer on a listing once it has sold.
+func CloseOthers(listingID, keepID string, now time.Time) error {
+	query := "listing_id=eq." + url.QueryEscape(listingID) + "&id=neq." + url.QueryEscape(keepID) +
+		"&status=in.(" + string(Pending) + "," + string(Countered) + ")"
+	patch := map[string]interface{}{"status": Closed, "responded_at": now}
+	return supabase.Update(table, query, patch, nil)
+}
+
+// Run calls ExpireDue every interval until ctx is cancelled.
+func Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ExpireDue(time.Now().UTC()); err != nil {
+				log.Printf("Warning: offer expiry failed: %v", err)
+			}
+		}
+	}
+}
+
+// ExpireDue marks open offers past their expiry as expired.
+func ExpireDue(now time.Time) error {
+	query := "status=in.(" + string(Pending) + "," + string(Countered) + ")" +
+		"&expires_at=lte." + url.QueryEscape(now.Format(time.RFC3339))
+	return supabase.Update(table, query, map[string]interface{}{"status": Expired}, nil)
+}
@@ -0,0 +1,41 @@
+package offers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOfferNext(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	pending := Offer{BuyerID: "buyer", SellerID: "seller", Status: Pending, ExpiresAt: now.Add(time.Hour)}
+	countered := pending
+	countered.Status = Countered
+
+	for _, tc := range []struct {
+		name   string
+		o      Offer
+		user   string
+		action string
+		want   Status
+		err    error
+	}{
+		{"seller accepts", pending, "seller", Accept, Accepted, nil},
+		{"seller counters", pending, "seller", Counter, Countered, nil},
+		{"seller declines", pending, "seller", Decline, Declined, nil},
+		{"buyer can't accept own offer", pending, "buyer", Accept, "", ErrForbidden},
+		{"buyer accepts counter", countered, "buyer", Accept, Accepted, nil},
+		{"seller waits on counter", countered, "seller", Accept, "", ErrForbidden},
+		{"no counter to a counter", countered, "buyer", Counter, "", ErrInvalid},
+		{"unknown action", pending, "seller", "haggle", "", ErrInvalid},
+	} {
+		got, err := tc.o.Next(tc.user, tc.action, now)
+		if got != tc.want || !errors.Is(err, tc.err) {
+			t.Errorf("%s: got (%q, %v), want (%q, %v)", tc.name, got, err, tc.want, tc.err)
+		}
+	}
+
+	if _, err := pending.Next("seller", Accept, now.Add(2*time.Hour)); !errors.Is(err, ErrNotOpen) {
+		t.Errorf("Expected an expired offer to be closed, got %v", err)
+	}
+}
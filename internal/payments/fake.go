@@ -0,0 +1,114 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+// FakeSignatureHeader carries a FakeProvider webhook's signature.
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeProvider is an in-memory provider for development and tests. Payments
+// succeed when a webhook signed with Secret says so, e.g.
+//
+//	{"type": "payment_succeeded", "payment_id": "fake_pi_1", "order_id": "1", "amount": 40, "currency": "USD"}
+//
+// with the hex HMAC-SHA256 of the body in X-Fake-Signature.
+type FakeProvider struct {
+	Secret string
+
+	mu       sync.Mutex
+	released map[string]bool
+	refunded map[string]money.Money
+}
+
+func (p *FakeProvider) Name() string { return "fake" }
+
+func (p *FakeProvider) CreatePayment(ctx context.Context, o *Order) (Intent, error) {
+	id := "fake_pi_" + o.ID
+	return Intent{PaymentID: id, ClientSecret: id + "_secret"}, nil
+}
+
+func (p *FakeProvider) Release(ctx context.Context, o *Order) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.released == nil {
+		p.released = map[string]bool{}
+	}
+	p.released[o.PaymentID] = true
+	return nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, o *Order, amount money.Money) error {
+	if amount.Cmp(o.Amount) > 0 {
+		return fmt.Errorf("refund of %s exceeds payment of %s", amount, o.Amount)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.refunded == nil {
+		p.refunded = map[string]money.Money{}
+	}
+	p.refunded[o.PaymentID] = amount
+	return nil
+}
+
+// Released reports whether the payment was paid out to the seller.
+func (p *FakeProvider) Released(paymentID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.released[paymentID]
+}
+
+// Refunded returns how much of the payment was refunded.
+func (p *FakeProvider) Refunded(paymentID string) (money.Money, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m, ok := p.refunded[paymentID]
+	return m, ok
+}
+
+// Sign returns the signature for a webhook payload.
+func (p *FakeProvider) Sign(payload []byte) string {
+	return hex.EncodeToString(p.mac(payload))
+}
+
+func (p *FakeProvider) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.Secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func (p *FakeProvider) ParseWebhook(payload []byte, header http.Header, now time.Time) (WebhookEvent, error) {
+	sig, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil || p.Secret == "" || !hmac.Equal(sig, p.mac(payload)) {
+		return WebhookEvent{}, ErrSignature
+	}
+	var body struct {
+		Type      WebhookType    `json:"type"`
+		PaymentID string         `json:"payment_id"`
+		OrderID   string         `json:"order_id"`
+		Amount    money.Money    `json:"amount"`
+		Currency  money.Currency `json:"currency"`
+	}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return WebhookEvent{}, fmt.Errorf("decode webhook: %w", err)
+	}
+	e := WebhookEvent{Type: body.Type, PaymentID: body.PaymentID, OrderID: body.OrderID}
+	if body.Currency != "" {
+		amount, err := body.Amount.In(body.Currency)
+		if err != nil {
+			return WebhookEvent{}, fmt.Errorf("decode webhook: %w", err)
+		}
+		e.Amount = amount
+	}
+	return e, nil
+}
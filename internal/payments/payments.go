@@ -0,0 +1,458 @@
+// Package payments collects payment for won auctions and holds it in escrow
+// until the buyer has the item. Each sale gets an order that moves
+// pending → paid → released or refunded; orders left unpaid past their
+// deadline are marked unpaid.
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/events"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+const table = "orders"
+
+// PaymentWindow is how long a buyer has to pay after winning.
+const PaymentWindow = 72 * time.Hour
+
+// Status is an order's escrow state.
+type Status string
+
+const (
+	// Pending orders are waiting for the buyer to pay.
+	Pending Status = "pending"
+	// Paid orders hold the buyer's money in escrow.
+	Paid Status = "paid"
+	// Released orders have paid the seller out.
+	Released Status = "released"
+	// Refunded orders have returned the money to the buyer.
+	Refunded Status = "refunded"
+	// Unpaid orders missed their payment deadline.
+	Unpaid Status = "unpaid"
+)
+
+// transitions lists the states each state can move to.
+var transitions = map[Status][]Status{
+	Pending: {Paid, Unpaid},
+	Paid:    {Released, Refunded},
+}
+
+// CanMove reports whether an order in s may move to to.
+func (s Status) CanMove(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrNotFound  = errors.New("order not found")
+	ErrForbidden = errors.New("order belongs to someone else")
+	ErrState     = errors.New("order can't do that in its current state")
+	ErrOverdue   = errors.New("payment deadline has passed")
+	ErrDisputed  = errors.New("order has an open dispute")
+	ErrMismatch  = errors.New("payment doesn't match the order amount")
+)
+
+// Order is the payment record for one sale.
+type Order struct {
+	ID         string         `json:"id,omitempty"`
+	ListingID  string         `json:"listing_id"`
+	BuyerID    string         `json:"buyer_id"`
+	SellerID   string         `json:"seller_id"`
+	Title      string         `json:"title,omitempty"`
+	Amount     money.Money    `json:"amount"`
+	Currency   money.Currency `json:"currency"`
+	Quantity   int            `json:"quantity"`
+	Status     Status         `json:"status"`
+	Provider   string         `json:"provider,omitempty"`
+	PaymentID  string         `json:"payment_id,omitempty"`
+	DueAt      time.Time      `json:"due_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	PaidAt     *time.Time     `json:"paid_at,omitempty"`
+	ReleasedAt *time.Time     `json:"released_at,omitempty"`
+	RefundedAt *time.Time     `json:"refunded_at,omitempty"`
+	// RefundedAmount is set when part of a payment was refunded before the
+	// rest was released
+	RefundedAmount *money.Money `json:"refunded_amount,omitempty"`
+	// Disputed holds the money in escrow while a dispute is open
+	Disputed bool `json:"disputed,omitempty"`
+
+	// Fulfilment, chosen by the buyer at checkout. Amount includes
+	// ShippingCost.
+	Method          string              `json:"fulfilment_method,omitempty"`
+	PickupSlot      *listing.PickupSlot `json:"pickup_slot,omitempty"`
+	ShippingAddress string              `json:"shipping_address,omitempty"`
+	ShippingCost    *money.Money        `json:"shipping_cost,omitempty"`
+	Carrier         string              `json:"carrier,omitempty"`
+	TrackingNumber  string              `json:"tracking_number,omitempty"`
+	ShippedAt       *time.Time          `json:"shipped_at,omitempty"`
+	DeliveredAt     *time.Time          `json:"delivered_at,omitempty"`
+	// ReleaseDueAt is when escrow pays the seller if the buyer hasn't
+	// confirmed delivery.
+	ReleaseDueAt *time.Time `json:"release_due_at,omitempty"`
+}
+
+// Create opens a pending order for a sale, due within PaymentWindow.
+func Create(listingID, title, buyerID, sellerID string, amount money.Money, quantity int, now time.Time) (*Order, error) {
+	if quantity < 1 {
+		quantity = 1
+	}
+	o := Order{
+		ListingID: listingID,
+		BuyerID:   buyerID,
+		SellerID:  sellerID,
+		Title:     title,
+		Amount:    amount,
+		Currency:  amount.Currency,
+		Quantity:  quantity,
+		Status:    Pending,
+		DueAt:     now.Add(PaymentWindow),
+		CreatedAt: now,
+	}
+	var created []Order
+	if err := supabase.Insert(table, []Order{o}, &created); err != nil {
+		return nil, fmt.Errorf("create order: %w", err)
+	}
+	if len(created) > 0 {
+		o = created[0]
+		o.round()
+	}
+	return &o, nil
+}
+
+// Get returns the order with the given ID.
+func Get(id string) (*Order, error) {
+	orders, err := list("id=eq." + url.QueryEscape(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, ErrNotFound
+	}
+	return &orders[0], nil
+}
+
+// ForBuyer returns the orders userID has to pay for, newest first.
+func ForBuyer(userID string) ([]Order, error) {
+	return list("buyer_id=eq." + url.QueryEscape(userID))
+}
+
+// ForListing returns the orders for a listing, newest first.
+func ForListing(listingID string) ([]Order, error) {
+	return list("listing_id=eq." + url.QueryEscape(listingID))
+}
+
+// DueForRelease returns paid orders whose release timeout passed by now.
+func DueForRelease(now time.Time) ([]Order, error) {
+	return list("status=eq." + string(Paid) + "&disputed=not.is.true" +
+		"&release_due_at=lte." + url.QueryEscape(now.Format(time.RFC3339)))
+}
+
+// ForSeller returns the orders for userID's sales, newest first.
+func ForSeller(userID string) ([]Order, error) {
+	return list("seller_id=eq." + url.QueryEscape(userID))
+}
+
+func list(filter string) ([]Order, error) {
+	var rows []Order
+	if err := supabase.Select(table, filter+"&order=created_at.desc", &rows); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].round()
+	}
+	return rows, nil
+}
+
+// round puts amounts read back from the table in the order's currency.
+func (o *Order) round() {
+	if o.Currency == "" {
+		o.Currency = money.DefaultCurrency
+	}
+	o.Amount = o.Amount.Round(o.Currency)
+	for _, m := range []*money.Money{o.ShippingCost, o.RefundedAmount} {
+		if m != nil {
+			*m = m.Round(o.Currency)
+		}
+	}
+}
+
+// Payout is what the seller receives on release: the amount paid less any
+// partial refund.
+func (o *Order) Payout() money.Money {
+	if o.RefundedAmount == nil {
+		return o.Amount
+	}
+	return o.Amount.Sub(*o.RefundedAmount)
+}
+
+// Party reports whether userID is the buyer or seller of o.
+func (o *Order) Party(userID string) bool {
+	return userID == o.BuyerID || userID == o.SellerID
+}
+
+// transition moves o to status along with any extra fields.
+func transition(o *Order, to Status, extra map[string]interface{}) error {
+	if !o.Status.CanMove(to) {
+		return ErrState
+	}
+	patch := map[string]interface{}{"status": to}
+	for k, v := range extra {
+		patch[k] = v
+	}
+	return Patch(o, patch)
+}
+
+// Patch updates fields of o. The update only applies if o is still in the
+// state it was read in, so concurrent changes can't both win; ErrState is
+// returned if it has moved on.
+func Patch(o *Order, patch map[string]interface{}) error {
+	return PatchWhere(o, "", patch)
+}
+
+// PatchUnstarted is like Patch but also fails once checkout has started a
+// payment, for changes to what the buyer owes.
+func PatchUnstarted(o *Order, patch map[string]interface{}) error {
+	return PatchWhere(o, "&payment_id=is.null", patch)
+}
+
+// PatchWhere is like Patch with an extra PostgREST filter, such as
+// "&disputed=is.false", that the order must also still match.
+func PatchWhere(o *Order, filter string, patch map[string]interface{}) error {
+	var updated []Order
+	query := "id=eq." + url.QueryEscape(o.ID) + "&status=eq." + string(o.Status) + filter
+	if err := supabase.Update(table, query, patch, &updated); err != nil {
+		return err
+	}
+	if len(updated) == 0 {
+		return ErrState
+	}
+	*o = updated[0]
+	o.round()
+	return nil
+}
+
+// Checkout starts paying for o with p. Only the buyer can pay, and only
+// before the deadline.
+func Checkout(ctx context.Context, p Provider, o *Order, userID string, now time.Time) (Intent, error) {
+	switch {
+	case o.BuyerID != userID:
+		return Intent{}, ErrForbidden
+	case o.Status != Pending:
+		return Intent{}, ErrState
+	case !now.Before(o.DueAt):
+		return Intent{}, ErrOverdue
+	}
+
+	intent, err := p.CreatePayment(ctx, o)
+	if err != nil {
+		return Intent{}, fmt.Errorf("create payment: %w", err)
+	}
+	patch := map[string]interface{}{"provider": p.Name(), "payment_id": intent.PaymentID}
+	query := "id=eq." + url.QueryEscape(o.ID) + "&status=eq." + string(Pending)
+	if err := supabase.Update(table, query, patch, nil); err != nil {
+		return Intent{}, err
+	}
+	o.Provider, o.PaymentID = p.Name(), intent.PaymentID
+	return intent, nil
+}
+
+// MarkPaid records that the payment for o succeeded and the money is now
+// held in escrow. paid must be exactly o's amount, in o's currency.
+func MarkPaid(o *Order, paymentID string, paid money.Money, now time.Time) error {
+	if o.PaymentID != "" && paymentID != o.PaymentID {
+		return fmt.Errorf("%w: payment %s isn't for this order", ErrState, paymentID)
+	}
+	if !paid.Equal(o.Amount) {
+		return fmt.Errorf("%w: paid %s %s for an order of %s %s", ErrMismatch, paid.Decimal(), paid.Currency, o.Amount.Decimal(), o.Amount.Currency)
+	}
+	if err := transition(o, Paid, map[string]interface{}{"payment_id": paymentID, "paid_at": now}); err != nil {
+		return err
+	}
+	publish(events.PaymentReceived, o.SellerID, o)
+	return nil
+}
+
+// Release pays the escrowed money out to the seller.
+func Release(ctx context.Context, p Provider, o *Order, now time.Time) error {
+	if o.Disputed {
+		return ErrDisputed
+	}
+	return release(ctx, p, o, now)
+}
+
+// release pays out o and lifts any dispute hold along with the transition.
+func release(ctx context.Context, p Provider, o *Order, now time.Time) error {
+	if o.Status != Paid {
+		return ErrState
+	}
+	if err := p.Release(ctx, o); err != nil {
+		return fmt.Errorf("release payment: %w", err)
+	}
+	if err := transition(o, Released, map[string]interface{}{"released_at": now, "disputed": false}); err != nil {
+		return err
+	}
+	publish(events.PaymentReleased, o.SellerID, o)
+	return nil
+}
+
+// Refund returns the escrowed money to the buyer.
+func Refund(ctx context.Context, p Provider, o *Order, now time.Time) error {
+	if o.Disputed {
+		return ErrDisputed
+	}
+	return refund(ctx, p, o, now)
+}
+
+// refund returns o in full and lifts any dispute hold along with the
+// transition.
+func refund(ctx context.Context, p Provider, o *Order, now time.Time) error {
+	if o.Status != Paid {
+		return ErrState
+	}
+	if err := p.Refund(ctx, o, o.Amount); err != nil {
+		return fmt.Errorf("refund payment: %w", err)
+	}
+	if err := transition(o, Refunded, map[string]interface{}{"refunded_at": now, "disputed": false}); err != nil {
+		return err
+	}
+	publish(events.PaymentRefunded, o.BuyerID, o)
+	return nil
+}
+
+// PartialRefund returns amount to the buyer and releases the rest of the
+// escrowed money to the seller.
+func PartialRefund(ctx context.Context, p Provider, o *Order, amount money.Money, now time.Time) error {
+	if o.Disputed {
+		return ErrDisputed
+	}
+	return partialRefund(ctx, p, o, amount, now)
+}
+
+func partialRefund(ctx context.Context, p Provider, o *Order, amount money.Money, now time.Time) error {
+	if o.Status != Paid {
+		return ErrState
+	}
+	if !amount.IsPositive() || amount.Cmp(o.Amount) >= 0 {
+		return fmt.Errorf("%w: a partial refund must be between zero and %s", ErrState, o.Amount)
+	}
+	if err := p.Refund(ctx, o, amount); err != nil {
+		return fmt.Errorf("refund payment: %w", err)
+	}
+	if err := Patch(o, map[string]interface{}{"refunded_amount": amount}); err != nil {
+		return err
+	}
+	events.Publish(events.Event{
+		Type:      events.PaymentRefunded,
+		UserID:    o.BuyerID,
+		ListingID: o.ListingID,
+		Data:      map[string]string{"title": o.Title, "amount": amount.String(), "order_id": o.ID},
+	})
+	return release(ctx, p, o, now)
+}
+
+// SettleDispute moves the escrow on an order held by a dispute: amount goes
+// back to the buyer and the rest is released to the seller. The hold is only
+// lifted with the final transition, so nothing can pay out around it.
+func SettleDispute(ctx context.Context, p Provider, o *Order, amount money.Money, now time.Time) error {
+	if !o.Disputed {
+		return fmt.Errorf("%w: order isn't held for a dispute", ErrState)
+	}
+	switch {
+	case amount.IsZero():
+		return release(ctx, p, o, now)
+	case amount.Cmp(o.Amount) >= 0:
+		return refund(ctx, p, o, now)
+	default:
+		return partialRefund(ctx, p, o, amount, now)
+	}
+}
+
+// HandleWebhook applies a verified provider callback to its order.
+// Callbacks for orders that have already moved on are ignored, since
+// providers retry deliveries.
+func HandleWebhook(e WebhookEvent, now time.Time) error {
+	if e.Type != PaymentSucceeded || e.OrderID == "" {
+		return nil
+	}
+	o, err := Get(e.OrderID)
+	if err != nil {
+		return err
+	}
+	if o.Status != Pending {
+		return nil
+	}
+	return MarkPaid(o, e.PaymentID, e.Amount, now)
+}
+
+// OnOverdue, when set, is called for each order that misses its payment
+// deadline.
+var OnOverdue func(Order)
+
+// Run calls ExpireOverdue every interval until ctx is cancelled.
+func Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := ExpireOverdue(time.Now().UTC()); err != nil {
+				log.Printf("Warning: overdue order check failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Marked %d orders unpaid", n)
+			}
+		}
+	}
+}
+
+// ExpireOverdue marks pending orders past their deadline unpaid and tells
+// both parties.
+func ExpireOverdue(now time.Time) (int, error) {
+	var overdue []Order
+	query := "status=eq." + string(Pending) + "&due_at=lte." + url.QueryEscape(now.Format(time.RFC3339))
+	if err := supabase.Update(table, query, map[string]interface{}{"status": Unpaid}, &overdue); err != nil {
+		return 0, err
+	}
+	for i := range overdue {
+		o := &overdue[i]
+		o.round()
+		publish(events.PaymentOverdue, o.BuyerID, o)
+		publish(events.UnpaidItem, o.SellerID, o)
+		if OnOverdue != nil {
+			OnOverdue(*o)
+		}
+	}
+	return len(overdue), nil
+}
+
+func publish(t events.Type, userID string, o *Order) {
+	amount := o.Amount
+	if t == events.PaymentReleased {
+		amount = o.Payout()
+	}
+	events.Publish(events.Event{
+		Type:      t,
+		UserID:    userID,
+		ListingID: o.ListingID,
+		Data: map[string]string{
+			"title":    o.Title,
+			"amount":   amount.String(),
+			"order_id": o.ID,
+			"due_at":   o.DueAt.Format(time.RFC1123),
+		},
+	})
+}
@@ -0,0 +1,181 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+func TestStatusCanMove(t *testing.T) {
+	for _, tc := range []struct {
+		from, to Status
+		want     bool
+	}{
+		{Pending, Paid, true},
+		{Pending, Unpaid, true},
+		{Pending, Released, false},
+		{Paid, Released, true},
+		{Paid, Refunded, true},
+		{Paid, Pending, false},
+		{Released, Refunded, false},
+		{Unpaid, Paid, false},
+	} {
+		if got := tc.from.CanMove(tc.to); got != tc.want {
+			t.Errorf("%s → %s: got %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestCheckoutChecks(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &FakeProvider{Secret: "s"}
+	o := Order{ID: "o1", BuyerID: "buyer", SellerID: "seller", Status: Pending, DueAt: now.Add(time.Hour)}
+
+	if _, err := Checkout(context.Background(), p, &o, "seller", now); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for the seller, got %v", err)
+	}
+	if _, err := Checkout(context.Background(), p, &o, "buyer", now.Add(2*time.Hour)); !errors.Is(err, ErrOverdue) {
+		t.Errorf("Expected ErrOverdue past the deadline, got %v", err)
+	}
+	paid := o
+	paid.Status = Paid
+	if _, err := Checkout(context.Background(), p, &paid, "buyer", now); !errors.Is(err, ErrState) {
+		t.Errorf("Expected ErrState for a paid order, got %v", err)
+	}
+}
+
+func TestPartialRefundChecks(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &FakeProvider{}
+	o := Order{ID: "o1", PaymentID: "pi", Amount: money.New(1000, "USD"), Currency: "USD", Status: Paid, Disputed: true}
+
+	if err := PartialRefund(context.Background(), p, &o, money.New(400, "USD"), now); !errors.Is(err, ErrDisputed) {
+		t.Errorf("Expected ErrDisputed while a dispute holds escrow, got %v", err)
+	}
+	o.Disputed = false
+	for _, amount := range []int64{0, 1000, 1500} {
+		if err := PartialRefund(context.Background(), p, &o, money.New(amount, "USD"), now); !errors.Is(err, ErrState) {
+			t.Errorf("Expected ErrState refunding %d of 1000, got %v", amount, err)
+		}
+	}
+	if _, ok := p.Refunded("pi"); ok {
+		t.Error("Expected no refund to reach the provider")
+	}
+}
+
+func TestPayout(t *testing.T) {
+	o := Order{Amount: money.New(1000, "USD"), Currency: "USD"}
+	if got := o.Payout(); got.Amount != 1000 {
+		t.Errorf("Expected the full 1000 paid out, got %v", got)
+	}
+	refunded := money.New(250, "USD")
+	o.RefundedAmount = &refunded
+	if got := o.Payout(); got.Amount != 750 {
+		t.Errorf("Expected 750 paid out after a 250 refund, got %v", got)
+	}
+}
+
+func TestFakeWebhook(t *testing.T) {
+	p := &FakeProvider{Secret: "s"}
+	payload := []byte(`{"type": "payment_succeeded", "payment_id": "fake_pi_o1", "order_id": "o1", "amount": 40, "currency": "USD"}`)
+
+	h := http.Header{}
+	h.Set(FakeSignatureHeader, p.Sign(payload))
+	e, err := p.ParseWebhook(payload, h, time.Now())
+	if err != nil {
+		t.Fatalf("Expected a valid webhook, got %v", err)
+	}
+	if e.Type != PaymentSucceeded || e.OrderID != "o1" || e.PaymentID != "fake_pi_o1" || !e.Amount.Equal(money.New(4000, "USD")) {
+		t.Errorf("Unexpected event %+v", e)
+	}
+
+	h.Set(FakeSignatureHeader, (&FakeProvider{Secret: "other"}).Sign(payload))
+	if _, err := p.ParseWebhook(payload, h, time.Now()); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected ErrSignature for the wrong secret, got %v", err)
+	}
+	h.Set(FakeSignatureHeader, (&FakeProvider{}).Sign(payload))
+	if _, err := (&FakeProvider{}).ParseWebhook(payload, h, time.Now()); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected ErrSignature without a secret, got %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"nothing configured", nil, ""},
+		{"stripe without webhook secret", map[string]string{"STRIPE_SECRET_KEY": "sk_test"}, ""},
+		{"stripe", map[string]string{"STRIPE_SECRET_KEY": "sk_test", "STRIPE_WEBHOOK_SECRET": "whsec"}, "stripe"},
+		{"fake secret without flag", map[string]string{"PAYMENTS_WEBHOOK_SECRET": "s"}, ""},
+		{"fake without secret", map[string]string{"PAYMENTS_FAKE": "true"}, ""},
+		{"fake", map[string]string{"PAYMENTS_FAKE": "true", "PAYMENTS_WEBHOOK_SECRET": "s"}, "fake"},
+	} {
+		for _, k := range []string{"STRIPE_SECRET_KEY", "STRIPE_WEBHOOK_SECRET", "PAYMENTS_FAKE", "PAYMENTS_WEBHOOK_SECRET"} {
+			t.Setenv(k, tc.env[k])
+		}
+		p, err := FromEnv()
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", tc.name, p.Name())
+			}
+		} else if err != nil || p.Name() != tc.want {
+			t.Errorf("%s: expected %s, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestFakeRefundLimit(t *testing.T) {
+	p := &FakeProvider{}
+	o := Order{PaymentID: "pi", Amount: money.New(1000, "USD"), Currency: "USD"}
+	if err := p.Refund(context.Background(), &o, money.New(1500, "USD")); err == nil {
+		t.Error("Expected refunding more than was paid to fail")
+	}
+	if err := p.Refund(context.Background(), &o, money.New(400, "USD")); err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := p.Refunded("pi"); !ok || m.Amount != 400 {
+		t.Errorf("Expected 400 refunded, got %v (%v)", m, ok)
+	}
+}
+
+func TestStripeWebhookSignature(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &StripeProvider{WebhookSecret: "whsec_test"}
+	payload := []byte(`{"type": "payment_intent.succeeded", "data": {"object": {"id": "pi_1", "amount_received": 4000, "currency": "usd", "metadata": {"order_id": "o1"}}}}`)
+
+	sign := func(ts time.Time, secret string) http.Header {
+		mac := hmac.New(sha256.New, []byte(secret))
+		fmt.Fprintf(mac, "%d.%s", ts.Unix(), payload)
+		h := http.Header{}
+		h.Set(StripeSignatureHeader, fmt.Sprintf("t=%d,v1=%s", ts.Unix(), hex.EncodeToString(mac.Sum(nil))))
+		return h
+	}
+
+	e, err := p.ParseWebhook(payload, sign(now, "whsec_test"), now)
+	if err != nil {
+		t.Fatalf("Expected a valid webhook, got %v", err)
+	}
+	if e.Type != PaymentSucceeded || e.PaymentID != "pi_1" || e.OrderID != "o1" || !e.Amount.Equal(money.New(4000, "USD")) {
+		t.Errorf("Unexpected event %+v", e)
+	}
+
+	if _, err := p.ParseWebhook(payload, sign(now, "whsec_other"), now); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected ErrSignature for the wrong secret, got %v", err)
+	}
+	if _, err := p.ParseWebhook(payload, sign(now.Add(-10*time.Minute), "whsec_test"), now); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected ErrSignature for a stale delivery, got %v", err)
+	}
+	if _, err := p.ParseWebhook(payload, http.Header{}, now); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected ErrSignature without a header, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+// ErrSignature is returned for webhook deliveries that fail verification.
+var ErrSignature = errors.New("invalid webhook signature")
+
+// Intent is what the client needs to complete a payment.
+type Intent struct {
+	PaymentID    string `json:"payment_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	CheckoutURL  string `json:"checkout_url,omitempty"`
+}
+
+// WebhookType is the kind of provider callback, normalized across providers.
+type WebhookType string
+
+const (
+	PaymentSucceeded WebhookType = "payment_succeeded"
+	PaymentFailed    WebhookType = "payment_failed"
+)
+
+// WebhookEvent is a verified provider callback. Type is empty for callbacks
+// the marketplace doesn't act on. Amount is what the buyer paid.
+type WebhookEvent struct {
+	Type      WebhookType
+	PaymentID string
+	OrderID   string
+	Amount    money.Money
+}
+
+// Provider takes and moves money on the marketplace's behalf.
+type Provider interface {
+	Name() string
+	// CreatePayment starts collecting o's amount from the buyer into escrow.
+	CreatePayment(ctx context.Context, o *Order) (Intent, error)
+	// Release pays o's escrowed amount, less any partial refund, out to the
+	// seller.
+	Release(ctx context.Context, o *Order) error
+	// Refund returns amount of o's payment to the buyer.
+	Refund(ctx context.Context, o *Order, amount money.Money) error
+	// ParseWebhook verifies a callback's signature and decodes it.
+	ParseWebhook(payload []byte, header http.Header, now time.Time) (WebhookEvent, error)
+}
+
+// Default is the provider used by handlers. Until main replaces it with
+// FromEnv it is a FakeProvider with no secret, which rejects every webhook.
+var Default Provider = &FakeProvider{}
+
+// FromEnv returns a Stripe provider when STRIPE_SECRET_KEY is set. The
+// FakeProvider, which lets anyone holding its secret mark orders paid, is only
+// used for development when PAYMENTS_FAKE=true. Either needs a webhook secret.
+func FromEnv() (Provider, error) {
+	if key := os.Getenv("STRIPE_SECRET_KEY"); key != "" {
+		secret := os.Getenv("STRIPE_WEBHOOK_SECRET")
+		if secret == "" {
+			return nil, errors.New("STRIPE_WEBHOOK_SECRET must be set with STRIPE_SECRET_KEY")
+		}
+		return &StripeProvider{SecretKey: key, WebhookSecret: secret}, nil
+	}
+	if os.Getenv("PAYMENTS_FAKE") != "true" {
+		return nil, errors.New("STRIPE_SECRET_KEY must be set (or PAYMENTS_FAKE=true for development)")
+	}
+	secret := os.Getenv("PAYMENTS_WEBHOOK_SECRET")
+	if secret == "" {
+		return nil, errors.New("PAYMENTS_WEBHOOK_SECRET must be set with PAYMENTS_FAKE")
+	}
+	return &FakeProvider{Secret: secret}, nil
+}
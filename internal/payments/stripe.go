@@ -0,0 +1,186 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// StripeSignatureHeader carries a Stripe webhook's signature.
+const StripeSignatureHeader = "Stripe-Signature"
+
+// stripeTolerance is how old a signed webhook may be, to stop replays.
+const stripeTolerance = 5 * time.Minute
+
+// StripeProvider collects payments with Stripe PaymentIntents into the
+// platform account and pays sellers out with transfers to their connected
+// account (profiles.stripe_account_id) on release.
+type StripeProvider struct {
+	SecretKey     string
+	WebhookSecret string
+	// BaseURL overrides https://api.stripe.com, for tests.
+	BaseURL string
+	Client  *http.Client
+}
+
+func (p *StripeProvider) Name() string { return "stripe" }
+
+func (p *StripeProvider) CreatePayment(ctx context.Context, o *Order) (Intent, error) {
+	form := url.Values{
+		"amount":                             {strconv.FormatInt(o.Amount.Amount, 10)},
+		"currency":                           {strings.ToLower(string(o.Currency))},
+		"transfer_group":                     {"order_" + o.ID},
+		"metadata[order_id]":                 {o.ID},
+		"metadata[listing_id]":               {o.ListingID},
+		"automatic_payment_methods[enabled]": {"true"},
+	}
+	var pi struct {
+		ID           string `json:"id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := p.post(ctx, "/v1/payment_intents", form, "order_"+o.ID, &pi); err != nil {
+		return Intent{}, err
+	}
+	return Intent{PaymentID: pi.ID, ClientSecret: pi.ClientSecret}, nil
+}
+
+func (p *StripeProvider) Release(ctx context.Context, o *Order) error {
+	var profiles []struct {
+		StripeAccountID string `json:"stripe_account_id"`
+	}
+	if err := supabase.Select("profiles", "select=stripe_account_id&id=eq."+url.QueryEscape(o.SellerID), &profiles); err != nil {
+		return err
+	}
+	if len(profiles) == 0 || profiles[0].StripeAccountID == "" {
+		return fmt.Errorf("seller %s has no connected Stripe account", o.SellerID)
+	}
+	form := url.Values{
+		"amount":         {strconv.FormatInt(o.Payout().Amount, 10)},
+		"currency":       {strings.ToLower(string(o.Currency))},
+		"destination":    {profiles[0].StripeAccountID},
+		"transfer_group": {"order_" + o.ID},
+	}
+	return p.post(ctx, "/v1/transfers", form, "release_"+o.ID, nil)
+}
+
+func (p *StripeProvider) Refund(ctx context.Context, o *Order, amount money.Money) error {
+	form := url.Values{
+		"payment_intent": {o.PaymentID},
+		"amount":         {strconv.FormatInt(amount.Round(o.Currency).Amount, 10)},
+	}
+	return p.post(ctx, "/v1/refunds", form, "refund_"+o.ID, nil)
+}
+
+// post sends a form request to the Stripe API. The idempotency key makes
+// retries of the same money movement safe.
+func (p *StripeProvider) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	base := p.BaseURL
+	if base == "" {
+		base = "https://api.stripe.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.SecretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("stripe %s failed: status=%d body=%s", path, resp.StatusCode, string(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ParseWebhook checks the Stripe-Signature header, "t=<unix>,v1=<hex>", which
+// signs "<t>.<payload>" with the endpoint's webhook secret.
+func (p *StripeProvider) ParseWebhook(payload []byte, header http.Header, now time.Time) (WebhookEvent, error) {
+	var timestamp string
+	var signatures [][]byte
+	for _, part := range strings.Split(header.Get(StripeSignatureHeader), ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			if sig, err := hex.DecodeString(v); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || p.WebhookSecret == "" {
+		return WebhookEvent{}, ErrSignature
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > stripeTolerance || age < -stripeTolerance {
+		return WebhookEvent{}, ErrSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.WebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+	valid := false
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			valid = true
+		}
+	}
+	if !valid {
+		return WebhookEvent{}, ErrSignature
+	}
+
+	var event struct {
+		Type string `json:"type"`
+		Data struct {
+			Object struct {
+				ID             string            `json:"id"`
+				AmountReceived int64             `json:"amount_received"`
+				Currency       string            `json:"currency"`
+				Metadata       map[string]string `json:"metadata"`
+			} `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return WebhookEvent{}, fmt.Errorf("decode webhook: %w", err)
+	}
+	obj := event.Data.Object
+	e := WebhookEvent{
+		PaymentID: obj.ID,
+		OrderID:   obj.Metadata["order_id"],
+		Amount:    money.New(obj.AmountReceived, money.Currency(strings.ToUpper(obj.Currency))),
+	}
+	switch event.Type {
+	case "payment_intent.succeeded":
+		e.Type = PaymentSucceeded
+	case "payment_intent.payment_failed":
+		e.Type = PaymentFailed
+	}
+	return e, nil
+}
@@ -0,0 +1,183 @@
+package questions
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/moderation"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// MaxLength caps the size of a question or answer.
+const MaxLength = 1000
+
+var (
+	// ErrAuctionEnded is returned when asking about a closed auction.
+	ErrAuctionEnded = fmt.Errorf("questions can't be asked after the auction has ended")
+	// ErrOwnListing is returned when a seller asks about their own listing.
+	ErrOwnListing = fmt.Errorf("sellers can't ask questions on their own listing")
+	// ErrNotSeller is returned when anyone but the seller tries to answer.
+	ErrNotSeller = fmt.Errorf("only the seller can answer questions")
+	// ErrAlreadyAnswered is returned when answering a question twice.
+	ErrAlreadyAnswered = fmt.Errorf("question has already been answered")
+	// ErrNotFound is returned for unknown questions.
+	ErrNotFound = fmt.Errorf("question not found")
+	// ErrInvalidText is returned for blank or oversized text.
+	ErrInvalidText = fmt.Errorf("text must be between 1 and %d characters", MaxLength)
+)
+
+// Question is a public question on a listing and the seller's answer.
+type Question struct {
+	ID         string     `json:"id,omitempty"`
+	ListingID  string     `json:"listing_id"`
+	AskerID    string     `json:"asker_id"`
+	Question   string     `json:"question"`
+	Answer     string     `json:"answer,omitempty"`
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// PublicQuestion is a question as shown on the listing, with the asker
+// masked by the same per-auction handle bid history uses.
+type PublicQuestion struct {
+	ID         string     `json:"id,omitempty"`
+	ListingID  string     `json:"listing_id"`
+	Asker      string     `json:"asker"`
+	Question   string     `json:"question"`
+	Answer     string     `json:"answer,omitempty"`
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Public masks the askers of qs, which must all be on listingID and in the
+// order they were asked.
+func Public(listingID string, qs []Question) ([]PublicQuestion, error) {
+	askers := make([]string, 0, len(qs))
+	for _, q := range qs {
+		askers = append(askers, q.AskerID)
+	}
+	handles, err := ledger.Handles(listingID, askers)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]PublicQuestion, len(qs))
+	for i, q := range qs {
+		out[i] = PublicQuestion{
+			ID:         q.ID,
+			ListingID:  q.ListingID,
+			Asker:      handles[q.AskerID],
+			Question:   q.Question,
+			Answer:     q.Answer,
+			AnsweredAt: q.AnsweredAt,
+			CreatedAt:  q.CreatedAt,
+		}
+	}
+	return out, nil
+}
+
+// clean trims text, enforces the length limit and strips contact details.
+func clean(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || len(text) > MaxLength {
+		return "", ErrInvalidText
+	}
+	text, _ = moderation.RedactContactInfo(text)
+	return text, nil
+}
+
+// Ask posts a public question from askerID on l.
+func Ask(l *listing.Listing, askerID, text string, now time.Time) (*Question, error) {
+	if !now.Before(l.AuctionEndTime) {
+		return nil, ErrAuctionEnded
+	}
+	if askerID == l.SellerID {
+		return nil, ErrOwnListing
+	}
+	text, err := clean(text)
+	if err != nil {
+		return nil, err
+	}
+
+	q := Question{ListingID: l.ID, AskerID: askerID, Question: text, CreatedAt: now}
+	var inserted []Question
+	if err := supabase.Insert("listing_questions", []Question{q}, &inserted); err != nil {
+		return nil, fmt.Errorf("store question: %w", err)
+	}
+	if len(inserted) > 0 {
+		q = inserted[0]
+	}
+	return &q, nil
+}
+
+// Answer records the seller's answer to a question on l.
+func Answer(l *listing.Listing, sellerID, questionID, text string, now time.Time) (*Question, error) {
+	if sellerID != l.SellerID {
+		return nil, ErrNotSeller
+	}
+	text, err := clean(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Question
+	query := "id=eq." + url.QueryEscape(questionID) + "&listing_id=eq." + url.QueryEscape(l.ID)
+	if err := supabase.Select("listing_questions", query, &rows); err != nil {
+		return nil, fmt.Errorf("fetch question: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	if rows[0].AnsweredAt != nil {
+		return nil, ErrAlreadyAnswered
+	}
+
+	q := rows[0]
+	q.Answer = text
+	q.AnsweredAt = &now
+	patch := map[string]interface{}{"answer": q.Answer, "answered_at": now}
+	var updated []Question
+	if err := supabase.Update("listing_questions", query+"&answer=is.null&answered_at=is.null", patch, &updated); err != nil {
+		return nil, fmt.Errorf("store answer: %w", err)
+	}
+	// A concurrent answer got there first
+	if len(updated) == 0 {
+		return nil, ErrAlreadyAnswered
+	}
+	return &q, nil
+}
+
+// ForListing returns every question on listingID, oldest first.
+func ForListing(listingID string) ([]Question, error) {
+	var rows []Question
+	query := "listing_id=eq." + url.QueryEscape(listingID) + "&order=created_at.asc"
+	if err := supabase.Select("listing_questions", query, &rows); err != nil {
+		return nil, fmt.Errorf("fetch questions: %w", err)
+	}
+	return rows, nil
+}
+
+// Audience returns the users to notify when a question on listingID is
+// answered: the asker plus everyone who has bid on the listing.
+func Audience(listingID, askerID string) ([]string, error) {
+	var bids []struct {
+		UserID string `json:"user_id"`
+	}
+	if err := supabase.Select("bids", "listing_id=eq."+url.QueryEscape(listingID)+"&select=user_id", &bids); err != nil {
+		return nil, fmt.Errorf("fetch bidders: %w", err)
+	}
+
+	seen := map[string]bool{askerID: true}
+	users := []string{askerID}
+	for _, b := range bids {
+		if !seen[b.UserID] {
+			seen[b.UserID] = true
+			users = append(users, b.UserID)
+		}
+	}
+	return users, nil
+}
@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Result describes the state of a key after a request was counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the oldest request in the window expires.
+	Reset time.Duration
+}
+
+// Limiter counts requests per key in a sliding window.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error)
+}
+
+// slidingWindowScript keeps one sorted-set member per request, scored by its
+// timestamp in milliseconds, and trims members older than the window.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local member = ARGV[4]
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, member)
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// RedisLimiter is a sliding-window limiter shared by every server instance.
+type RedisLimiter struct {
+	rdb *redis.Client
+	seq atomic.Uint64
+}
+
+// NewRedisLimiter creates a limiter backed by rdb.
+func NewRedisLimiter(rdb *redis.Client) *RedisLimiter {
+	return &RedisLimiter{rdb: rdb}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	now := time.Now().UnixMilli()
+	member := fmt.Sprintf("%d-%d", now, l.seq.Add(1))
+
+	vals, err := slidingWindowScript.Run(ctx, l.rdb, []string{"ratelimit:" + key},
+		now, window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("redis rate limit: %w", err)
+	}
+	if len(vals) != 3 {
+		return Result{}, fmt.Errorf("redis rate limit: unexpected reply %v", vals)
+	}
+
+	return Result{
+		Allowed:   vals[0] == 1,
+		Limit:     limit,
+		Remaining: max(limit-int(vals[1]), 0),
+		Reset:     time.Duration(vals[2]) * time.Millisecond,
+	}, nil
+}
+
+// MemoryLimiter is a sliding-window limiter local to this process.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	windows map[string][]time.Time
+	calls   int
+}
+
+// NewMemoryLimiter creates an empty in-memory limiter.
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{windows: make(map[string][]time.Time)}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Periodically drop keys nobody has used for a while
+	l.calls++
+	if l.calls%1000 == 0 {
+		for k, hits := range l.windows {
+			if len(hits) == 0 || now.Sub(hits[len(hits)-1]) > time.Hour {
+				delete(l.windows, k)
+			}
+		}
+	}
+
+	hits := l.windows[key]
+	i := 0
+	for i < len(hits) && now.Sub(hits[i]) >= window {
+		i++
+	}
+	hits = hits[i:]
+
+	allowed := len(hits) < limit
+	if allowed {
+		hits = append(hits, now)
+	}
+	l.windows[key] = hits
+
+	reset := window
+	if len(hits) > 0 {
+		reset = hits[0].Add(window).Sub(now)
+	}
+	return Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: max(limit-len(hits), 0),
+		Reset:     reset,
+	}, nil
+}
+
+// FallbackLimiter uses a primary limiter and switches to a secondary one
+// whenever the primary fails, e.g. while Redis is unavailable.
+type FallbackLimiter struct {
+	Primary   Limiter
+	Secondary Limiter
+
+	warned atomic.Bool
+}
+
+func (l *FallbackLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	if l.Primary != nil {
+		res, err := l.Primary.Allow(ctx, key, limit, window)
+		if err == nil {
+			l.warned.Store(false)
+			return res, nil
+		}
+		if !l.warned.Swap(true) {
+			log.Printf("Warning: rate limiter falling back to memory: %v", err)
+		}
+	}
+	return l.Secondary.Allow(ctx, key, limit, window)
+}
+
+// New returns a Redis-backed limiter with an in-memory fallback. When rdb is
+// nil only the in-memory limiter is used.
+func New(rdb *redis.Client) Limiter {
+	fl := &FallbackLimiter{Secondary: NewMemoryLimiter()}
+	if rdb != nil {
+		fl.Primary = NewRedisLimiter(rdb)
+	}
+	return fl
+}
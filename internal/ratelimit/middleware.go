@@ -0,0 +1,170 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a named limit applied to a group of endpoints.
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	// Key identifies who the request is counted against.
+	Key func(r *http.Request) string
+}
+
+// Auth throttles login and signup per client IP to slow credential stuffing.
+var Auth = Policy{Name: "auth", Limit: 10, Window: time.Minute, Key: ByIP}
+
+// Bid throttles bid submission per user per auction.
+var Bid = Policy{Name: "bid", Limit: 20, Window: time.Minute, Key: ByUserAndPath("id")}
+
+// Read throttles read APIs per user, or per IP for anonymous callers.
+var Read = Policy{Name: "read", Limit: 120, Window: time.Minute, Key: ByUser}
+
+// ClientIP returns the caller's address. X-Forwarded-For is only honoured
+// when the request comes from a trusted proxy (TRUSTED_PROXIES, a
+// comma-separated list of IPs or CIDRs); the right-most hop that isn't one of
+// those proxies is the client, since anything to its left was written by the
+// client itself.
+func ClientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	proxies := trustedProxies()
+	if !trusted(proxies, remote) {
+		return remote
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		client = hop
+		if !trusted(proxies, hop) {
+			break
+		}
+	}
+	return client
+}
+
+func trustedProxies() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, entry := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		if _, n, err := net.ParseCIDR(entry); err == nil {
+			nets = append(nets, n)
+		}
+	}
+	return nets
+}
+
+func trusted(proxies []*net.IPNet, addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ByIP keys requests by client IP.
+func ByIP(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// VerifiedUser returns the user r's bearer token belongs to. Tokens are
+// checked locally against SUPABASE_JWT_SECRET, so the result is only as fresh
+// as the token's expiry.
+func VerifiedUser(r *http.Request) (string, bool) {
+	token := r.Header.Get("Authorization")
+	if len(token) > 7 && token[:7] == "Bearer " {
+		token = token[7:]
+	}
+	return verifyToken(token, []byte(os.Getenv("SUPABASE_JWT_SECRET")), time.Now())
+}
+
+// ByUser keys requests by the user a valid bearer token belongs to, falling
+// back to the client IP. Unverified tokens are keyed by IP, so inventing
+// tokens doesn't buy a fresh bucket.
+func ByUser(r *http.Request) string {
+	if userID, ok := VerifiedUser(r); ok {
+		return "user:" + userID
+	}
+	return ByIP(r)
+}
+
+// ByUserAndPath keys requests by user and the named path value, e.g. the
+// auction being bid on.
+func ByUserAndPath(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return ByUser(r) + ":" + name + ":" + r.PathValue(name)
+	}
+}
+
+// Middleware enforces p on next. Every response carries RateLimit-* headers;
+// rejected requests get 429 with Retry-After. If the limiter fails the
+// request is let through.
+func Middleware(l Limiter, p Policy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, err := l.Allow(r.Context(), p.Name+":"+p.Key(r), p.Limit, p.Window)
+		if err != nil {
+			log.Printf("Warning: rate limit %s unavailable: %v", p.Name, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reset := int(math.Ceil(res.Reset.Seconds()))
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(reset))
+		w.Header().Set("RateLimit-Policy", strconv.Itoa(p.Limit)+";w="+strconv.Itoa(int(p.Window.Seconds())))
+
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(max(reset, 1)))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Too many requests, please slow down"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Reads applies p to GET requests only, leaving writes to their own policies.
+func Reads(l Limiter, p Policy, next http.Handler) http.Handler {
+	limited := Middleware(l, p, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			limited.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	p := Policy{Name: "test", Limit: 2, Window: time.Minute, Key: ByIP}
+	handler := Middleware(New(nil), p, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/api/auth/login", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i, rr.Code)
+		}
+		if got := rr.Header().Get("RateLimit-Remaining"); got != []string{"1", "0"}[i] {
+			t.Errorf("Request %d: expected RateLimit-Remaining %d, got %s", i, 1-i, got)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/api/auth/login", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 Too Many Requests, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected Retry-After header on 429")
+	}
+
+	other := httptest.NewRequest("POST", "/api/auth/login", nil)
+	other.RemoteAddr = "10.0.0.2:1234"
+	rr2 := httptest.NewRecorder()
+	handler.ServeHTTP(rr2, other)
+	if rr2.Code != http.StatusOK {
+		t.Errorf("Expected other IPs to be unaffected, got %d", rr2.Code)
+	}
+}
+
+func TestSpoofedForwardedForDoesNotBypassLimit(t *testing.T) {
+	os.Setenv("TRUSTED_PROXIES", "")
+	p := Policy{Name: "test", Limit: 2, Window: time.Minute, Key: ByIP}
+	handler := Middleware(New(nil), p, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var last int
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("POST", "/api/auth/login", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		last = rr.Code
+	}
+	if last != http.StatusTooManyRequests {
+		t.Errorf("Expected a new X-Forwarded-For per request to still hit the limit, got %d", last)
+	}
+}
+
+func TestClientIPTrustedProxies(t *testing.T) {
+	os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.1")
+	defer os.Setenv("TRUSTED_PROXIES", "")
+
+	for _, tc := range []struct {
+		remote, xff, want string
+	}{
+		{"203.0.113.7:1234", "198.51.100.1", "203.0.113.7"},
+		{"10.0.0.1:1234", "198.51.100.1", "198.51.100.1"},
+		{"10.0.0.1:1234", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"10.0.0.1:1234", "1.2.3.4, 198.51.100.1, 192.0.2.1", "198.51.100.1"},
+		{"10.0.0.1:1234", "", "10.0.0.1"},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remote
+		if tc.xff != "" {
+			req.Header.Set("X-Forwarded-For", tc.xff)
+		}
+		if got := ClientIP(req); got != tc.want {
+			t.Errorf("%s via %q: got %s, want %s", tc.remote, tc.xff, got, tc.want)
+		}
+	}
+}
+
+func TestByUserVerifiesTokens(t *testing.T) {
+	os.Setenv("SUPABASE_JWT_SECRET", "secret")
+	defer os.Setenv("SUPABASE_JWT_SECRET", "")
+
+	sign := func(secret, payload string) string {
+		seg := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+		unsigned := seg(`{"alg":"HS256","typ":"JWT"}`) + "." + seg(payload)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(unsigned))
+		return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	for _, tc := range []struct {
+		name, token, want string
+	}{
+		{"valid", sign("secret", fmt.Sprintf(`{"sub":"user123","exp":%d}`, exp)), "user:user123"},
+		{"wrong secret", sign("guess", fmt.Sprintf(`{"sub":"user123","exp":%d}`, exp)), "ip:203.0.113.7"},
+		{"expired", sign("secret", `{"sub":"user123","exp":1}`), "ip:203.0.113.7"},
+		{"random", "not-a-token", "ip:203.0.113.7"},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		if got := ByUser(req); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryLimiterWindow(t *testing.T) {
+	l := NewMemoryLimiter()
+	ctx := httptest.NewRequest("GET", "/", nil).Context()
+
+	if res, _ := l.Allow(ctx, "k", 1, 50*time.Millisecond); !res.Allowed {
+		t.Fatalf("First request should be allowed")
+	}
+	if res, _ := l.Allow(ctx, "k", 1, 50*time.Millisecond); res.Allowed {
+		t.Fatalf("Second request within the window should be rejected")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if res, _ := l.Allow(ctx, "k", 1, 50*time.Millisecond); !res.Allowed {
+		t.Errorf("Request after the window should be allowed")
+	}
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// verifyToken checks an HS256 Supabase access token's signature and expiry
+// and returns its subject. It doesn't call Supabase, so it is cheap enough to
+// run on every request before the handler authenticates it properly.
+func verifyToken(token string, secret []byte, now time.Time) (string, bool) {
+	if len(secret) == 0 {
+		return "", false
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if !decodeSegment(parts[0], &header) || header.Alg != "HS256" {
+		return "", false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", false
+	}
+
+	var claims struct {
+		Sub string `json:"sub"`
+		Exp int64  `json:"exp"`
+	}
+	if !decodeSegment(parts[1], &claims) || claims.Sub == "" || now.Unix() >= claims.Exp {
+		return "", false
+	}
+	return claims.Sub, true
+}
+
+func decodeSegment(seg string, out interface{}) bool {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(b, out) == nil
+}
@@ -0,0 +1,90 @@
+package retraction
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+// Reasons a bidder may give for retracting a bid.
+const (
+	// ReasonTypo covers amounts entered wrongly, e.g. 1000 instead of 100.
+	ReasonTypo = "typo"
+	// ReasonItemChanged covers sellers materially changing the listing after
+	// the bid was placed.
+	ReasonItemChanged = "item_changed"
+)
+
+// Policy decides which bids may be retracted.
+type Policy struct {
+	// Window is how long after placing a bid it may be retracted.
+	Window time.Duration
+	// FinalPeriod is how long before the auction ends retractions close.
+	FinalPeriod time.Duration
+	// TypoFactor is how many times the price it outbid a bid must be to
+	// count as an obvious typo.
+	TypoFactor int64
+	// MaxPerPeriod caps retractions per user within Period.
+	MaxPerPeriod int
+	Period       time.Duration
+}
+
+// DefaultPolicy is used by the retraction endpoint.
+var DefaultPolicy = Policy{
+	Window:       30 * time.Minute,
+	FinalPeriod:  time.Hour,
+	TypoFactor:   5,
+	MaxPerPeriod: 3,
+	Period:       30 * 24 * time.Hour,
+}
+
+// ErrIneligible wraps the reason a retraction was refused.
+var ErrIneligible = errors.New("bid can't be retracted")
+
+func ineligible(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrIneligible, fmt.Sprintf(format, args...))
+}
+
+// Check applies the policy to a request by userID to retract bid on l.
+// outbid is the price the bid had to beat; recent is how many bids the user
+// has retracted within the policy period.
+func (p Policy) Check(l *listing.Listing, bid *ledger.Bid, userID, reason string, outbid money.Money, recent int, now time.Time) error {
+	switch {
+	case l.AuctionType.Sealed():
+		return ineligible("sealed bids can be revised instead")
+	case l.AuctionType == listing.Dutch:
+		return ineligible("accepting a Dutch auction price is a purchase")
+	case l.Units() > 1:
+		return ineligible("bids on multi-quantity listings can't be retracted")
+	case bid.UserID != userID:
+		return ineligible("only the bidder can retract a bid")
+	case bid.Status != "" && bid.Status != ledger.StatusActive:
+		return ineligible("bid is already %s", bid.Status)
+	case !now.Before(l.AuctionEndTime):
+		return ineligible("the auction has ended")
+	case l.AuctionEndTime.Sub(now) < p.FinalPeriod:
+		return ineligible("retractions close %s before the auction ends", p.FinalPeriod)
+	case now.Sub(bid.Timestamp) > p.Window:
+		return ineligible("bids can only be retracted within %s of being placed", p.Window)
+	case recent >= p.MaxPerPeriod:
+		return ineligible("retraction limit of %d reached", p.MaxPerPeriod)
+	}
+
+	switch reason {
+	case ReasonTypo:
+		if bid.BidAmount.Amount < outbid.Amount*p.TypoFactor {
+			return ineligible("a typo retraction needs the bid to be at least %dx the price it outbid", p.TypoFactor)
+		}
+	case ReasonItemChanged:
+		if l.UpdatedAt == nil || !l.UpdatedAt.After(bid.Timestamp) {
+			return ineligible("the listing hasn't been edited since the bid was placed")
+		}
+	default:
+		return ineligible("unknown reason %q", reason)
+	}
+	return nil
+}
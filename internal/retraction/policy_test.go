@@ -0,0 +1,64 @@
+package retraction
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := &listing.Listing{ID: "list1", AuctionEndTime: now.Add(24 * time.Hour)}
+	usd := func(minor int64) money.Money { return money.Money{Amount: minor, Currency: "USD"} }
+	bid := func() *ledger.Bid {
+		return &ledger.Bid{ID: "b1", UserID: "u1", BidAmount: usd(100000), Status: ledger.StatusActive, Timestamp: now.Add(-5 * time.Minute)}
+	}
+	p := DefaultPolicy
+
+	if err := p.Check(l, bid(), "u1", ReasonTypo, usd(10000), 0, now); err != nil {
+		t.Errorf("Expected 1000 over 100 to be an obvious typo, got %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		l      *listing.Listing
+		bid    *ledger.Bid
+		user   string
+		reason string
+		outbid money.Money
+		recent int
+	}{
+		"not the bidder":    {l, bid(), "u2", ReasonTypo, usd(10000), 0},
+		"small raise":       {l, bid(), "u1", ReasonTypo, usd(90000), 0},
+		"unknown reason":    {l, bid(), "u1", "changed my mind", usd(10000), 0},
+		"limit reached":     {l, bid(), "u1", ReasonTypo, usd(10000), p.MaxPerPeriod},
+		"final hour":        {&listing.Listing{AuctionEndTime: now.Add(30 * time.Minute)}, bid(), "u1", ReasonTypo, usd(10000), 0},
+		"ended":             {&listing.Listing{AuctionEndTime: now.Add(-time.Minute)}, bid(), "u1", ReasonTypo, usd(10000), 0},
+		"outside window":    {l, &ledger.Bid{UserID: "u1", BidAmount: usd(100000), Status: ledger.StatusActive, Timestamp: now.Add(-2 * time.Hour)}, "u1", ReasonTypo, usd(10000), 0},
+		"already retracted": {l, &ledger.Bid{UserID: "u1", BidAmount: usd(100000), Status: ledger.StatusRetracted, Timestamp: now}, "u1", ReasonTypo, usd(10000), 0},
+	} {
+		if err := p.Check(tc.l, tc.bid, tc.user, tc.reason, tc.outbid, tc.recent, now); !errors.Is(err, ErrIneligible) {
+			t.Errorf("%s: expected ErrIneligible, got %v", name, err)
+		}
+	}
+
+	if err := p.Check(l, bid(), "u1", ReasonItemChanged, usd(90000), 0, now); !errors.Is(err, ErrIneligible) {
+		t.Errorf("Expected item_changed to need an edit after the bid, got %v", err)
+	}
+	edited := now.Add(-time.Minute)
+	changed := &listing.Listing{ID: "list1", AuctionEndTime: now.Add(24 * time.Hour), UpdatedAt: &edited}
+	if err := p.Check(changed, bid(), "u1", ReasonItemChanged, usd(90000), 0, now); err != nil {
+		t.Errorf("Expected item_changed after an edit to skip the typo check, got %v", err)
+	}
+	if err := p.Check(l, bid(), "u1", ReasonTypo, usd(20000), 0, now); err != nil {
+		t.Errorf("Expected exactly 5x the outbid price to count as a typo, got %v", err)
+	}
+	legacy := bid()
+	legacy.Status = ""
+	if err := p.Check(l, legacy, "u1", ReasonTypo, usd(10000), 0, now); err != nil {
+		t.Errorf("Expected a bid recorded without a status to count as active, got %v", err)
+	}
+}
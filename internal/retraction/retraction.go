@@ -0,0 +1,147 @@
+// Package retraction lets bidders withdraw a bid under a limited policy and
+// rolls the auction back to the best remaining bid.
+package retraction
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Every retraction is written to the bid_retractions audit table.
+const table = "bid_retractions"
+
+// Record is one audited retraction.
+type Record struct {
+	ID          string      `json:"id,omitempty"`
+	BidID       string      `json:"bid_id"`
+	ListingID   string      `json:"listing_id"`
+	UserID      string      `json:"user_id"`
+	Reason      string      `json:"reason"`
+	Explanation string      `json:"explanation,omitempty"`
+	Amount      money.Money `json:"amount"`
+	// PreviousPrice and NewPrice are the auction's current price before and
+	// after the retraction; NewLeaderID is "" when no bids remain.
+	PreviousPrice money.Money `json:"previous_price"`
+	NewPrice      money.Money `json:"new_price"`
+	NewLeaderID   string      `json:"new_leader_id,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// Outcome describes a completed retraction.
+type Outcome struct {
+	Record Record
+	// WasLeading reports whether the retracted bid held the lead.
+	WasLeading bool
+}
+
+// Retract withdraws bidID on l for userID if p allows it, recomputes the
+// current price and leader from the remaining bids and audits the change.
+func (p Policy) Retract(l *listing.Listing, bidID, userID, reason, explanation string, now time.Time) (*Outcome, error) {
+	bid, err := ledger.Get(bidID)
+	if err != nil {
+		return nil, err
+	}
+	if bid.ListingID != l.ID {
+		return nil, ledger.ErrNotFound
+	}
+	bid.BidAmount = bid.BidAmount.Round(l.Currency)
+
+	active, err := ledger.Active(l.ID, l.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bids: %w", err)
+	}
+
+	// The price this bid had to beat is the best earlier bid still standing
+	outbid := l.StartingBid
+	for _, b := range active {
+		if b.BidSequence < bid.BidSequence {
+			outbid = b.BidAmount
+			break
+		}
+	}
+
+	recent, err := Count(userID, now.Add(-p.Period))
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Check(l, bid, userID, reason, outbid, recent, now); err != nil {
+		return nil, err
+	}
+
+	ok, err := ledger.MarkRetracted(bid.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ineligible("bid is already retracted")
+	}
+
+	rec := Record{
+		BidID:         bid.ID,
+		ListingID:     l.ID,
+		UserID:        userID,
+		Reason:        reason,
+		Explanation:   explanation,
+		Amount:        bid.BidAmount,
+		PreviousPrice: l.StartingBid,
+		NewPrice:      l.StartingBid,
+		CreatedAt:     now,
+	}
+	out := &Outcome{}
+	if len(active) > 0 {
+		rec.PreviousPrice = active[0].BidAmount
+		out.WasLeading = active[0].ID == bid.ID
+	}
+	for _, b := range active {
+		if b.ID != bid.ID {
+			rec.NewPrice = b.BidAmount
+			rec.NewLeaderID = b.UserID
+			break
+		}
+	}
+
+	var created []Record
+	if err := supabase.Insert(table, []Record{rec}, &created); err != nil {
+		return nil, fmt.Errorf("failed to audit retraction: %w", err)
+	}
+	if len(created) > 0 {
+		rec.ID = created[0].ID
+	}
+	out.Record = rec
+
+	if err := updateProfileCount(userID); err != nil {
+		log.Printf("Warning: failed to update retraction count for %s: %v", userID, err)
+	}
+	return out, nil
+}
+
+// Count returns how many bids userID has retracted since the given time.
+func Count(userID string, since time.Time) (int, error) {
+	var rows []struct {
+		ID string `json:"id"`
+	}
+	query := "user_id=eq." + url.QueryEscape(userID) + "&select=id" +
+		"&created_at=gte." + url.QueryEscape(since.Format(time.RFC3339))
+	if err := supabase.Select(table, query, &rows); err != nil {
+		return 0, fmt.Errorf("failed to count retractions: %w", err)
+	}
+	return len(rows), nil
+}
+
+// updateProfileCount stores the user's lifetime retraction count on their
+// profile, where trust scoring reads it.
+func updateProfileCount(userID string) error {
+	total, err := Count(userID, time.Time{})
+	if err != nil {
+		return err
+	}
+	patch := map[string]int{"bid_retraction_count": total}
+	return supabase.Update("profiles", "id=eq."+url.QueryEscape(userID), patch, nil)
+}
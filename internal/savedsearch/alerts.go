@@ -0,0 +1,208 @@
+package savedsearch
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/events"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Matches live in the Supabase `saved_search_matches` table. Daily searches
+// leave them un-notified until the next digest.
+const matchesTable = "saved_search_matches"
+
+// GoLiveWindow is how far back Run looks for scheduled listings that have
+// started without being matched yet.
+const GoLiveWindow = 24 * time.Hour
+
+// DigestInterval is the minimum time between two digests for one search.
+const DigestInterval = 24 * time.Hour
+
+// digestTitles is how many listing titles a digest names.
+const digestTitles = 5
+
+// Match records that a listing matched a saved search.
+type Match struct {
+	SearchID   string     `json:"search_id"`
+	UserID     string     `json:"user_id"`
+	ListingID  string     `json:"listing_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	NotifiedAt *time.Time `json:"notified_at"`
+}
+
+// Notify matches a listing that has just gone live against every saved
+// search. Instant searches are alerted right away; daily ones wait for the
+// digest. It returns the number of matches.
+func Notify(ctx context.Context, l *listing.Listing) (int, error) {
+	var searches []Search
+	if err := supabase.Select(table, "user_id=neq."+url.QueryEscape(l.SellerID), &searches); err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	var matches []Match
+	for _, s := range searches {
+		if !s.Criteria.Matches(ctx, l) {
+			continue
+		}
+		m := Match{SearchID: s.ID, UserID: s.UserID, ListingID: l.ID, CreatedAt: now}
+		if s.Frequency != Daily {
+			m.NotifiedAt = &now
+			events.Publish(events.Event{
+				Type:      events.SavedSearchMatch,
+				UserID:    s.UserID,
+				ListingID: l.ID,
+				Data: map[string]string{
+					"title":  l.Title,
+					"search": s.Name,
+					"price":  l.StartingBid.String(),
+				},
+			})
+		}
+		matches = append(matches, m)
+	}
+
+	if len(matches) > 0 {
+		if err := supabase.Insert(matchesTable, matches, nil); err != nil {
+			return len(matches), err
+		}
+	}
+	return len(matches), nil
+}
+
+// Run matches scheduled listings as they go live and sends daily digests
+// every interval until ctx is cancelled.
+func Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now().UTC()
+			if n, err := GoLive(ctx, now); err != nil {
+				log.Printf("Warning: saved search matching failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Matched %d newly live listings against saved searches", n)
+			}
+			if n, err := SendDigests(now); err != nil {
+				log.Printf("Warning: saved search digests failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Sent %d saved search digests", n)
+			}
+		}
+	}
+}
+
+// GoLive matches scheduled listings whose auction started within
+// GoLiveWindow of now and that haven't been matched yet.
+func GoLive(ctx context.Context, now time.Time) (int, error) {
+	var listings []listing.Listing
+	query := "search_alerted_at=is.null" +
+		"&auction_start_time=gt." + url.QueryEscape(now.Add(-GoLiveWindow).Format(time.RFC3339)) +
+		"&auction_start_time=lte." + url.QueryEscape(now.Format(time.RFC3339))
+	if err := supabase.Select("listings", query, &listings); err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for i := range listings {
+		l := &listings[i]
+		// Mark first so an overlapping run can't alert twice
+		var claimed []listing.Listing
+		patch := map[string]interface{}{"search_alerted_at": now}
+		if err := supabase.Update("listings", "id=eq."+url.QueryEscape(l.ID)+"&search_alerted_at=is.null", patch, &claimed); err != nil {
+			log.Printf("Warning: failed to mark listing %s as matched: %v", l.ID, err)
+			continue
+		}
+		if len(claimed) == 0 {
+			continue
+		}
+		if _, err := Notify(ctx, l); err != nil {
+			log.Printf("Warning: failed to match listing %s: %v", l.ID, err)
+			// Unmark it so the next run tries again
+			unmark := map[string]interface{}{"search_alerted_at": nil}
+			if err := supabase.Update("listings", "id=eq."+url.QueryEscape(l.ID), unmark, nil); err != nil {
+				log.Printf("Warning: listing %s won't be matched again: %v", l.ID, err)
+			}
+			continue
+		}
+		done++
+	}
+	return done, nil
+}
+
+// SendDigests sends one digest per daily search with un-notified matches,
+// at most once per DigestInterval.
+func SendDigests(now time.Time) (int, error) {
+	var searches []Search
+	cutoff := url.QueryEscape(now.Add(-DigestInterval).Format(time.RFC3339))
+	query := "frequency=eq." + string(Daily) + "&or=(last_notified_at.is.null,last_notified_at.lt." + cutoff + ")"
+	if err := supabase.Select(table, query, &searches); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, s := range searches {
+		var pending []Match
+		filter := "search_id=eq." + url.QueryEscape(s.ID) + "&notified_at=is.null"
+		if err := supabase.Select(matchesTable, filter+"&order=created_at.desc", &pending); err != nil {
+			log.Printf("Warning: failed to load matches for search %s: %v", s.ID, err)
+			continue
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		if err := supabase.Update(matchesTable, filter, map[string]interface{}{"notified_at": now}, nil); err != nil {
+			log.Printf("Warning: failed to mark matches for search %s: %v", s.ID, err)
+			continue
+		}
+		if err := supabase.Update(table, "id=eq."+url.QueryEscape(s.ID), map[string]interface{}{"last_notified_at": now}, nil); err != nil {
+			log.Printf("Warning: failed to mark search %s as notified: %v", s.ID, err)
+		}
+
+		events.Publish(events.Event{
+			Type:   events.SavedSearchDigest,
+			UserID: s.UserID,
+			Data: map[string]string{
+				"search": s.Name,
+				"count":  strconv.Itoa(len(pending)),
+				"titles": digestList(pending),
+			},
+		})
+		sent++
+	}
+	return sent, nil
+}
+
+// digestList names the first few matched listings, one per line.
+func digestList(matches []Match) string {
+	ids := make([]string, 0, digestTitles)
+	for i := 0; i < len(matches) && i < digestTitles; i++ {
+		ids = append(ids, url.QueryEscape(matches[i].ListingID))
+	}
+	var listings []struct {
+		Title string `json:"title"`
+	}
+	if err := supabase.Select("listings", "select=title&id=in.("+strings.Join(ids, ",")+")", &listings); err != nil {
+		return ""
+	}
+
+	lines := make([]string, 0, len(listings)+1)
+	for _, l := range listings {
+		lines = append(lines, "- "+l.Title)
+	}
+	if more := len(matches) - len(listings); more > 0 {
+		lines = append(lines, "...and "+strconv.Itoa(more)+" more")
+	}
+	return strings.Join(lines, "\n")
+}
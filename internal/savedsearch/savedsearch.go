@@ -0,0 +1,252 @@
+package savedsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/categories"
+	"github.com/quickswap/quickswap/internal/fx"
+	"github.com/quickswap/quickswap/internal/geo"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Saved searches live in the Supabase `saved_searches` table.
+const table = "saved_searches"
+
+// MaxPerUser caps how many searches one user can save.
+const MaxPerUser = 25
+
+// Frequency controls how alerts for a search are delivered.
+type Frequency string
+
+const (
+	// Instant sends one alert per matching listing as soon as it goes live.
+	Instant Frequency = "instant"
+	// Daily collects matches into one digest a day.
+	Daily Frequency = "daily"
+)
+
+// Criteria are the query and filters of a search. Empty fields match
+// everything.
+type Criteria struct {
+	// Query terms must all appear in the listing's title, subtitle,
+	// description or brand.
+	Query       string         `json:"query,omitempty"`
+	Category    string         `json:"category,omitempty"`
+	Subcategory string         `json:"subcategory,omitempty"`
+	Condition   string         `json:"condition,omitempty"`
+	MaxPrice    *money.Money   `json:"max_price,omitempty"`
+	Currency    money.Currency `json:"currency,omitempty"`
+	Near        *geo.Point     `json:"near,omitempty"`
+	RadiusKm    float64        `json:"radius_km,omitempty"`
+}
+
+// Search is a user's saved search.
+type Search struct {
+	ID             string     `json:"id,omitempty"`
+	UserID         string     `json:"user_id"`
+	Name           string     `json:"name"`
+	Criteria       Criteria   `json:"criteria"`
+	Frequency      Frequency  `json:"frequency"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastNotifiedAt *time.Time `json:"last_notified_at,omitempty"`
+}
+
+var (
+	// ErrInvalid is wrapped by errors describing a bad search.
+	ErrInvalid = errors.New("invalid saved search")
+	// ErrTooMany is returned when a user already has MaxPerUser searches.
+	ErrTooMany = fmt.Errorf("at most %d saved searches are allowed", MaxPerUser)
+	// ErrNotFound is returned when deleting a search the user doesn't own.
+	ErrNotFound = errors.New("saved search not found")
+)
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalid, fmt.Sprintf(format, args...))
+}
+
+// normalize validates s and puts its criteria in canonical form.
+func normalize(s *Search) error {
+	c := &s.Criteria
+	c.Query = strings.TrimSpace(c.Query)
+
+	if c.Category != "" {
+		tax := categories.Current()
+		cat, ok := tax.Find("", c.Category)
+		if !ok {
+			return invalid("unknown category %q", c.Category)
+		}
+		c.Category = cat.ID
+		if c.Subcategory != "" {
+			sub, ok := tax.Find(cat.ID, c.Subcategory)
+			if !ok {
+				return invalid("unknown subcategory %q", c.Subcategory)
+			}
+			c.Subcategory = sub.ID
+		}
+	} else if c.Subcategory != "" {
+		return invalid("subcategory requires a category")
+	}
+
+	if c.Condition != "" {
+		known := false
+		for _, cond := range categories.Conditions {
+			if strings.EqualFold(cond, c.Condition) {
+				c.Condition, known = cond, true
+			}
+		}
+		if !known {
+			return invalid("condition must be one of %s", strings.Join(categories.Conditions, ", "))
+		}
+	}
+
+	if c.MaxPrice != nil {
+		if c.Currency == "" {
+			c.Currency = money.DefaultCurrency
+		}
+		cur, err := money.ParseCurrency(string(c.Currency))
+		if err != nil {
+			return invalid("%v", err)
+		}
+		p, err := c.MaxPrice.In(cur)
+		if err != nil || !p.IsPositive() {
+			return invalid("max_price must be a positive %s amount", cur)
+		}
+		c.Currency, c.MaxPrice = cur, &p
+	}
+
+	if c.Near != nil {
+		if !c.Near.Valid() {
+			return invalid("near is out of range")
+		}
+		if c.RadiusKm <= 0 {
+			c.RadiusKm = 25
+		}
+	}
+
+	if c.Query == "" && c.Category == "" && c.MaxPrice == nil && c.Near == nil && c.Condition == "" {
+		return invalid("a query or at least one filter is required")
+	}
+
+	switch s.Frequency {
+	case "":
+		s.Frequency = Instant
+	case Instant, Daily:
+	default:
+		return invalid("frequency must be %q or %q", Instant, Daily)
+	}
+	if s.Name == "" {
+		s.Name = c.Query
+		if s.Name == "" {
+			s.Name = "Saved search"
+		}
+	}
+	return nil
+}
+
+// Save stores a new search for s.UserID.
+func Save(s *Search) error {
+	if err := normalize(s); err != nil {
+		return err
+	}
+
+	existing, err := ForUser(s.UserID)
+	if err != nil {
+		return err
+	}
+	if len(existing) >= MaxPerUser {
+		return ErrTooMany
+	}
+
+	s.CreatedAt = time.Now().UTC()
+	var inserted []Search
+	if err := supabase.Insert(table, []Search{*s}, &inserted); err != nil {
+		return fmt.Errorf("failed to save search: %w", err)
+	}
+	if len(inserted) > 0 {
+		*s = inserted[0]
+	}
+	return nil
+}
+
+// ForUser returns userID's saved searches, newest first.
+func ForUser(userID string) ([]Search, error) {
+	var searches []Search
+	query := "user_id=eq." + url.QueryEscape(userID) + "&order=created_at.desc"
+	if err := supabase.Select(table, query, &searches); err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// Delete removes one of userID's saved searches.
+func Delete(userID, id string) error {
+	var rows []Search
+	filter := "id=eq." + url.QueryEscape(id) + "&user_id=eq." + url.QueryEscape(userID)
+	if err := supabase.Select(table, filter, &rows); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return ErrNotFound
+	}
+	return supabase.Delete(table, filter)
+}
+
+// isCategory reports whether a listing's stored category value refers to the
+// category with ID id, which older listings store by name.
+func isCategory(id, stored string) bool {
+	if cat, ok := categories.Current().Get(id); ok {
+		return cat.Is(stored)
+	}
+	return id == stored
+}
+
+// Matches reports whether l satisfies c.
+func (c Criteria) Matches(ctx context.Context, l *listing.Listing) bool {
+	if c.Category != "" && !isCategory(c.Category, l.Category) {
+		return false
+	}
+	if c.Subcategory != "" && !isCategory(c.Subcategory, l.Subcategory) {
+		return false
+	}
+	if c.Condition != "" && c.Condition != l.Condition {
+		return false
+	}
+
+	if c.Query != "" {
+		text := strings.ToLower(strings.Join([]string{l.Title, l.Subtitle, l.Description, l.Brand}, " "))
+		for _, term := range strings.Fields(strings.ToLower(c.Query)) {
+			if !strings.Contains(text, term) {
+				return false
+			}
+		}
+	}
+
+	if c.MaxPrice != nil {
+		price := l.StartingBid
+		if price.Currency != c.MaxPrice.Currency {
+			converted, ok := fx.Convert(ctx, price, c.MaxPrice.Currency)
+			if !ok {
+				return false
+			}
+			price = converted
+		}
+		if price.Cmp(*c.MaxPrice) > 0 {
+			return false
+		}
+	}
+
+	if c.Near != nil {
+		p, ok := l.Point()
+		if !ok || geo.DistanceKm(*c.Near, p) > c.RadiusKm {
+			return false
+		}
+	}
+	return true
+}
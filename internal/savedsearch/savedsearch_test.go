@@ -0,0 +1,129 @@
+package savedsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/geo"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+)
+
+func testListing() *listing.Listing {
+	lat, lng := 29.65, -82.33
+	return &listing.Listing{
+		ID:          "list1",
+		Title:       "iPhone 13 Pro",
+		Description: "Unlocked, great battery",
+		Category:    "electronics",
+		Subcategory: "mobile-phones",
+		Condition:   "like_new",
+		StartingBid: money.New(40000, "USD"),
+		Currency:    "USD",
+		Latitude:    &lat,
+		Longitude:   &lng,
+	}
+}
+
+func TestCriteriaMatches(t *testing.T) {
+	ctx := context.Background()
+	l := testListing()
+	maxPrice := func(amount int64, c money.Currency) *money.Money {
+		m := money.New(amount, c)
+		return &m
+	}
+
+	tests := []struct {
+		name string
+		c    Criteria
+		want bool
+	}{
+		{"all query terms", Criteria{Query: "iphone unlocked"}, true},
+		{"missing term", Criteria{Query: "iphone android"}, false},
+		{"category", Criteria{Category: "electronics", Subcategory: "mobile-phones"}, true},
+		{"other category", Criteria{Category: "books"}, false},
+		{"condition", Criteria{Condition: "new"}, false},
+		{"under max price", Criteria{MaxPrice: maxPrice(50000, "USD")}, true},
+		{"over max price", Criteria{MaxPrice: maxPrice(30000, "USD")}, false},
+		{"converted max price", Criteria{MaxPrice: maxPrice(50000, "EUR")}, true},
+		{"within radius", Criteria{Near: &geo.Point{Lat: 29.65, Lng: -82.32}, RadiusKm: 10}, true},
+		{"outside radius", Criteria{Near: &geo.Point{Lat: 25.77, Lng: -80.19}, RadiusKm: 10}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Matches(ctx, l); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	legacy := testListing()
+	legacy.Category, legacy.Subcategory = "Electronics", "Mobile phones"
+	if !(Criteria{Category: "electronics", Subcategory: "mobile-phones"}).Matches(ctx, legacy) {
+		t.Errorf("Expected a listing storing category names to match by ID")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	price := money.Money{Amount: 25000} // 25.000 as decoded from JSON
+	s := &Search{Criteria: Criteria{Category: "Electronics", Subcategory: "Mobile phones", Condition: "Like_New", MaxPrice: &price}}
+	if err := normalize(s); err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	c := s.Criteria
+	if c.Category != "electronics" || c.Subcategory != "mobile-phones" || c.Condition != "like_new" {
+		t.Errorf("Expected canonical filters, got %+v", c)
+	}
+	if !c.MaxPrice.Equal(money.New(2500, "USD")) || s.Frequency != Instant || s.Name == "" {
+		t.Errorf("Expected defaults to be filled in, got %+v", s)
+	}
+
+	for _, bad := range []*Search{
+		{},
+		{Criteria: Criteria{Category: "cars"}},
+		{Criteria: Criteria{Subcategory: "laptops"}},
+		{Criteria: Criteria{Query: "desk"}, Frequency: "hourly"},
+	} {
+		if err := normalize(bad); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected %+v to be invalid, got %v", bad, err)
+		}
+	}
+}
+
+func TestGoLiveUnmarksListingWhenMatchingFails(t *testing.T) {
+	var patches []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/rest/v1/listings" && r.Method == http.MethodGet:
+			w.Write([]byte(`[{"id": "list1", "seller_id": "seller1"}]`))
+		case r.URL.Path == "/rest/v1/listings" && r.Method == http.MethodPatch:
+			body, _ := io.ReadAll(r.Body)
+			var patch map[string]interface{}
+			json.Unmarshal(body, &patch)
+			patches = append(patches, patch)
+			if r.Header.Get("Prefer") == "return=representation" {
+				w.Write([]byte(`[{"id": "list1"}]`))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			// Saved searches can't be loaded, so matching fails
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+	t.Setenv("SUPABASE_URL", ts.URL)
+	t.Setenv("SUPABASE_ANON_KEY", "anon")
+
+	n, err := GoLive(context.Background(), time.Now().UTC())
+	if err != nil || n != 0 {
+		t.Fatalf("Expected no listings matched, got %d, %v", n, err)
+	}
+	if len(patches) != 2 || patches[1]["search_alerted_at"] != nil || len(patches[1]) != 1 {
+		t.Errorf("Expected the listing to be marked then unmarked, got %v", patches)
+	}
+}
@@ -0,0 +1,345 @@
+// Package secondchance lets sellers offer an item to the runner-up bidder
+// at their highest bid when the winner doesn't complete the purchase.
+package secondchance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/events"
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/payments"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+const table = "second_chance_offers"
+
+// Listing statuses set while a second-chance offer is out.
+const (
+	ListingOffered = "second_chance"
+	listingSold    = "sold"
+	listingUnsold  = "unsold"
+)
+
+// Limits on second-chance offers.
+const (
+	// Window is how long after the auction ends offers can be sent.
+	Window = 30 * 24 * time.Hour
+	// DefaultExpiry is how long the runner-up has to accept.
+	DefaultExpiry = 48 * time.Hour
+	// MaxExpiry caps the acceptance window a seller can choose.
+	MaxExpiry = 7 * 24 * time.Hour
+)
+
+// Status of a second-chance offer.
+type Status string
+
+const (
+	Pending  Status = "pending"
+	Accepted Status = "accepted"
+	Declined Status = "declined"
+	Expired  Status = "expired"
+)
+
+var (
+	ErrNotFound   = errors.New("second-chance offer not found")
+	ErrIneligible = errors.New("listing can't get a second-chance offer")
+	ErrNoRunnerUp = errors.New("no runner-up bidder left to offer the item to")
+	ErrNotOpen    = errors.New("second-chance offer is no longer open")
+)
+
+// Offer is a second-chance offer to a runner-up bidder.
+type Offer struct {
+	ID          string         `json:"id,omitempty"`
+	ListingID   string         `json:"listing_id"`
+	SellerID    string         `json:"seller_id"`
+	BidderID    string         `json:"bidder_id"`
+	Amount      money.Money    `json:"amount"`
+	Currency    money.Currency `json:"currency"`
+	Status      Status         `json:"status"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	RespondedAt *time.Time     `json:"responded_at,omitempty"`
+}
+
+// RunnerUp picks the highest bidder on a listing who isn't excluded, at
+// their highest bid. bids must be highest first, as ledger.Active returns.
+func RunnerUp(bids []ledger.Bid, exclude map[string]bool) (ledger.Bid, bool) {
+	for _, b := range bids {
+		if !exclude[b.UserID] {
+			return b, true
+		}
+	}
+	return ledger.Bid{}, false
+}
+
+// Send offers l to its next runner-up once the winner has failed to pay. The
+// winner and bidders who already had a second-chance offer are skipped, and
+// only one offer may be open at once.
+func Send(l *listing.Listing, sellerID string, expiry time.Duration, now time.Time) (*Offer, error) {
+	switch {
+	case l.SellerID != sellerID:
+		return nil, fmt.Errorf("%w: only the seller can send one", ErrIneligible)
+	case l.SettledAt == nil || l.WinnerID == nil:
+		return nil, fmt.Errorf("%w: the auction hasn't closed with a winner", ErrIneligible)
+	case l.Units() > 1:
+		return nil, fmt.Errorf("%w: multi-quantity listings aren't supported", ErrIneligible)
+	case now.Sub(l.AuctionEndTime) > Window:
+		return nil, fmt.Errorf("%w: the auction ended more than %d days ago", ErrIneligible, int(Window.Hours()/24))
+	case l.Status == ListingOffered:
+		return nil, fmt.Errorf("%w: a second-chance offer is already open", ErrIneligible)
+	case l.Status != listingSold && l.Status != listingUnsold:
+		return nil, fmt.Errorf("%w: the listing is %s", ErrIneligible, l.Status)
+	}
+	if expiry <= 0 {
+		expiry = DefaultExpiry
+	}
+	if expiry > MaxExpiry {
+		expiry = MaxExpiry
+	}
+
+	orders, err := payments.ForListing(l.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		if o.Status != payments.Unpaid {
+			return nil, fmt.Errorf("%w: the buyer's order is %s", ErrIneligible, o.Status)
+		}
+	}
+	previous, err := ForListing(l.ID)
+	if err != nil {
+		return nil, err
+	}
+	exclude := map[string]bool{*l.WinnerID: true}
+	for _, o := range previous {
+		if o.Status == Accepted {
+			return nil, fmt.Errorf("%w: a runner-up already bought it", ErrIneligible)
+		}
+		exclude[o.BidderID] = true
+	}
+	bids, err := ledger.Active(l.ID, l.Currency)
+	if err != nil {
+		return nil, err
+	}
+	runnerUp, ok := RunnerUp(bids, exclude)
+	if !ok {
+		return nil, ErrNoRunnerUp
+	}
+
+	// Claim the listing first so two sends can't both open an offer
+	claimed, err := setListingStatus(l.ID, l.Status, ListingOffered, nil)
+	if err != nil {
+		return nil, err
+	}
+	if claimed == nil {
+		return nil, fmt.Errorf("%w: the listing changed, try again", ErrIneligible)
+	}
+
+	o := Offer{
+		ListingID: l.ID,
+		SellerID:  l.SellerID,
+		BidderID:  runnerUp.UserID,
+		Amount:    runnerUp.BidAmount,
+		Currency:  l.Currency,
+		Status:    Pending,
+		ExpiresAt: now.Add(expiry),
+		CreatedAt: now,
+	}
+	var created []Offer
+	if err := supabase.Insert(table, []Offer{o}, &created); err != nil {
+		if _, rerr := setListingStatus(l.ID, ListingOffered, l.Status, nil); rerr != nil {
+			log.Printf("Warning: failed to release %s after a failed offer: %v", l.ID, rerr)
+		}
+		return nil, err
+	}
+	if len(created) > 0 {
+		o = created[0]
+		o.round()
+	}
+
+	events.Publish(events.Event{
+		Type:      events.SecondChanceOffer,
+		UserID:    o.BidderID,
+		ListingID: l.ID,
+		Data: map[string]string{
+			"title":      l.Title,
+			"amount":     o.Amount.String(),
+			"expires_at": o.ExpiresAt.Format(time.RFC1123),
+		},
+	})
+	return &o, nil
+}
+
+// Respond records the runner-up's answer. Accepting makes them the winner at
+// the offer amount and opens an order for them to pay; declining puts the
+// listing back to unsold so the seller can try the next runner-up.
+func Respond(o *Offer, bidderID string, accept bool, now time.Time) error {
+	if o.BidderID != bidderID {
+		return ErrNotFound
+	}
+	if o.Status != Pending || !now.Before(o.ExpiresAt) {
+		return ErrNotOpen
+	}
+	status := Declined
+	if accept {
+		status = Accepted
+	}
+
+	var updated []Offer
+	query := "id=eq." + url.QueryEscape(o.ID) + "&status=eq." + string(Pending)
+	patch := map[string]interface{}{"status": status, "responded_at": now}
+	if err := supabase.Update(table, query, patch, &updated); err != nil {
+		return err
+	}
+	if len(updated) == 0 {
+		return ErrNotOpen
+	}
+	*o = updated[0]
+	o.round()
+
+	var (
+		l   *listing.Listing
+		err error
+	)
+	if accept {
+		l, err = setListingStatus(o.ListingID, ListingOffered, listingSold, map[string]interface{}{
+			"winner_id":   o.BidderID,
+			"final_price": o.Amount,
+		})
+	} else {
+		_, err = setListingStatus(o.ListingID, ListingOffered, listingUnsold, nil)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to update listing %s after second-chance answer: %v", o.ListingID, err)
+	}
+	if accept {
+		if l == nil {
+			l, err = listing.Get(o.ListingID)
+		}
+		title := ""
+		if err == nil && l != nil {
+			title = l.Title
+		}
+		if _, err := payments.Create(o.ListingID, title, o.BidderID, o.SellerID, o.Amount, 1, now); err != nil {
+			log.Printf("Warning: failed to open order for %s: %v", o.ListingID, err)
+		}
+	}
+
+	t := events.SecondChanceDeclined
+	if accept {
+		t = events.SecondChanceAccepted
+	}
+	events.Publish(events.Event{
+		Type:      t,
+		UserID:    o.SellerID,
+		ListingID: o.ListingID,
+		Data:      map[string]string{"amount": o.Amount.String()},
+	})
+	return nil
+}
+
+// setListingStatus moves a listing to status, if it is currently in from
+// ("" for any status), along with any extra fields. It returns the updated
+// listing, or nil if the listing wasn't in from.
+func setListingStatus(listingID, from, status string, extra map[string]interface{}) (*listing.Listing, error) {
+	patch := map[string]interface{}{"status": status}
+	for k, v := range extra {
+		patch[k] = v
+	}
+	query := "id=eq." + url.QueryEscape(listingID)
+	if from != "" {
+		query += "&status=eq." + url.QueryEscape(from)
+	}
+	var updated []listing.Listing
+	if err := supabase.Update("listings", query, patch, &updated); err != nil {
+		return nil, err
+	}
+	if len(updated) == 0 {
+		return nil, nil
+	}
+	return &updated[0], nil
+}
+
+// Get returns the second-chance offer with the given ID.
+func Get(id string) (*Offer, error) {
+	offers, err := list("id=eq." + url.QueryEscape(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(offers) == 0 {
+		return nil, ErrNotFound
+	}
+	return &offers[0], nil
+}
+
+// ForListing returns every second-chance offer sent on a listing.
+func ForListing(listingID string) ([]Offer, error) {
+	return list("listing_id=eq." + url.QueryEscape(listingID))
+}
+
+// ForUser returns the second-chance offers userID has sent or received.
+func ForUser(userID string) ([]Offer, error) {
+	id := url.QueryEscape(userID)
+	return list("or=(seller_id.eq." + id + ",bidder_id.eq." + id + ")")
+}
+
+func list(filter string) ([]Offer, error) {
+	var rows []Offer
+	if err := supabase.Select(table, filter+"&order=created_at.desc", &rows); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].round()
+	}
+	return rows, nil
+}
+
+// round puts amounts read back from the table in the offer's currency.
+func (o *Offer) round() {
+	if o.Currency == "" {
+		o.Currency = money.DefaultCurrency
+	}
+	o.Amount = o.Amount.Round(o.Currency)
+}
+
+// Run calls ExpireDue every interval until ctx is cancelled.
+func Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := ExpireDue(time.Now().UTC()); err != nil {
+				log.Printf("Warning: second-chance expiry failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Expired %d second-chance offers", n)
+			}
+		}
+	}
+}
+
+// ExpireDue expires pending offers past their deadline and puts their
+// listings back to unsold.
+func ExpireDue(now time.Time) (int, error) {
+	var expired []Offer
+	query := "status=eq." + string(Pending) + "&expires_at=lte." + url.QueryEscape(now.Format(time.RFC3339))
+	if err := supabase.Update(table, query, map[string]interface{}{"status": Expired}, &expired); err != nil {
+		return 0, err
+	}
+	for _, o := range expired {
+		if _, err := setListingStatus(o.ListingID, ListingOffered, listingUnsold, nil); err != nil {
+			log.Printf("Warning: failed to reopen listing %s: %v", o.ListingID, err)
+		}
+	}
+	return len(expired), nil
+}
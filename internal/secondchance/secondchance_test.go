@@ -0,0 +1,79 @@
+package secondchance
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+)
+
+func TestRunnerUp(t *testing.T) {
+	bids := []ledger.Bid{
+		{UserID: "winner", BidSequence: 4},
+		{UserID: "second", BidSequence: 3},
+		{UserID: "third", BidSequence: 2},
+		{UserID: "second", BidSequence: 1},
+	}
+
+	b, ok := RunnerUp(bids, map[string]bool{"winner": true})
+	if !ok || b.UserID != "second" || b.BidSequence != 3 {
+		t.Errorf("Expected second's highest bid, got %+v (%v)", b, ok)
+	}
+	b, ok = RunnerUp(bids, map[string]bool{"winner": true, "second": true})
+	if !ok || b.UserID != "third" {
+		t.Errorf("Expected third once second was offered, got %+v (%v)", b, ok)
+	}
+	if _, ok := RunnerUp(bids, map[string]bool{"winner": true, "second": true, "third": true}); ok {
+		t.Error("Expected no runner-up once every bidder was offered")
+	}
+}
+
+func TestSendEligibility(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	winner := "winner"
+	settled := now.Add(-time.Hour)
+	closed := listing.Listing{ID: "l1", SellerID: "seller", WinnerID: &winner, SettledAt: &settled, AuctionEndTime: settled}
+
+	open := closed
+	open.SettledAt, open.WinnerID = nil, nil
+	stale := closed
+	stale.AuctionEndTime = now.Add(-Window - time.Hour)
+	offered := closed
+	offered.Status = ListingOffered
+	multi := closed
+	multi.Quantity = 3
+
+	for _, tc := range []struct {
+		name   string
+		l      listing.Listing
+		seller string
+	}{
+		{"not the seller", closed, "someone"},
+		{"still open", open, "seller"},
+		{"window passed", stale, "seller"},
+		{"offer already out", offered, "seller"},
+		{"multi-quantity", multi, "seller"},
+	} {
+		if _, err := Send(&tc.l, tc.seller, 0, now); !errors.Is(err, ErrIneligible) {
+			t.Errorf("%s: expected ErrIneligible, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestRespondChecksBidder(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	o := Offer{ID: "sc1", BidderID: "second", Status: Pending, ExpiresAt: now.Add(time.Hour)}
+
+	if err := Respond(&o, "someone", true, now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another user, got %v", err)
+	}
+	if err := Respond(&o, "second", true, now.Add(2*time.Hour)); !errors.Is(err, ErrNotOpen) {
+		t.Errorf("Expected ErrNotOpen after expiry, got %v", err)
+	}
+	o.Status = Declined
+	if err := Respond(&o, "second", true, now); !errors.Is(err, ErrNotOpen) {
+		t.Errorf("Expected ErrNotOpen once answered, got %v", err)
+	}
+}
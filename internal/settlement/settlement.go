@@ -0,0 +1,215 @@
+package settlement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/events"
+	"github.com/quickswap/quickswap/internal/ledger"
+	listing "github.com/quickswap/quickswap/internal/listings"
+	"github.com/quickswap/quickswap/internal/money"
+	"github.com/quickswap/quickswap/internal/payments"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Result is the outcome of closing a single auction.
+type Result struct {
+	ListingID  string      `json:"listing_id"`
+	SellerID   string      `json:"seller_id"`
+	Title      string      `json:"title"`
+	WinnerID   string      `json:"winner_id,omitempty"`
+	FinalPrice money.Money `json:"final_price"`
+	// Awards lists every winner of a multi-quantity listing
+	Awards []listing.Award `json:"awards,omitempty"`
+}
+
+// Multi-quantity winners are recorded in the auction_awards table.
+const awardsTable = "auction_awards"
+
+// errSettled means another run settled the listing first.
+var errSettled = errors.New("listing already settled")
+
+type awardRow struct {
+	ListingID string `json:"listing_id"`
+	listing.Award
+}
+
+// Run calls CloseEndedAuctions every interval until ctx is cancelled.
+func Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if results, err := CloseEndedAuctions(time.Now().UTC()); err != nil {
+				log.Printf("Warning: auction settlement failed: %v", err)
+			} else if len(results) > 0 {
+				log.Printf("Settled %d auctions", len(results))
+			}
+		}
+	}
+}
+
+// CloseEndedAuctions settles every listing whose auction ended before now and
+// has not been settled yet. The highest bid wins, paying what the auction
+// type's pricing rule says; the listing is marked settled, an order is opened
+// for the winner to pay, and the winner and seller are notified.
+func CloseEndedAuctions(now time.Time) ([]Result, error) {
+	var ended []listing.Listing
+	query := "select=id,title,seller_id,starting_bid,currency,auction_type,quantity,pricing,auction_end_time" +
+		"&auction_end_time=lte." + url.QueryEscape(now.Format(time.RFC3339)) +
+		"&settled_at=is.null"
+	if err := supabase.Select("listings", query, &ended); err != nil {
+		return nil, fmt.Errorf("fetch ended auctions: %w", err)
+	}
+
+	var results []Result
+	for _, l := range ended {
+		if l.Units() > 1 {
+			res, err := closeMultiUnit(&l, now)
+			if errors.Is(err, errSettled) {
+				continue
+			} else if err != nil {
+				log.Printf("Warning: failed to settle listing %s: %v", l.ID, err)
+				continue
+			}
+			results = append(results, *res)
+			continue
+		}
+
+		var bids []struct {
+			UserID    string      `json:"user_id"`
+			BidAmount money.Money `json:"bid_amount"`
+		}
+		// Second-price auctions need the runner-up; ties go to the earlier bid
+		bidsQuery := "listing_id=eq." + url.QueryEscape(l.ID) + "&" + ledger.Standing +
+			"&order=bid_amount.desc,bid_sequence.asc&limit=2"
+		if err := supabase.Select("bids", bidsQuery, &bids); err != nil {
+			log.Printf("Warning: failed to fetch bids for %s: %v", l.ID, err)
+			continue
+		}
+
+		res := Result{ListingID: l.ID, SellerID: l.SellerID, Title: l.Title, FinalPrice: l.StartingBid}
+		status := "unsold"
+		if len(bids) > 0 {
+			amounts := make([]money.Money, len(bids))
+			for i, b := range bids {
+				amounts[i] = b.BidAmount.Round(l.Currency)
+			}
+			res.WinnerID = bids[0].UserID
+			res.FinalPrice = l.AuctionType.ClearingPrice(l.StartingBid, amounts)
+			status = "sold"
+		}
+
+		if ok, err := claim(&l, status, res, now); err != nil {
+			log.Printf("Warning: failed to settle listing %s: %v", l.ID, err)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		if res.WinnerID != "" {
+			if _, err := payments.Create(l.ID, l.Title, res.WinnerID, l.SellerID, res.FinalPrice, 1, now); err != nil {
+				log.Printf("Warning: failed to open order for %s: %v", l.ID, err)
+			}
+			data := map[string]string{"title": l.Title, "amount": res.FinalPrice.String()}
+			events.Publish(events.Event{Type: events.AuctionWon, UserID: res.WinnerID, ListingID: l.ID, Data: data})
+			events.Publish(events.Event{Type: events.ItemSold, UserID: l.SellerID, ListingID: l.ID, Data: data})
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// closeMultiUnit settles a multi-quantity listing: the top bids win units
+// and pay per the listing's pricing rule. FinalPrice is the total paid.
+func closeMultiUnit(l *listing.Listing, now time.Time) (*Result, error) {
+	active, err := ledger.Active(l.ID, l.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("fetch bids: %w", err)
+	}
+	bids := make([]listing.UnitBid, len(active))
+	for i, b := range active {
+		bids[i] = listing.UnitBid{UserID: b.UserID, Amount: b.BidAmount, Quantity: b.Quantity, Sequence: b.BidSequence}
+	}
+	awards := listing.Allocate(l.Units(), l.Pricing, bids)
+
+	res := &Result{ListingID: l.ID, SellerID: l.SellerID, Title: l.Title, FinalPrice: money.Money{Currency: l.Currency}, Awards: awards}
+	status := "unsold"
+	rows := make([]awardRow, len(awards))
+	for i, a := range awards {
+		res.FinalPrice = res.FinalPrice.Add(a.UnitPrice.Mul(int64(a.Quantity)))
+		rows[i] = awardRow{ListingID: l.ID, Award: a}
+	}
+	if len(awards) > 0 {
+		res.WinnerID = awards[0].UserID
+		status = "sold"
+	}
+
+	if ok, err := claim(l, status, *res, now); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errSettled
+	}
+	if len(rows) > 0 {
+		if err := supabase.Insert(awardsTable, rows, nil); err != nil {
+			return nil, fmt.Errorf("record awards: %w", err)
+		}
+	}
+
+	sold := 0
+	for _, a := range awards {
+		sold += a.Quantity
+		total := a.UnitPrice.Mul(int64(a.Quantity))
+		if _, err := payments.Create(l.ID, l.Title, a.UserID, l.SellerID, total, a.Quantity, now); err != nil {
+			log.Printf("Warning: failed to open order for %s: %v", l.ID, err)
+		}
+		events.Publish(events.Event{Type: events.AuctionWon, UserID: a.UserID, ListingID: l.ID, Data: map[string]string{
+			"title":    l.Title,
+			"amount":   total.String(),
+			"quantity": fmt.Sprint(a.Quantity),
+		}})
+	}
+	if sold > 0 {
+		events.Publish(events.Event{Type: events.ItemSold, UserID: l.SellerID, ListingID: l.ID, Data: map[string]string{
+			"title":    fmt.Sprintf("%s (%d of %d)", l.Title, sold, l.Units()),
+			"amount":   res.FinalPrice.String(),
+			"quantity": fmt.Sprint(sold),
+		}})
+	}
+	return res, nil
+}
+
+// claim marks l settled with res if no other run has, reporting whether this
+// run won. Orders, awards and events only follow a successful claim, so an
+// overlapping or retried run can't create them twice.
+func claim(l *listing.Listing, status string, res Result, now time.Time) (bool, error) {
+	patch := map[string]interface{}{
+		"status":      status,
+		"winner_id":   nullable(res.WinnerID),
+		"final_price": res.FinalPrice,
+		"settled_at":  now,
+	}
+	var claimed []struct {
+		ID string `json:"id"`
+	}
+	if err := supabase.Update("listings", "id=eq."+url.QueryEscape(l.ID)+"&settled_at=is.null", patch, &claimed); err != nil {
+		return false, err
+	}
+	return len(claimed) > 0, nil
+}
+
+// nullable maps an empty ID to a JSON null.
+func nullable(id string) interface{} {
+	if id == "" {
+		return nil
+	}
+	return id
+}
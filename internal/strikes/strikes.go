@@ -0,0 +1,172 @@
+// Package strikes records unpaid-item strikes against buyers who miss their
+// payment deadline and restricts bidding for accounts with too many.
+package strikes
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/events"
+	"github.com/quickswap/quickswap/internal/payments"
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Strikes and their clearing are kept in the bidder_strikes table, which is
+// the audit history.
+const table = "bidder_strikes"
+
+// ReasonNonPayment is the strike reason for a missed payment deadline.
+const ReasonNonPayment = "non_payment"
+
+// Threshold is how many active strikes an account can hold before it is
+// restricted from bidding.
+const Threshold = 2
+
+var (
+	ErrNotFound = errors.New("strike not found")
+	ErrCleared  = errors.New("strike is already cleared")
+	// ErrRestricted is returned by Check for accounts that can't bid.
+	ErrRestricted = errors.New("bidding is restricted after unpaid items")
+)
+
+// Strike is one unpaid-item strike. A strike stays active until an admin
+// clears it.
+type Strike struct {
+	ID        string     `json:"id,omitempty"`
+	UserID    string     `json:"user_id"`
+	ListingID string     `json:"listing_id"`
+	OrderID   string     `json:"order_id"`
+	Reason    string     `json:"reason"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClearedAt *time.Time `json:"cleared_at,omitempty"`
+	ClearedBy string     `json:"cleared_by,omitempty"`
+	ClearNote string     `json:"clear_note,omitempty"`
+}
+
+// Active reports whether s still counts against its user.
+func (s *Strike) Active() bool { return s.ClearedAt == nil }
+
+// HandleOverdue strikes the buyer of an order that missed its payment
+// deadline. It is set as payments.OnOverdue.
+func HandleOverdue(o payments.Order) {
+	if _, err := Issue(o, time.Now().UTC()); err != nil {
+		log.Printf("Warning: failed to record strike for order %s: %v", o.ID, err)
+	}
+}
+
+// Issue records a non-payment strike for o's buyer and tells them if it
+// restricts their bidding.
+func Issue(o payments.Order, now time.Time) (*Strike, error) {
+	s := Strike{
+		UserID:    o.BuyerID,
+		ListingID: o.ListingID,
+		OrderID:   o.ID,
+		Reason:    ReasonNonPayment,
+		CreatedAt: now,
+	}
+	var created []Strike
+	if err := supabase.Insert(table, []Strike{s}, &created); err != nil {
+		return nil, err
+	}
+	if len(created) > 0 {
+		s = created[0]
+	}
+
+	active, err := updateProfileCount(o.BuyerID)
+	if err != nil {
+		log.Printf("Warning: failed to update strike count for %s: %v", o.BuyerID, err)
+	}
+	if active == Threshold {
+		events.Publish(events.Event{
+			Type:      events.BiddingRestricted,
+			UserID:    o.BuyerID,
+			ListingID: o.ListingID,
+			Data:      map[string]string{"title": o.Title, "count": strconv.Itoa(active)},
+		})
+	}
+	return &s, nil
+}
+
+// Get returns the strike with the given ID.
+func Get(id string) (*Strike, error) {
+	var rows []Strike
+	if err := supabase.Select(table, "id=eq."+url.QueryEscape(id), &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return &rows[0], nil
+}
+
+// History returns every strike against userID, cleared or not, newest first.
+func History(userID string) ([]Strike, error) {
+	var rows []Strike
+	query := "user_id=eq." + url.QueryEscape(userID) + "&order=created_at.desc"
+	if err := supabase.Select(table, query, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ActiveCount returns how many uncleared strikes userID has.
+func ActiveCount(userID string) (int, error) {
+	var rows []struct {
+		ID string `json:"id"`
+	}
+	query := "user_id=eq." + url.QueryEscape(userID) + "&cleared_at=is.null&select=id"
+	if err := supabase.Select(table, query, &rows); err != nil {
+		return 0, fmt.Errorf("failed to count strikes: %w", err)
+	}
+	return len(rows), nil
+}
+
+// Check returns ErrRestricted if userID holds Threshold or more active
+// strikes.
+func Check(userID string) error {
+	n, err := ActiveCount(userID)
+	if err != nil {
+		return err
+	}
+	if n >= Threshold {
+		return fmt.Errorf("%w: %d unpaid items must be cleared first", ErrRestricted, n)
+	}
+	return nil
+}
+
+// Clear lifts a strike, recording who cleared it and why. The row is kept so
+// the history stays complete.
+func Clear(id, adminID, note string, now time.Time) (*Strike, error) {
+	var updated []Strike
+	query := "id=eq." + url.QueryEscape(id) + "&cleared_at=is.null"
+	patch := map[string]interface{}{"cleared_at": now, "cleared_by": adminID, "clear_note": note}
+	if err := supabase.Update(table, query, patch, &updated); err != nil {
+		return nil, err
+	}
+	if len(updated) == 0 {
+		if _, err := Get(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrCleared
+	}
+	s := &updated[0]
+	if _, err := updateProfileCount(s.UserID); err != nil {
+		log.Printf("Warning: failed to update strike count for %s: %v", s.UserID, err)
+	}
+	return s, nil
+}
+
+// updateProfileCount stores the user's active strike count on their profile
+// and returns it.
+func updateProfileCount(userID string) (int, error) {
+	active, err := ActiveCount(userID)
+	if err != nil {
+		return 0, err
+	}
+	patch := map[string]int{"unpaid_strike_count": active}
+	return active, supabase.Update("profiles", "id=eq."+url.QueryEscape(userID), patch, nil)
+}
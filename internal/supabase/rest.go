@@ -0,0 +1,116 @@
+package supabase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// ErrNotConfigured is returned when the Supabase env vars are missing.
+var ErrNotConfigured = fmt.Errorf("Supabase env vars not set")
+
+// credentials returns the project URL and the key used for table access.
+// The service role key is preferred, falling back to the anon key.
+func credentials() (string, string, error) {
+	supaURL := os.Getenv("SUPABASE_URL")
+	apiKey := os.Getenv("SUPABASE_SERVICE_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("SUPABASE_ANON_KEY")
+	}
+	if supaURL == "" || apiKey == "" {
+		return "", "", ErrNotConfigured
+	}
+	return supaURL, apiKey, nil
+}
+
+// do sends a request to /rest/v1/{table}?{query} and decodes the JSON
+// response into out when out is non-nil.
+func do(method, table, query string, body interface{}, prefer string, out interface{}) error {
+	supaURL, apiKey, err := credentials()
+	if err != nil {
+		return err
+	}
+
+	url := supaURL + "/rest/v1/" + table
+	if query != "" {
+		url += "?" + query
+	}
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apikey", apiKey)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("supabase %s %s failed: status=%d body=%s", method, table, resp.StatusCode, string(msg))
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Select fetches rows from table matching the PostgREST query string.
+func Select(table, query string, out interface{}) error {
+	return do(http.MethodGet, table, query, nil, "", out)
+}
+
+// Insert adds rows to table. When out is non-nil the inserted rows are
+// returned and decoded into it.
+func Insert(table string, rows interface{}, out interface{}) error {
+	prefer := "return=minimal"
+	if out != nil {
+		prefer = "return=representation"
+	}
+	return do(http.MethodPost, table, "", rows, prefer, out)
+}
+
+// Upsert inserts rows, merging into existing rows on primary key conflict.
+func Upsert(table string, rows interface{}, out interface{}) error {
+	prefer := "resolution=merge-duplicates,return=minimal"
+	if out != nil {
+		prefer = "resolution=merge-duplicates,return=representation"
+	}
+	return do(http.MethodPost, table, "", rows, prefer, out)
+}
+
+// Update patches every row matching query. When out is non-nil the updated
+// rows are decoded into it.
+func Update(table, query string, patch interface{}, out interface{}) error {
+	prefer := "return=minimal"
+	if out != nil {
+		prefer = "return=representation"
+	}
+	return do(http.MethodPatch, table, query, patch, prefer, out)
+}
+
+// Delete removes every row matching query.
+func Delete(table, query string) error {
+	return do(http.MethodDelete, table, query, nil, "", nil)
+}
@@ -0,0 +1,100 @@
+package watchlist
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// ReminderWindow is how long before auction end watchers are reminded.
+const ReminderWindow = 1 * time.Hour
+
+// Reminder tells a watcher that an auction is entering its final hour.
+type Reminder struct {
+	UserID         string    `json:"user_id"`
+	ListingID      string    `json:"listing_id"`
+	Title          string    `json:"title"`
+	AuctionEndTime time.Time `json:"auction_end_time"`
+}
+
+// OnReminder is called for every reminder generated by SendReminders.
+// It defaults to logging the reminder.
+var OnReminder = func(r Reminder) {
+	log.Printf("Reminder: user %s watching listing %s (%q) ends at %s",
+		r.UserID, r.ListingID, r.Title, r.AuctionEndTime.Format(time.RFC3339))
+}
+
+// RunReminders calls SendReminders every interval until ctx is cancelled.
+func RunReminders(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := SendReminders(time.Now().UTC()); err != nil {
+				log.Printf("Warning: watchlist reminders failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Sent %d watchlist reminders", n)
+			}
+		}
+	}
+}
+
+// SendReminders generates a reminder for every watcher of an auction ending
+// within ReminderWindow of now that has not been reminded yet. Each watchlist
+// row is marked as reminded so a watcher is only reminded once per auction.
+func SendReminders(now time.Time) (int, error) {
+	var listings []struct {
+		ID             string    `json:"id"`
+		Title          string    `json:"title"`
+		AuctionEndTime time.Time `json:"auction_end_time"`
+	}
+	query := "select=id,title,auction_end_time" +
+		"&auction_end_time=gt." + url.QueryEscape(now.Format(time.RFC3339)) +
+		"&auction_end_time=lte." + url.QueryEscape(now.Add(ReminderWindow).Format(time.RFC3339))
+	if err := supabase.Select("listings", query, &listings); err != nil {
+		return 0, err
+	}
+	if len(listings) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, 0, len(listings))
+	byID := make(map[string]int, len(listings))
+	for i, l := range listings {
+		ids = append(ids, l.ID)
+		byID[l.ID] = i
+	}
+
+	var pending []Entry
+	query = "listing_id=in.(" + strings.Join(ids, ",") + ")&reminded_at=is.null"
+	if err := supabase.Select("watchlist", query, &pending); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, e := range pending {
+		l := listings[byID[e.ListingID]]
+		patch := map[string]interface{}{"reminded_at": now}
+		filter := "user_id=eq." + url.QueryEscape(e.UserID) + "&listing_id=eq." + url.QueryEscape(e.ListingID)
+		if err := supabase.Update("watchlist", filter, patch, nil); err != nil {
+			log.Printf("Warning: failed to mark reminder for %s/%s: %v", e.UserID, e.ListingID, err)
+			continue
+		}
+		OnReminder(Reminder{
+			UserID:         e.UserID,
+			ListingID:      e.ListingID,
+			Title:          l.Title,
+			AuctionEndTime: l.AuctionEndTime,
+		})
+		sent++
+	}
+	return sent, nil
+}
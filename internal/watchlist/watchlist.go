@@ -0,0 +1,80 @@
+package watchlist
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/quickswap/quickswap/internal/supabase"
+)
+
+// Entry is a single row in the Supabase watchlist table.
+type Entry struct {
+	UserID     string     `json:"user_id"`
+	ListingID  string     `json:"listing_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RemindedAt *time.Time `json:"reminded_at,omitempty"`
+}
+
+// Watch adds listingID to the user's watchlist. Watching the same listing
+// twice is not an error.
+func Watch(userID, listingID string) error {
+	row := []Entry{{UserID: userID, ListingID: listingID, CreatedAt: time.Now().UTC()}}
+	if err := supabase.Upsert("watchlist", row, nil); err != nil {
+		return fmt.Errorf("watch listing: %w", err)
+	}
+	return nil
+}
+
+// Unwatch removes listingID from the user's watchlist.
+func Unwatch(userID, listingID string) error {
+	query := "user_id=eq." + url.QueryEscape(userID) + "&listing_id=eq." + url.QueryEscape(listingID)
+	if err := supabase.Delete("watchlist", query); err != nil {
+		return fmt.Errorf("unwatch listing: %w", err)
+	}
+	return nil
+}
+
+// ForUser returns the user's watchlist, newest first.
+func ForUser(userID string) ([]Entry, error) {
+	var entries []Entry
+	query := "user_id=eq." + url.QueryEscape(userID) + "&order=created_at.desc"
+	if err := supabase.Select("watchlist", query, &entries); err != nil {
+		return nil, fmt.Errorf("fetch watchlist: %w", err)
+	}
+	return entries, nil
+}
+
+// Watchers returns the IDs of every user watching listingID.
+func Watchers(listingID string) ([]string, error) {
+	var entries []Entry
+	query := "listing_id=eq." + url.QueryEscape(listingID) + "&select=user_id"
+	if err := supabase.Select("watchlist", query, &entries); err != nil {
+		return nil, fmt.Errorf("fetch watchers: %w", err)
+	}
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.UserID)
+	}
+	return ids, nil
+}
+
+// Counts returns the number of watchers for each of the given listings.
+// Listings nobody watches are absent from the map.
+func Counts(listingIDs []string) (map[string]int, error) {
+	counts := make(map[string]int)
+	if len(listingIDs) == 0 {
+		return counts, nil
+	}
+
+	var entries []Entry
+	query := "listing_id=in.(" + strings.Join(listingIDs, ",") + ")&select=listing_id"
+	if err := supabase.Select("watchlist", query, &entries); err != nil {
+		return nil, fmt.Errorf("fetch watcher counts: %w", err)
+	}
+	for _, e := range entries {
+		counts[e.ListingID]++
+	}
+	return counts, nil
+}